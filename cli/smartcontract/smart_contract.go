@@ -2,7 +2,6 @@ package smartcontract
 
 import (
 	"bufio"
-	"bytes"
 	"context"
 	"encoding/hex"
 	"encoding/json"
@@ -15,6 +14,7 @@ import (
 
 	"github.com/ixje/neo-go-legacy/cli/flags"
 	"github.com/ixje/neo-go-legacy/pkg/compiler"
+	"github.com/ixje/neo-go-legacy/pkg/compiler/vet"
 	"github.com/ixje/neo-go-legacy/pkg/crypto/hash"
 	"github.com/ixje/neo-go-legacy/pkg/encoding/address"
 	"github.com/ixje/neo-go-legacy/pkg/rpc/client"
@@ -58,7 +58,7 @@ var (
 	}
 	sysGasFlag = flags.Fixed8Flag{
 		Name:  "sysgas, s",
-		Usage: "system fee to add to invocation transaction",
+		Usage: "system fee to add to invocation transaction (estimated by the node if not specified)",
 	}
 )
 
@@ -90,7 +90,7 @@ func NewCommands() []cli.Command {
 				Flags: []cli.Flag{
 					cli.StringFlag{
 						Name:  "in, i",
-						Usage: "Input file for the smart contract to be compiled",
+						Usage: "Input file or package directory (Go module root, for example) for the smart contract to be compiled",
 					},
 					cli.StringFlag{
 						Name:  "out, o",
@@ -112,6 +112,25 @@ func NewCommands() []cli.Command {
 						Name:  "config, c",
 						Usage: "Configuration input file (*.yml)",
 					},
+					cli.BoolFlag{
+						Name:  "no-opt",
+						Usage: "Do not optimize the bytecode",
+					},
+					cli.StringSliceFlag{
+						Name:  "standard",
+						Usage: "Check that the contract conforms to the standard (nep5) and list it in the ABI, can be repeated",
+					},
+				},
+			},
+			{
+				Name:   "vet",
+				Usage:  "report unsupported constructs and common mistakes in a smart contract",
+				Action: contractVet,
+				Flags: []cli.Flag{
+					cli.StringFlag{
+						Name:  "in, i",
+						Usage: "Input file or package directory for the smart contract to be checked",
+					},
 				},
 			},
 			{
@@ -312,7 +331,7 @@ func NewCommands() []cli.Command {
 				Flags: []cli.Flag{
 					cli.BoolFlag{
 						Name:  "compile, c",
-						Usage: "compile input file (it should be go code then)",
+						Usage: "compile input file or package directory (it should be go code then)",
 					},
 					cli.StringFlag{
 						Name:  "in, i",
@@ -389,6 +408,8 @@ func contractCompile(ctx *cli.Context) error {
 
 		DebugInfo: ctx.String("debug"),
 		ABIInfo:   abi,
+		NoOpt:     ctx.Bool("no-opt"),
+		Standards: ctx.StringSlice("standard"),
 	}
 
 	if len(confFile) != 0 {
@@ -410,6 +431,25 @@ func contractCompile(ctx *cli.Context) error {
 	return nil
 }
 
+// contractVet runs static analyzers on the contract source.
+func contractVet(ctx *cli.Context) error {
+	src := ctx.String("in")
+	if len(src) == 0 {
+		return cli.NewExitError(errNoInput, 1)
+	}
+	ds, err := vet.Run(src)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	for _, d := range ds {
+		fmt.Println(d)
+	}
+	if len(ds) != 0 {
+		return cli.NewExitError(fmt.Errorf("%d problem(s) found", len(ds)), 1)
+	}
+	return nil
+}
+
 func testInvoke(ctx *cli.Context) error {
 	return invokeInternal(ctx, false, false)
 }
@@ -513,6 +553,16 @@ func invokeInternal(ctx *cli.Context, withMethod bool, signAndPush bool) error {
 		if err != nil {
 			return cli.NewExitError(fmt.Errorf("bad script returned from the RPC node: %v", err), 1)
 		}
+		if !ctx.IsSet("sysgas") {
+			addr, err := address.StringToUint160(acc.Address)
+			if err != nil {
+				return cli.NewExitError(err, 1)
+			}
+			sysGas, err = c.EstimateSystemFee(script, []util.Uint160{addr})
+			if err != nil {
+				return cli.NewExitError(fmt.Errorf("failed to estimate system fee: %v", err), 1)
+			}
+		}
 		txHash, err := c.SignAndPushInvocationTx(script, acc, sysGas, gas)
 		if err != nil {
 			return cli.NewExitError(fmt.Errorf("failed to push invocation tx: %v", err), 1)
@@ -619,15 +669,20 @@ func inspect(ctx *cli.Context) error {
 	if len(in) == 0 {
 		return cli.NewExitError(errNoInput, 1)
 	}
-	b, err := ioutil.ReadFile(in)
-	if err != nil {
-		return cli.NewExitError(err, 1)
-	}
+	var (
+		b   []byte
+		err error
+	)
 	if compile {
-		b, err = compiler.Compile(bytes.NewReader(b))
+		b, _, err = compiler.CompilePath(in)
 		if err != nil {
 			return cli.NewExitError(errors.Wrap(err, "failed to compile"), 1)
 		}
+	} else {
+		b, err = ioutil.ReadFile(in)
+		if err != nil {
+			return cli.NewExitError(err, 1)
+		}
 	}
 	v := vm.New()
 	v.LoadScript(b)
@@ -666,7 +721,7 @@ func getAccFromContext(ctx *cli.Context) (*wallet.Account, error) {
 		return nil, cli.NewExitError(err, 1)
 	}
 	pass := strings.TrimRight(string(rawPass), "\n")
-	err = acc.Decrypt(pass)
+	err = acc.DecryptWithScrypt(pass, wall.Scrypt)
 	if err != nil {
 		return nil, cli.NewExitError(err, 1)
 	}
@@ -1,6 +1,12 @@
 package vm
 
 import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"text/tabwriter"
+
+	"github.com/ixje/neo-go-legacy/pkg/vm"
 	"github.com/urfave/cli"
 )
 
@@ -13,6 +19,35 @@ func NewCommands() []cli.Command {
 		Flags: []cli.Flag{
 			cli.BoolFlag{Name: "debug, d"},
 		},
+		Subcommands: []cli.Command{
+			{
+				Name:      "conformance",
+				Usage:     "run neo-vm JSON test suite",
+				UsageText: "neo-go vm conformance [--verbose] [--json] [--out <file>] <dir>",
+				Description: `Executes all neo-vm JSON test cases (*.json files) found in the given
+   directory (recursively) and compares the results with the ones recorded in
+   test files (obtained from the reference C# implementation). Every failed
+   test is reported with the differences between expected and actual VM state
+   and then a per-opcode summary is printed. The command fails if there is at
+   least one failed test.
+`,
+				Action: runConformance,
+				Flags: []cli.Flag{
+					cli.BoolFlag{
+						Name:  "verbose, v",
+						Usage: "print passed tests too",
+					},
+					cli.BoolFlag{
+						Name:  "json",
+						Usage: "print full report in JSON format",
+					},
+					cli.StringFlag{
+						Name:  "out, o",
+						Usage: "save full report in JSON format to the given file",
+					},
+				},
+			},
+		},
 	}}
 }
 
@@ -21,3 +56,61 @@ func startVMPrompt(ctx *cli.Context) error {
 	//return p.Run()
 	return nil
 }
+
+func runConformance(ctx *cli.Context) error {
+	if len(ctx.Args()) != 1 {
+		return cli.NewExitError("test directory should be specified as the only argument", 1)
+	}
+	report, err := vm.RunConformanceDir(ctx.Args().First())
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+
+	if out := ctx.String("out"); out != "" || ctx.Bool("json") {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return cli.NewExitError(err, 1)
+		}
+		if out != "" {
+			if err := ioutil.WriteFile(out, data, 0644); err != nil {
+				return cli.NewExitError(err, 1)
+			}
+		}
+		if ctx.Bool("json") {
+			fmt.Fprintln(ctx.App.Writer, string(data))
+			if !report.Passed() {
+				return cli.NewExitError("some tests have failed", 1)
+			}
+			return nil
+		}
+	}
+
+	for _, res := range report.Results {
+		switch {
+		case res.Passed && ctx.Bool("verbose"):
+			fmt.Fprintf(ctx.App.Writer, "PASS %s: %s/%s\n", res.File, res.Name, res.Test)
+		case !res.Passed:
+			fmt.Fprintf(ctx.App.Writer, "FAIL %s: %s/%s (step %d)\n", res.File, res.Name, res.Test, res.Step)
+			for _, d := range res.Diff {
+				fmt.Fprintf(ctx.App.Writer, "\t%s\n", d)
+			}
+		}
+	}
+
+	var passed, failed int
+	w := tabwriter.NewWriter(ctx.App.Writer, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tPASSED\tFAILED")
+	for _, s := range report.Summary() {
+		fmt.Fprintf(w, "%s\t%d\t%d\n", s.Name, s.Passed, s.Failed)
+		passed += s.Passed
+		failed += s.Failed
+	}
+	fmt.Fprintf(w, "TOTAL\t%d\t%d\n", passed, failed)
+	if err := w.Flush(); err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	if failed != 0 {
+		return cli.NewExitError(fmt.Sprintf("%d test(s) failed", failed), 1)
+	}
+	return nil
+}
@@ -0,0 +1,284 @@
+package wallet
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ixje/neo-go-legacy/cli/flags"
+	"github.com/ixje/neo-go-legacy/pkg/config"
+	"github.com/ixje/neo-go-legacy/pkg/core/transaction"
+	"github.com/ixje/neo-go-legacy/pkg/encoding/address"
+	"github.com/ixje/neo-go-legacy/pkg/rpc/client"
+	"github.com/ixje/neo-go-legacy/pkg/util"
+	"github.com/urfave/cli"
+)
+
+// unlimitedAmount is the amount of the asset without issue limit.
+const unlimitedAmount = -util.Fixed8(1)
+
+var protocolConfigFlag = cli.StringFlag{
+	Name:  "config",
+	Usage: "Protocol configuration file of the network to get system fees from",
+}
+
+func newAssetCommands() []cli.Command {
+	return []cli.Command{
+		{
+			Name:      "register",
+			Usage:     "register new UTXO asset",
+			UsageText: "register --path <path> --rpc <node> --config <file> --address <owner> --name <name> --amount <amount|unlimited> [--type <type>] [--precision <n>] [--admin <addr>] [--gas <netfee>] [--out <file>]",
+			Description: `Registers new asset with RegisterTX owned by the key of the given account,
+   the account also pays system fee (RegisterTransaction from the protocol
+   configuration) and network fee. Asset ID is the hash of the transaction.`,
+			Action: registerAsset,
+			Flags: []cli.Flag{
+				walletPathFlag,
+				rpcFlag,
+				timeoutFlag,
+				outFlag,
+				protocolConfigFlag,
+				flags.AddressFlag{
+					Name:  "address, a",
+					Usage: "Address of the owner account",
+				},
+				cli.StringFlag{
+					Name:  "name",
+					Usage: "Asset name",
+				},
+				cli.StringFlag{
+					Name:  "type",
+					Usage: "Asset type: Currency, Share, Invoice or Token",
+					Value: transaction.Token.String(),
+				},
+				cli.UintFlag{
+					Name:  "precision",
+					Usage: "Number of decimals",
+					Value: 8,
+				},
+				cli.StringFlag{
+					Name:  "amount",
+					Usage: "Total amount of the asset or 'unlimited'",
+				},
+				flags.AddressFlag{
+					Name:  "admin",
+					Usage: "Admin and issuer address (owner account by default)",
+				},
+				netFeeFlag,
+				signerFlag,
+			},
+		},
+		{
+			Name:      "issue",
+			Usage:     "issue registered UTXO asset",
+			UsageText: "issue --path <path> --rpc <node> --config <file> --address <issuer> --asset <id> --amount <amount> [--to <addr>] [--gas <netfee>] [--out <file>]",
+			Description: `Issues the asset with IssueTX, the issuer account pays system fee
+   (IssueTransaction from the protocol configuration) and network fee.`,
+			Action: issueAsset,
+			Flags: []cli.Flag{
+				walletPathFlag,
+				rpcFlag,
+				timeoutFlag,
+				outFlag,
+				protocolConfigFlag,
+				flags.AddressFlag{
+					Name:  "address, a",
+					Usage: "Address of the issuer account",
+				},
+				cli.StringFlag{
+					Name:  "asset",
+					Usage: "Asset ID",
+				},
+				cli.StringFlag{
+					Name:  "amount",
+					Usage: "Amount to issue",
+				},
+				flags.AddressFlag{
+					Name:  "to",
+					Usage: "Address to issue the asset to (issuer by default)",
+				},
+				netFeeFlag,
+				signerFlag,
+			},
+		},
+	}
+}
+
+func registerAsset(ctx *cli.Context) error {
+	reg, err := parseRegisterTX(ctx)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	sysfee, err := getSystemFee(ctx, transaction.RegisterType)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+
+	wall, err := openWallet(ctx.String("path"))
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	defer wall.Close()
+
+	acc, closeSigner, err := unlockAccount(ctx, wall)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	defer closeSigner()
+	reg.Owner = *acc.Signer().PublicKey()
+	if admin := ctx.Generic("admin").(*flags.Address); admin.IsSet {
+		reg.Admin = admin.Uint160()
+	} else {
+		reg.Admin = acc.Contract.ScriptHash()
+	}
+
+	c, cancel, err := newClient(ctx, client.Options{})
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	defer cancel()
+
+	tx, err := c.CreateRegisterTx(reg, acc.Contract.ScriptHash(), sysfee, flags.Fixed8FromContext(ctx, "gas"))
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	if err := signAndProcessTx(c, acc, tx, ctx.String("out")); err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	fmt.Println(tx.Hash().StringLE())
+	return nil
+}
+
+// parseRegisterTX creates RegisterTX from the command flags and checks it
+// the same way the node does, owner and admin are not set.
+func parseRegisterTX(ctx *cli.Context) (*transaction.RegisterTX, error) {
+	name := ctx.String("name")
+	if name == "" {
+		return nil, errors.New("asset name was not provided")
+	}
+	typ, err := transaction.AssetTypeFromString(ctx.String("type"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid asset type: %v", err)
+	}
+	switch typ {
+	case transaction.Currency, transaction.Share, transaction.Invoice, transaction.Token:
+	default:
+		return nil, fmt.Errorf("can't register %s asset", typ)
+	}
+	precision := ctx.Uint("precision")
+	if precision > 8 {
+		return nil, errors.New("precision can't be more than 8")
+	}
+
+	var amount util.Fixed8
+	switch s := ctx.String("amount"); {
+	case s == "":
+		return nil, errors.New("amount was not provided")
+	case strings.EqualFold(s, "unlimited"):
+		amount = unlimitedAmount
+	default:
+		if amount, err = util.Fixed8FromString(s); err != nil {
+			return nil, fmt.Errorf("invalid amount: %v", err)
+		} else if amount <= 0 {
+			return nil, errors.New("amount must be positive")
+		} else if amount%precisionUnit(uint8(precision)) != 0 {
+			return nil, fmt.Errorf("amount doesn't match precision %d", precision)
+		}
+	}
+	if typ == transaction.Invoice && amount != unlimitedAmount {
+		return nil, errors.New("invoice amount must be unlimited")
+	}
+	return &transaction.RegisterTX{
+		AssetType: typ,
+		Name:      name,
+		Amount:    amount,
+		Precision: uint8(precision),
+	}, nil
+}
+
+func issueAsset(ctx *cli.Context) error {
+	asset, err := util.Uint256DecodeStringLE(strings.TrimPrefix(ctx.String("asset"), "0x"))
+	if err != nil {
+		return cli.NewExitError(fmt.Errorf("invalid asset id: %v", err), 1)
+	}
+	amount, err := util.Fixed8FromString(ctx.String("amount"))
+	if err != nil {
+		return cli.NewExitError(fmt.Errorf("invalid amount: %v", err), 1)
+	} else if amount <= 0 {
+		return cli.NewExitError("amount must be positive", 1)
+	}
+	sysfee, err := getSystemFee(ctx, transaction.IssueType)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+
+	wall, err := openWallet(ctx.String("path"))
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	defer wall.Close()
+
+	acc, closeSigner, err := unlockAccount(ctx, wall)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	defer closeSigner()
+	issuer := acc.Contract.ScriptHash()
+	to := issuer
+	if toFlag := ctx.Generic("to").(*flags.Address); toFlag.IsSet {
+		to = toFlag.Uint160()
+	}
+
+	c, cancel, err := newClient(ctx, client.Options{})
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	defer cancel()
+
+	as, err := c.GetAssetState(asset)
+	if err != nil {
+		return cli.NewExitError(fmt.Errorf("can't get asset %s: %v", asset.StringLE(), err), 1)
+	}
+	if as.Issuer != address.Uint160ToString(issuer) {
+		return cli.NewExitError(fmt.Errorf("asset can only be issued by %s", as.Issuer), 1)
+	} else if amount%precisionUnit(as.Precision) != 0 {
+		return cli.NewExitError(fmt.Errorf("amount doesn't match precision %d", as.Precision), 1)
+	} else if as.Amount != unlimitedAmount && as.Available+amount > as.Amount {
+		return cli.NewExitError(fmt.Errorf("only %s can be issued", as.Amount-as.Available), 1)
+	}
+
+	outputs := []transaction.Output{*transaction.NewOutput(asset, amount, to)}
+	tx, err := c.CreateIssueTx(outputs, issuer, sysfee, flags.Fixed8FromContext(ctx, "gas"))
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	if err := signAndProcessTx(c, acc, tx, ctx.String("out")); err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	fmt.Println(tx.Hash().StringLE())
+	return nil
+}
+
+// getSystemFee returns the system fee of the transaction type from the
+// protocol configuration specified with the 'config' flag.
+func getSystemFee(ctx *cli.Context, typ transaction.TXType) (util.Fixed8, error) {
+	path := ctx.String("config")
+	if path == "" {
+		return 0, errors.New("protocol configuration file was not provided")
+	}
+	cfg, err := config.LoadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	return cfg.ProtocolConfiguration.SystemFee.TryGetValue(typ), nil
+}
+
+// precisionUnit returns the smallest amount of the asset with the given
+// precision.
+func precisionUnit(precision uint8) util.Fixed8 {
+	unit := util.Fixed8(1)
+	for i := int(precision); i < 8; i++ {
+		unit *= 10
+	}
+	return unit
+}
@@ -0,0 +1,141 @@
+package wallet
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/ixje/neo-go-legacy/pkg/core"
+	"github.com/ixje/neo-go-legacy/pkg/core/transaction"
+	"github.com/ixje/neo-go-legacy/pkg/rpc/response/result"
+	"github.com/ixje/neo-go-legacy/pkg/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAsset(t *testing.T) {
+	dir := t.TempDir()
+	walletPath, accs := newTestWallet(t, dir, 2)
+	acc := accs[0]
+	signer := newTestSigner(t, acc.PrivateKey())
+	cfgPath := filepath.Join(dir, "protocol.yml")
+	require.NoError(t, ioutil.WriteFile(cfgPath, []byte(`ProtocolConfiguration:
+  SystemFee:
+    IssueTransaction: 5
+    RegisterTransaction: 100
+`), 0644))
+
+	gas := core.UtilityTokenID()
+	asset := util.Uint256{1, 2, 3}
+	rpc := newTestRPC(t, map[string]interface{}{
+		"getunspents":        testUnspents(acc.Address, gas, util.Fixed8FromInt64(200)),
+		"sendrawtransaction": true,
+		"getassetstate": result.AssetState{
+			ID:        asset,
+			AssetType: transaction.Token,
+			Name:      "Coin",
+			Amount:    util.Fixed8FromInt64(1000),
+			Available: util.Fixed8FromInt64(900),
+			Precision: 2,
+			Issuer:    acc.Address,
+		},
+	})
+	args := []string{"--rpc", rpc.URL, "--path", walletPath, "--config", cfgPath,
+		"--address", acc.Address, "--signer", signer}
+	// withArgs returns a copy of the command arguments with the extra ones.
+	withArgs := func(cmd []string, extra ...string) []string {
+		return append(append([]string{}, cmd...), extra...)
+	}
+	register := withArgs([]string{"asset", "register", "--name", "Coin"}, args...)
+	issue := withArgs([]string{"asset", "issue", "--asset", asset.StringLE()}, args...)
+
+	t.Run("register", func(t *testing.T) {
+		out, err := runWalletCmd(t, withArgs(register, "--amount", "1000", "--precision", "2",
+			"--admin", accs[1].Address, "--gas", "1")...)
+		require.NoError(t, err)
+		tx := rpc.sentTx(t)
+		require.Equal(t, tx.Hash().StringLE()+"\n", out)
+		require.Equal(t, &transaction.RegisterTX{
+			AssetType: transaction.Token,
+			Name:      "Coin",
+			Amount:    util.Fixed8FromInt64(1000),
+			Precision: 2,
+			Owner:     *acc.PrivateKey().PublicKey(),
+			Admin:     accs[1].Contract.ScriptHash(),
+		}, tx.Data)
+		require.Equal(t, []transaction.Output{
+			{AssetID: gas, Amount: util.Fixed8FromInt64(99), ScriptHash: acc.Contract.ScriptHash()},
+		}, tx.Outputs)
+		require.Equal(t, 1, len(tx.Scripts))
+	})
+	t.Run("register unlimited", func(t *testing.T) {
+		_, err := runWalletCmd(t, withArgs(register, "--amount", "unlimited", "--type", "Invoice")...)
+		require.NoError(t, err)
+		reg := rpc.sentTx(t).Data.(*transaction.RegisterTX)
+		require.Equal(t, unlimitedAmount, reg.Amount)
+		require.Equal(t, transaction.Invoice, reg.AssetType)
+		require.Equal(t, acc.Contract.ScriptHash(), reg.Admin)
+	})
+	t.Run("issue, out, inspect and send", func(t *testing.T) {
+		txPath := filepath.Join(dir, "issue.json")
+		out, err := runWalletCmd(t, withArgs(issue, "--amount", "100", "--to", accs[1].Address, "--out", txPath)...)
+		require.NoError(t, err)
+		pc, err := readParameterContext(txPath)
+		require.NoError(t, err)
+		tx := pc.Verifiable.(*transaction.Transaction)
+		require.Equal(t, tx.Hash().StringLE()+"\n", out)
+		require.Equal(t, transaction.IssueType, tx.Type)
+		require.Equal(t, []transaction.Output{
+			{AssetID: asset, Amount: util.Fixed8FromInt64(100), ScriptHash: accs[1].Contract.ScriptHash()},
+			{AssetID: gas, Amount: util.Fixed8FromInt64(195), ScriptHash: acc.Contract.ScriptHash(), Position: 1},
+		}, tx.Outputs)
+
+		out, err = runWalletCmd(t, "tx", "inspect", "--in", txPath)
+		require.NoError(t, err)
+		require.Contains(t, out, "Type: IssueTransaction\n")
+		require.Contains(t, out, "Signatures of "+acc.Address+": 1 of 1\n")
+
+		_, err = runWalletCmd(t, "tx", "sign", "--path", walletPath, "--address", acc.Address,
+			"--in", txPath, "--signer", signer)
+		require.NoError(t, err)
+		_, err = runWalletCmd(t, "tx", "send", "--rpc", rpc.URL, "--in", txPath)
+		require.NoError(t, err)
+		require.Equal(t, tx.Hash(), rpc.sentTx(t).Hash())
+	})
+
+	testCases := []struct {
+		name   string
+		args   []string
+		errMsg string
+	}{
+		{"register, no name", withArgs([]string{"asset", "register", "--amount", "1"}, args...), "asset name was not provided"},
+		{"register, bad type", withArgs(register, "--amount", "1", "--type", "Coin"), "invalid asset type"},
+		{"register, system type", withArgs(register, "--amount", "1", "--type", "GoverningToken"), "can't register"},
+		{"register, bad precision", withArgs(register, "--amount", "1", "--precision", "9"),
+			"precision can't be more than 8"},
+		{"register, no amount", register, "amount was not provided"},
+		{"register, bad amount", withArgs(register, "--amount", "lots"), "invalid amount"},
+		{"register, negative amount", withArgs(register, "--amount", "-1"), "amount must be positive"},
+		{"register, amount precision", withArgs(register, "--amount", "1.5", "--precision", "0"),
+			"amount doesn't match precision 0"},
+		{"register, limited invoice", withArgs(register, "--amount", "1", "--type", "Invoice"),
+			"invoice amount must be unlimited"},
+		{"register, no config", []string{"asset", "register", "--name", "Coin", "--amount", "1"},
+			"protocol configuration file was not provided"},
+		{"issue, bad asset", withArgs([]string{"asset", "issue", "--asset", "xyz", "--amount", "1"}, args...),
+			"invalid asset id"},
+		{"issue, bad amount", withArgs(issue, "--amount", "some"), "invalid amount"},
+		{"issue, zero amount", withArgs(issue, "--amount", "0"), "amount must be positive"},
+		{"issue, amount precision", withArgs(issue, "--amount", "0.001"), "amount doesn't match precision 2"},
+		{"issue, too much", withArgs(issue, "--amount", "101"), "only 100 can be issued"},
+		{"issue, not issuer", []string{"asset", "issue", "--asset", asset.StringLE(), "--amount", "1",
+			"--rpc", rpc.URL, "--path", walletPath, "--config", cfgPath, "--address", accs[1].Address,
+			"--signer", newTestSigner(t, accs[1].PrivateKey())}, "asset can only be issued by " + acc.Address},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := runWalletCmd(t, tc.args...)
+			require.Error(t, err)
+			require.Contains(t, err.Error(), tc.errMsg)
+		})
+	}
+}
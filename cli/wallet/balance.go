@@ -0,0 +1,240 @@
+package wallet
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ixje/neo-go-legacy/pkg/encoding/address"
+	"github.com/ixje/neo-go-legacy/pkg/rpc/client"
+	"github.com/ixje/neo-go-legacy/pkg/rpc/response/result"
+	"github.com/ixje/neo-go-legacy/pkg/util"
+	"github.com/ixje/neo-go-legacy/pkg/wallet"
+	"github.com/urfave/cli"
+)
+
+// accountBalance is an overview of all account balances.
+type accountBalance struct {
+	Address   string            `json:"address"`
+	Label     string            `json:"label,omitempty"`
+	WatchOnly bool              `json:"watchonly"`
+	Assets    []assetBalance    `json:"assets"`
+	Unclaimed *result.Unclaimed `json:"unclaimed"`
+	Tokens    []tokenBalance    `json:"tokens"`
+}
+
+// assetBalance is a UTXO asset balance.
+type assetBalance struct {
+	Asset    util.Uint256 `json:"asset"`
+	Symbol   string       `json:"symbol"`
+	Amount   util.Fixed8  `json:"amount"`
+	Unspents int          `json:"unspents"`
+}
+
+// tokenBalance is a NEP-5 token balance, Amount is formatted according to
+// the token decimals if the token is known.
+type tokenBalance struct {
+	Hash        util.Uint160 `json:"hash"`
+	Symbol      string       `json:"symbol,omitempty"`
+	Amount      string       `json:"amount"`
+	LastUpdated uint32       `json:"lastupdatedblock"`
+}
+
+func importWatchOnly(ctx *cli.Context) error {
+	wall, err := openWallet(ctx.String("path"))
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	defer wall.Close()
+
+	s := ctx.String("address")
+	if s == "" {
+		return cli.NewExitError("address or script hash was not provided", 1)
+	}
+	h, err := parseAddressOrHash(s)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+
+	acc := wallet.NewWatchOnlyAccount(h)
+	acc.Label = ctx.String("name")
+	if err := addAccountAndSave(wall, acc); err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	fmt.Printf("watch-only account %s added\n", acc.Address)
+	return nil
+}
+
+// parseAddressOrHash parses either an address or a LE script hash
+// (optionally prefixed with 0x).
+func parseAddressOrHash(s string) (util.Uint160, error) {
+	if h, err := address.StringToUint160(s); err == nil {
+		return h, nil
+	}
+	h, err := util.Uint160DecodeStringLE(strings.TrimPrefix(s, "0x"))
+	if err != nil {
+		return h, fmt.Errorf("invalid address or script hash: %s", s)
+	}
+	return h, nil
+}
+
+func getBalance(ctx *cli.Context) error {
+	wall, err := openWallet(ctx.String("path"))
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	defer wall.Close()
+
+	accs := wall.Accounts
+	if s := ctx.String("address"); s != "" {
+		h, err := parseAddressOrHash(s)
+		if err != nil {
+			return cli.NewExitError(err, 1)
+		}
+		acc := wall.GetAccount(h)
+		if acc == nil {
+			return cli.NewExitError(fmt.Errorf("wallet contains no account for '%s'", s), 1)
+		}
+		accs = []*wallet.Account{acc}
+	}
+
+	gctx, cancel := getGoContext(ctx)
+	defer cancel()
+
+	c, err := client.New(gctx, ctx.String("rpc"), client.Options{})
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+
+	tokens := make(map[util.Uint160]*wallet.Token)
+	for _, tok := range wall.Extra.Tokens {
+		tokens[tok.Hash] = tok
+	}
+	res := make([]accountBalance, 0, len(accs))
+	for _, acc := range accs {
+		b, err := getAccountBalance(c, acc, tokens)
+		if err != nil {
+			return cli.NewExitError(fmt.Errorf("account %s: %v", acc.Address, err), 1)
+		}
+		res = append(res, *b)
+	}
+
+	if ctx.Bool("json") {
+		data, err := json.MarshalIndent(res, "", "  ")
+		if err != nil {
+			return cli.NewExitError(err, 1)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+	for i := range res {
+		printAccountBalance(&res[i])
+	}
+	return nil
+}
+
+// getAccountBalance retrieves balances of the account, tokens are used to
+// cache NEP-5 token info.
+func getAccountBalance(c *client.Client, acc *wallet.Account, tokens map[util.Uint160]*wallet.Token) (*accountBalance, error) {
+	h, err := acc.ScriptHash()
+	if err != nil {
+		return nil, err
+	}
+	res := &accountBalance{
+		Address:   acc.Address,
+		Label:     acc.Label,
+		WatchOnly: acc.IsWatchOnly(),
+		Assets:    []assetBalance{},
+		Tokens:    []tokenBalance{},
+	}
+
+	unspents, err := c.GetUnspents(acc.Address)
+	if err != nil {
+		return nil, fmt.Errorf("can't get unspents: %v", err)
+	}
+	for _, b := range unspents.Balance {
+		res.Assets = append(res.Assets, assetBalance{
+			Asset:    b.AssetHash,
+			Symbol:   b.AssetSymbol,
+			Amount:   b.Amount,
+			Unspents: len(b.Unspents),
+		})
+	}
+
+	res.Unclaimed, err = c.GetUnclaimed(acc.Address)
+	if err != nil {
+		return nil, fmt.Errorf("can't get unclaimed GAS: %v", err)
+	}
+
+	nep5, err := c.GetNEP5Balances(h)
+	if err != nil {
+		return nil, fmt.Errorf("can't get NEP5 balances: %v", err)
+	}
+	for _, b := range nep5.Balances {
+		tok, ok := tokens[b.Asset]
+		if !ok {
+			// Unknown tokens are just shown without decimals.
+			tok, _ = c.NEP5TokenInfo(b.Asset)
+			tokens[b.Asset] = tok
+		}
+		tb := tokenBalance{
+			Hash:        b.Asset,
+			Amount:      b.Amount,
+			LastUpdated: b.LastUpdated,
+		}
+		if tok != nil {
+			tb.Symbol = tok.Symbol
+			tb.Amount = formatTokenAmount(b.Amount, tok.Decimals)
+		}
+		res.Tokens = append(res.Tokens, tb)
+	}
+	return res, nil
+}
+
+// formatTokenAmount converts integer token amount to the decimal
+// representation, amount is returned as is if it can't be parsed.
+func formatTokenAmount(amount string, decimals int64) string {
+	v, ok := new(big.Int).SetString(amount, 10)
+	if !ok || decimals <= 0 {
+		return amount
+	}
+	neg := v.Sign() < 0
+	s := new(big.Int).Abs(v).String()
+	if pad := int(decimals) + 1 - len(s); pad > 0 {
+		s = strings.Repeat("0", pad) + s
+	}
+	point := len(s) - int(decimals)
+	s = s[:point] + strings.TrimRight("."+s[point:], ".0")
+	if neg {
+		s = "-" + s
+	}
+	return s
+}
+
+func printAccountBalance(b *accountBalance) {
+	fmt.Printf("Account: %s", b.Address)
+	if b.Label != "" {
+		fmt.Printf(" (%s)", b.Label)
+	}
+	if b.WatchOnly {
+		fmt.Print(" [watch-only]")
+	}
+	fmt.Println()
+	for _, a := range b.Assets {
+		name := a.Symbol
+		if name == "" {
+			name = a.Asset.StringLE()
+		}
+		fmt.Printf("\t%s: %s (%d unspents)\n", name, a.Amount, a.Unspents)
+	}
+	fmt.Printf("\tUnclaimed GAS: %s (available: %s, unavailable: %s)\n",
+		b.Unclaimed.Unclaimed, b.Unclaimed.Available, b.Unclaimed.Unavailable)
+	for _, t := range b.Tokens {
+		name := t.Symbol
+		if name == "" {
+			name = t.Hash.StringLE()
+		}
+		fmt.Printf("\t%s: %s (updated at %d)\n", name, t.Amount, t.LastUpdated)
+	}
+}
@@ -0,0 +1,133 @@
+package wallet
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ixje/neo-go-legacy/pkg/core"
+	"github.com/ixje/neo-go-legacy/pkg/crypto/keys"
+	"github.com/ixje/neo-go-legacy/pkg/encoding/address"
+	"github.com/ixje/neo-go-legacy/pkg/rpc/response/result"
+	"github.com/ixje/neo-go-legacy/pkg/util"
+	"github.com/ixje/neo-go-legacy/pkg/wallet"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBalance(t *testing.T) {
+	walletPath, accs := newTestWallet(t, t.TempDir(), 1)
+	acc := accs[0]
+	known, unknown := util.Uint160{1, 2, 3}, util.Uint160{4, 5, 6}
+	w, err := wallet.NewWalletFromFile(walletPath)
+	require.NoError(t, err)
+	w.AddToken(wallet.NewToken(known, "Token", "TKN", 3))
+	require.NoError(t, w.Save())
+	w.Close()
+
+	gas := core.UtilityTokenID()
+	unspents := testUnspents(acc.Address, gas, util.Fixed8FromInt64(10))
+	unspents.Balance[0].AssetSymbol = "GAS"
+	unclaimed := result.Unclaimed{
+		Available:   util.Fixed8FromInt64(1),
+		Unavailable: util.Fixed8FromInt64(2),
+		Unclaimed:   util.Fixed8FromInt64(3),
+	}
+	results := map[string]interface{}{
+		"getunspents":  unspents,
+		"getunclaimed": unclaimed,
+		"getnep5balances": result.NEP5Balances{Address: acc.Address, Balances: []result.NEP5Balance{
+			{Asset: known, Amount: "12345", LastUpdated: 7},
+			{Asset: unknown, Amount: "42", LastUpdated: 8},
+		}},
+	}
+	rpc := newTestRPC(t, results)
+
+	watch, err := keys.NewPrivateKey()
+	require.NoError(t, err)
+	watchAddr := address.Uint160ToString(watch.GetScriptHash())
+	t.Run("import-watch", func(t *testing.T) {
+		out, err := runWalletCmd(t, "import-watch", "--path", walletPath,
+			"--address", "0x"+watch.GetScriptHash().StringLE(), "--name", "cold")
+		require.NoError(t, err)
+		require.Equal(t, "watch-only account "+watchAddr+" added\n", out)
+
+		_, err = runWalletCmd(t, "import-watch", "--path", walletPath, "--address", watchAddr)
+		require.Error(t, err)
+		_, err = runWalletCmd(t, "import-watch", "--path", walletPath, "--address", "bad")
+		require.EqualError(t, err, "invalid address or script hash: bad")
+		_, err = runWalletCmd(t, "import-watch", "--path", walletPath)
+		require.EqualError(t, err, "address or script hash was not provided")
+	})
+
+	t.Run("text", func(t *testing.T) {
+		out, err := runWalletCmd(t, "balance", "--rpc", rpc.URL, "--path", walletPath)
+		require.NoError(t, err)
+		tokens := "\tUnclaimed GAS: 3 (available: 1, unavailable: 2)\n" +
+			"\tTKN: 12.345 (updated at 7)\n" +
+			"\t" + unknown.StringLE() + ": 42 (updated at 8)\n"
+		require.Equal(t, "Account: "+acc.Address+"\n\tGAS: 10 (1 unspents)\n"+tokens+
+			"Account: "+watchAddr+" (cold) [watch-only]\n\tGAS: 10 (1 unspents)\n"+tokens, out)
+	})
+	t.Run("json", func(t *testing.T) {
+		out, err := runWalletCmd(t, "balance", "--rpc", rpc.URL, "--path", walletPath,
+			"--address", acc.Address, "--json")
+		require.NoError(t, err)
+		var res []accountBalance
+		require.NoError(t, json.Unmarshal([]byte(out), &res))
+		require.Equal(t, []accountBalance{{
+			Address: acc.Address,
+			Assets: []assetBalance{{
+				Asset:    gas,
+				Symbol:   "GAS",
+				Amount:   util.Fixed8FromInt64(10),
+				Unspents: 1,
+			}},
+			Unclaimed: &unclaimed,
+			Tokens: []tokenBalance{
+				{Hash: known, Symbol: "TKN", Amount: "12.345", LastUpdated: 7},
+				{Hash: unknown, Amount: "42", LastUpdated: 8},
+			},
+		}}, res)
+	})
+
+	testCases := []struct {
+		name   string
+		args   []string
+		errMsg string
+	}{
+		{"no wallet", []string{"--rpc", rpc.URL}, ""},
+		{"bad address", []string{"--rpc", rpc.URL, "--path", walletPath, "--address", "bad"},
+			"invalid address or script hash"},
+		{"unknown address", []string{"--rpc", rpc.URL, "--path", walletPath,
+			"--address", util.Uint160{7}.StringLE()}, "wallet contains no account"},
+		{"RPC error", []string{"--rpc", newTestRPC(t, map[string]interface{}{
+			"getunspents": unspents,
+		}).URL, "--path", walletPath}, "can't get unclaimed GAS"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := runWalletCmd(t, append([]string{"balance"}, tc.args...)...)
+			require.Error(t, err)
+			require.Contains(t, err.Error(), tc.errMsg)
+		})
+	}
+}
+
+func TestFormatTokenAmount(t *testing.T) {
+	testCases := []struct {
+		amount   string
+		decimals int64
+		result   string
+	}{
+		{"12345", 3, "12.345"},
+		{"12000", 3, "12"},
+		{"12300", 3, "12.3"},
+		{"5", 8, "0.00000005"},
+		{"-150", 2, "-1.5"},
+		{"0", 8, "0"},
+		{"42", 0, "42"},
+		{"bad", 8, "bad"},
+	}
+	for _, tc := range testCases {
+		require.Equal(t, tc.result, formatTokenAmount(tc.amount, tc.decimals), tc.amount)
+	}
+}
@@ -0,0 +1,222 @@
+package wallet
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	gio "io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/ixje/neo-go-legacy/cli/flags"
+	"github.com/ixje/neo-go-legacy/pkg/core/state"
+	"github.com/ixje/neo-go-legacy/pkg/core/transaction"
+	"github.com/ixje/neo-go-legacy/pkg/encoding/address"
+	"github.com/ixje/neo-go-legacy/pkg/io"
+	"github.com/ixje/neo-go-legacy/pkg/rpc/client"
+	"github.com/ixje/neo-go-legacy/pkg/rpc/request"
+	"github.com/ixje/neo-go-legacy/pkg/util"
+	"github.com/ixje/neo-go-legacy/pkg/vm"
+	"github.com/ixje/neo-go-legacy/pkg/wallet"
+	"github.com/urfave/cli"
+)
+
+// maxFreeTxSize is MaxFreeTransactionSize of public networks, bigger
+// transactions need network fee to be accepted by nodes.
+const maxFreeTxSize = 1024
+
+// batchEntry is a single transfer as specified in the batch file.
+type batchEntry struct {
+	Address string `json:"address"`
+	Asset   string `json:"asset"`
+	Amount  string `json:"amount"`
+}
+
+func transferBatch(ctx *cli.Context, wall *wallet.Wallet, acc *wallet.Account, path string) error {
+	if ctx.IsSet("to") || ctx.IsSet("amount") || ctx.IsSet("asset") {
+		return cli.NewExitError("--to, --amount and --asset can't be used with --batch", 1)
+	}
+	transfers, err := readBatchFile(path)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	selector, err := getCoinSelector(ctx.String("coin-selection"))
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+
+	closeSigner, err := setAccountSigner(ctx, wall, acc)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	defer closeSigner()
+
+	gctx, cancel := getGoContext(ctx)
+	defer cancel()
+
+	c, err := client.New(gctx, ctx.String("rpc"), client.Options{})
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+
+	from := ctx.Generic("from").(*flags.Address)
+	unspents, err := getUnspents(c, from.String())
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+
+	txs, err := request.CreateBatchContractTransactions(request.BatchTxParams{
+		From:        from.Uint160(),
+		Transfers:   transfers,
+		Unspents:    unspents,
+		Attributes:  remarkAttributes(ctx),
+		MaxSize:     ctx.Int("max-size"),
+		WitnessSize: witnessSize(acc.Contract.Script),
+		Selector:    selector,
+	})
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+
+	if err := processTxs(c, acc, txs, ctx.String("out")); err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	return nil
+}
+
+// processTxs signs and processes every transaction like signAndProcessTx
+// does. If there are several transactions, the number of transaction is
+// added to the file name and printed along with its hash.
+func processTxs(c *client.Client, acc *wallet.Account, txs []*transaction.Transaction, out string) error {
+	for i, tx := range txs {
+		outFile := out
+		if out != "" && len(txs) > 1 {
+			outFile = numberedPath(out, i+1)
+		}
+		if err := signAndProcessTx(c, acc, tx, outFile); err != nil {
+			return fmt.Errorf("transaction %d: %v", i+1, err)
+		}
+		if outFile != "" && outFile != out {
+			fmt.Printf("%s (%s)\n", tx.Hash().StringLE(), outFile)
+		} else {
+			fmt.Println(tx.Hash().StringLE())
+		}
+	}
+	return nil
+}
+
+// getUnspents returns unspents of the address by asset.
+func getUnspents(c *client.Client, addr string) (map[util.Uint256][]state.UnspentBalance, error) {
+	resp, err := c.GetUnspents(addr)
+	if err != nil {
+		return nil, fmt.Errorf("can't get unspents: %v", err)
+	}
+	unspents := make(map[util.Uint256][]state.UnspentBalance, len(resp.Balance))
+	for _, b := range resp.Balance {
+		unspents[b.AssetHash] = b.Unspents
+	}
+	return unspents, nil
+}
+
+// remarkAttributes returns transaction attributes specified with remark
+// flags.
+func remarkAttributes(ctx *cli.Context) []transaction.Attribute {
+	var attrs []transaction.Attribute
+	if remark14 := ctx.String("remark14"); remark14 != "" {
+		attrs = append(attrs, transaction.Attribute{
+			Usage: transaction.Remark14,
+			Data:  []byte(remark14),
+		})
+	}
+	return attrs
+}
+
+// readBatchFile reads transfers from the JSON file (if it has .json
+// extension) or from the CSV file.
+func readBatchFile(path string) ([]request.Transfer, error) {
+	var entries []batchEntry
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, &entries); err != nil {
+			return nil, fmt.Errorf("can't parse batch file: %v", err)
+		}
+	} else {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		if entries, err = readBatchCSV(f); err != nil {
+			return nil, fmt.Errorf("can't parse batch file: %v", err)
+		}
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("no transfers in %s", path)
+	}
+
+	transfers := make([]request.Transfer, len(entries))
+	for i, e := range entries {
+		var err error
+		t := &transfers[i]
+		if t.Address, err = address.StringToUint160(e.Address); err != nil {
+			return nil, fmt.Errorf("entry %d: invalid address: %v", i+1, err)
+		}
+		if t.AssetID, err = getAssetID(e.Asset); err != nil {
+			return nil, fmt.Errorf("entry %d: invalid asset id: %v", i+1, err)
+		}
+		if t.Amount, err = util.Fixed8FromString(e.Amount); err != nil {
+			return nil, fmt.Errorf("entry %d: invalid amount: %v", i+1, err)
+		}
+	}
+	return transfers, nil
+}
+
+// readBatchCSV reads "address,asset,amount" records, the first line is
+// skipped if it's a header. Lines starting with '#' are ignored.
+func readBatchCSV(r gio.Reader) ([]batchEntry, error) {
+	cr := csv.NewReader(r)
+	cr.Comment = '#'
+	cr.FieldsPerRecord = 3
+	cr.TrimLeadingSpace = true
+	var entries []batchEntry
+	for {
+		rec, err := cr.Read()
+		if err == gio.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		for i := range rec {
+			rec[i] = strings.TrimSpace(rec[i])
+		}
+		if len(entries) == 0 && strings.EqualFold(rec[0], "address") {
+			continue
+		}
+		entries = append(entries, batchEntry{Address: rec[0], Asset: rec[1], Amount: rec[2]})
+	}
+	return entries, nil
+}
+
+// witnessSize returns the size of the witness for the verification script
+// given. For multisig scripts signatures of all keys are accounted for.
+func witnessSize(script []byte) int {
+	sigs := 1
+	if pubs, ok := vm.ParseMultiSigContract(script); ok {
+		sigs = len(pubs)
+	}
+	// Every signature is pushed with a one-byte PUSHBYTES64 opcode.
+	invocation := make([]byte, sigs*65)
+	return io.GetVarSize(invocation) + io.GetVarSize(script)
+}
+
+// numberedPath inserts the number before the file extension.
+func numberedPath(path string, n int) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "." + strconv.Itoa(n) + ext
+}
@@ -0,0 +1,67 @@
+package wallet
+
+import (
+	"testing"
+
+	"github.com/ixje/neo-go-legacy/pkg/core/state"
+	"github.com/ixje/neo-go-legacy/pkg/io"
+	"github.com/ixje/neo-go-legacy/pkg/rpc/request"
+	"github.com/ixje/neo-go-legacy/pkg/util"
+	"github.com/ixje/neo-go-legacy/pkg/wallet"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli"
+)
+
+func TestTransferBatchSize(t *testing.T) {
+	acc, err := wallet.NewAccount()
+	require.NoError(t, err)
+
+	gas := util.Uint256{2}
+	unspents := map[util.Uint256][]state.UnspentBalance{gas: nil}
+	for i := 0; i < 100; i++ {
+		unspents[gas] = append(unspents[gas], state.UnspentBalance{
+			Tx:    util.Uint256{byte(i)},
+			Value: util.Fixed8FromInt64(1),
+		})
+	}
+	transfers := make([]request.Transfer, 50)
+	for i := range transfers {
+		transfers[i] = request.Transfer{
+			AssetID: gas,
+			Address: util.Uint160{byte(i)},
+			Amount:  util.Fixed8FromFloat(1.5),
+		}
+	}
+
+	// Transactions not bigger than the free size limit are accepted
+	// without network fee which is not added to batch transactions.
+	txs, err := request.CreateBatchContractTransactions(request.BatchTxParams{
+		From:        acc.Contract.ScriptHash(),
+		Transfers:   transfers,
+		Unspents:    unspents,
+		MaxSize:     maxFreeTxSize,
+		WitnessSize: witnessSize(acc.Contract.Script),
+	})
+	require.NoError(t, err)
+	require.True(t, len(txs) > 1)
+	for _, tx := range txs {
+		require.NoError(t, acc.SignTx(tx))
+		require.True(t, io.GetVarSize(tx) <= maxFreeTxSize)
+	}
+
+	t.Run("default", func(t *testing.T) {
+		for _, cmd := range NewCommands()[0].Subcommands {
+			if cmd.Name != "transfer" && cmd.Name != "consolidate" {
+				continue
+			}
+			var found bool
+			for _, f := range cmd.Flags {
+				if f, ok := f.(cli.IntFlag); ok && f.Name == "max-size" {
+					require.Equal(t, maxFreeTxSize, f.Value, cmd.Name)
+					found = true
+				}
+			}
+			require.True(t, found, cmd.Name)
+		}
+	})
+}
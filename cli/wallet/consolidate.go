@@ -0,0 +1,141 @@
+package wallet
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/ixje/neo-go-legacy/cli/flags"
+	"github.com/ixje/neo-go-legacy/pkg/core"
+	"github.com/ixje/neo-go-legacy/pkg/core/transaction"
+	"github.com/ixje/neo-go-legacy/pkg/rpc/client"
+	"github.com/ixje/neo-go-legacy/pkg/rpc/request"
+	"github.com/ixje/neo-go-legacy/pkg/util"
+	"github.com/urfave/cli"
+)
+
+// coinSelectors are coin selection strategies by name.
+var coinSelectors = map[string]request.CoinSelector{
+	"smallest": request.SmallestFirst,
+	"largest":  request.LargestFirst,
+	"exact":    request.BranchAndBound,
+	"privacy":  request.PrivacyPreserving,
+}
+
+func getCoinSelector(name string) (request.CoinSelector, error) {
+	if name == "" {
+		return request.SmallestFirst, nil
+	}
+	sel, ok := coinSelectors[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("unknown coin selection strategy: %s", name)
+	}
+	return sel, nil
+}
+
+func consolidateUnspents(ctx *cli.Context) error {
+	wall, err := openWallet(ctx.String("path"))
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	defer wall.Close()
+
+	addrFlag := ctx.Generic("address").(*flags.Address)
+	if !addrFlag.IsSet {
+		return cli.NewExitError("address was not provided", 1)
+	}
+	acc := wall.GetAccount(addrFlag.Uint160())
+	if acc == nil {
+		return cli.NewExitError(fmt.Errorf("wallet contains no account for '%s'", addrFlag), 1)
+	}
+
+	var assets []util.Uint256
+	if s := ctx.String("asset"); s != "" {
+		asset, err := getAssetID(s)
+		if err != nil {
+			return cli.NewExitError(fmt.Errorf("invalid asset id: %v", err), 1)
+		}
+		assets = append(assets, asset)
+	}
+	var below util.Fixed8
+	if s := ctx.String("below"); s != "" {
+		if below, err = util.Fixed8FromString(s); err != nil {
+			return cli.NewExitError(fmt.Errorf("invalid amount: %v", err), 1)
+		}
+	}
+
+	closeSigner, err := setAccountSigner(ctx, wall, acc)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	defer closeSigner()
+
+	gctx, cancel := getGoContext(ctx)
+	defer cancel()
+
+	c, err := client.New(gctx, ctx.String("rpc"), client.Options{})
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	unspents, err := getUnspents(c, addrFlag.String())
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	if len(assets) == 0 {
+		for asset := range unspents {
+			assets = append(assets, asset)
+		}
+		sort.Slice(assets, func(i, j int) bool { return assets[i].CompareTo(assets[j]) < 0 })
+	}
+
+	var txs []*transaction.Transaction
+	for _, asset := range assets {
+		unit, err := assetUnit(c, asset)
+		if err != nil {
+			return cli.NewExitError(err, 1)
+		}
+		tx, err := request.CreateConsolidationTransaction(request.ConsolidateTxParams{
+			Address:     addrFlag.Uint160(),
+			AssetID:     asset,
+			Unspents:    unspents[asset],
+			Outputs:     ctx.Int("outputs"),
+			MaxValue:    below,
+			Unit:        unit,
+			Attributes:  remarkAttributes(ctx),
+			MaxSize:     ctx.Int("max-size"),
+			WitnessSize: witnessSize(acc.Contract.Script),
+		})
+		if err != nil {
+			// Other assets can still be merged.
+			if len(assets) > 1 {
+				fmt.Fprintf(os.Stderr, "asset %s: %v\n", asset.StringLE(), err)
+				continue
+			}
+			return cli.NewExitError(err, 1)
+		}
+		txs = append(txs, tx)
+	}
+	if len(txs) == 0 {
+		return cli.NewExitError("nothing to consolidate", 1)
+	}
+	if err := processTxs(c, acc, txs, ctx.String("out")); err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	return nil
+}
+
+// assetUnit returns the smallest amount of the asset according to its
+// precision.
+func assetUnit(c *client.Client, asset util.Uint256) (util.Fixed8, error) {
+	if asset.Equals(core.GoverningTokenID()) {
+		return util.Fixed8FromInt64(1), nil
+	} else if asset.Equals(core.UtilityTokenID()) {
+		return util.Fixed8(1), nil
+	}
+	as, err := c.GetAssetState(asset)
+	if err != nil {
+		return 0, fmt.Errorf("can't get asset %s: %v", asset.StringLE(), err)
+	}
+	return precisionUnit(as.Precision), nil
+}
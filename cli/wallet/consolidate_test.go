@@ -0,0 +1,107 @@
+package wallet
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ixje/neo-go-legacy/pkg/core"
+	"github.com/ixje/neo-go-legacy/pkg/core/transaction"
+	"github.com/ixje/neo-go-legacy/pkg/crypto/keys"
+	"github.com/ixje/neo-go-legacy/pkg/encoding/address"
+	"github.com/ixje/neo-go-legacy/pkg/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConsolidate(t *testing.T) {
+	dir := t.TempDir()
+	walletPath, accs := newTestWallet(t, dir, 1)
+	acc := accs[0]
+	signer := newTestSigner(t, acc.PrivateKey())
+	neo, gas := core.GoverningTokenID(), core.UtilityTokenID()
+	values := make([]util.Fixed8, 10)
+	for i := range values {
+		values[i] = util.Fixed8FromInt64(int64(i + 1))
+	}
+	unspents := testUnspents(acc.Address, gas, values...)
+	unspents.Balance = append(unspents.Balance, testUnspents(acc.Address, neo, values[:3]...).Balance...)
+	rpc := newTestRPC(t, map[string]interface{}{
+		"getunspents":        unspents,
+		"sendrawtransaction": true,
+	})
+	args := []string{"consolidate", "--rpc", rpc.URL, "--path", walletPath,
+		"--address", acc.Address, "--signer", signer}
+
+	t.Run("send", func(t *testing.T) {
+		out, err := runWalletCmd(t, append(args, "--asset", "GAS", "--outputs", "2", "--below", "10")...)
+		require.NoError(t, err)
+		tx := rpc.sentTx(t)
+		require.Equal(t, tx.Hash().StringLE()+"\n", out)
+		require.Equal(t, 9, len(tx.Inputs))
+		require.Equal(t, []transaction.Output{
+			{AssetID: gas, Amount: util.Fixed8FromFloat(22.5), ScriptHash: acc.Contract.ScriptHash()},
+			{AssetID: gas, Amount: util.Fixed8FromFloat(22.5), ScriptHash: acc.Contract.ScriptHash(), Position: 1},
+		}, tx.Outputs)
+		require.Equal(t, 1, len(tx.Scripts))
+		require.Equal(t, acc.Contract.Script, tx.Scripts[0].VerificationScript)
+	})
+	t.Run("all assets", func(t *testing.T) {
+		out, err := runWalletCmd(t, args...)
+		require.NoError(t, err)
+		require.Equal(t, 2, len(strings.Split(strings.TrimSpace(out), "\n")))
+	})
+	t.Run("out, inspect and send", func(t *testing.T) {
+		txPath := filepath.Join(dir, "consolidate.json")
+		out, err := runWalletCmd(t, append(args, "--asset", "NEO", "--out", txPath)...)
+		require.NoError(t, err)
+		pc, err := readParameterContext(txPath)
+		require.NoError(t, err)
+		tx := pc.Verifiable.(*transaction.Transaction)
+		require.Equal(t, tx.Hash().StringLE()+"\n", out)
+		require.Equal(t, []transaction.Output{
+			{AssetID: neo, Amount: util.Fixed8FromInt64(6), ScriptHash: acc.Contract.ScriptHash()},
+		}, tx.Outputs)
+
+		out, err = runWalletCmd(t, "tx", "inspect", "--in", txPath)
+		require.NoError(t, err)
+		require.Contains(t, out, "Signatures of "+acc.Address+": 1 of 1\n")
+
+		// Signature is in the context, but the witness is only added
+		// by 'tx sign'.
+		_, err = runWalletCmd(t, "tx", "send", "--rpc", rpc.URL, "--in", txPath)
+		require.Error(t, err)
+		_, err = runWalletCmd(t, "tx", "sign", "--path", walletPath, "--address", acc.Address,
+			"--in", txPath, "--signer", signer)
+		require.NoError(t, err)
+		out, err = runWalletCmd(t, "tx", "send", "--rpc", rpc.URL, "--in", txPath)
+		require.NoError(t, err)
+		require.Equal(t, tx.Hash().StringLE()+"\n", out)
+		require.Equal(t, tx.Hash(), rpc.sentTx(t).Hash())
+	})
+
+	other, err := keys.NewPrivateKey()
+	require.NoError(t, err)
+	testCases := []struct {
+		name   string
+		args   []string
+		errMsg string
+	}{
+		{"no wallet", []string{"consolidate", "--rpc", rpc.URL, "--address", acc.Address}, ""},
+		{"no address", []string{"consolidate", "--rpc", rpc.URL, "--path", walletPath}, "address was not provided"},
+		{"unknown address", []string{"consolidate", "--rpc", rpc.URL, "--path", walletPath,
+			"--address", address.Uint160ToString(other.GetScriptHash())}, "wallet contains no account"},
+		{"bad asset", append(args, "--asset", "BTC"), "invalid asset id"},
+		{"bad below", append(args, "--below", "many"), "invalid amount"},
+		{"nothing below", append(args, "--asset", "GAS", "--below", "1"), "nothing to consolidate"},
+		{"nothing for all assets", append(args, "--below", "1"), "nothing to consolidate"},
+		{"too many outputs", append(args, "--asset", "NEO", "--outputs", "3"), "nothing to consolidate"},
+		{"too small", append(args, "--asset", "GAS", "--max-size", "100"), "doesn't fit in 100 bytes"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := runWalletCmd(t, tc.args...)
+			require.Error(t, err)
+			require.Contains(t, err.Error(), tc.errMsg)
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package wallet
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ixje/neo-go-legacy/pkg/rpc/client"
+	"github.com/ixje/neo-go-legacy/pkg/util"
+	"github.com/ixje/neo-go-legacy/pkg/wallet"
+	"github.com/urfave/cli"
+)
+
+// createMnemonicAccount generates a new mnemonic and adds the first account
+// derived from it to the wallet.
+func createMnemonicAccount(wall *wallet.Wallet) error {
+	mnemonic, err := wallet.NewMnemonic()
+	if err != nil {
+		return err
+	}
+	fmt.Println("Write down the mnemonic and keep it in a safe place, it's the only way to recover your accounts:")
+	fmt.Println()
+	fmt.Println(mnemonic)
+	fmt.Println()
+	return deriveAccount(wall, mnemonic, 0)
+}
+
+// deriveAccount adds the account with the given index derived from the
+// mnemonic to the wallet.
+func deriveAccount(wall *wallet.Wallet, mnemonic string, index uint32) error {
+	acc, err := wallet.NewAccountFromMnemonic(mnemonic, "", index)
+	if err != nil {
+		return err
+	}
+	name, phrase, err := readAccountInfo()
+	if err != nil {
+		return err
+	}
+	acc.Label = name
+	if err := acc.EncryptWithScrypt(phrase, wall.Scrypt); err != nil {
+		return err
+	}
+	if err := addAccountAndSave(wall, acc); err != nil {
+		return err
+	}
+	fmt.Printf("account %s derived with path %s\n", acc.Address, acc.Extra.DerivationPath)
+	return nil
+}
+
+func readMnemonic() (string, error) {
+	mnemonic, err := readPassword("Enter mnemonic > ")
+	if err != nil {
+		return "", err
+	}
+	return strings.Join(strings.Fields(mnemonic), " "), nil
+}
+
+func recoverAccounts(ctx *cli.Context) error {
+	wall, err := openWallet(ctx.String("path"))
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	defer wall.Close()
+
+	mnemonic, err := readMnemonic()
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+
+	gctx, cancel := getGoContext(ctx)
+	defer cancel()
+	c, err := client.New(gctx, ctx.String("rpc"), client.Options{})
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+
+	now := uint32(time.Now().Unix())
+	accs, err := wallet.FindUsedAccounts(mnemonic, "", ctx.Int("gap"), func(h util.Uint160) (bool, error) {
+		txs, err := c.GetAllTransferTx(h, 0, now, 1, 0)
+		if err != nil {
+			return false, err
+		}
+		return len(txs) != 0, nil
+	})
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+
+	var added []*wallet.Account
+	for _, acc := range accs {
+		if wall.GetAccount(acc.Contract.ScriptHash()) == nil {
+			added = append(added, acc)
+		}
+	}
+	if len(added) == 0 {
+		fmt.Printf("found %d used accounts, no new ones to add\n", len(accs))
+		return nil
+	}
+
+	phrase, err := readPassword("Enter passphrase > ")
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	phraseCheck, err := readPassword("Confirm passphrase > ")
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	} else if phrase != phraseCheck {
+		return cli.NewExitError(errPhraseMismatch, 1)
+	}
+	for _, acc := range added {
+		if err := acc.EncryptWithScrypt(phrase, wall.Scrypt); err != nil {
+			return cli.NewExitError(err, 1)
+		}
+		wall.AddAccount(acc)
+		fmt.Printf("recovered account %s (%s)\n", acc.Address, acc.Extra.DerivationPath)
+	}
+	if err := wall.Save(); err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+package wallet
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/ixje/neo-go-legacy/pkg/core/state"
+	"github.com/ixje/neo-go-legacy/pkg/core/transaction"
+	"github.com/ixje/neo-go-legacy/pkg/crypto/keys"
+	"github.com/ixje/neo-go-legacy/pkg/io"
+	"github.com/ixje/neo-go-legacy/pkg/rpc/response"
+	"github.com/ixje/neo-go-legacy/pkg/rpc/response/result"
+	"github.com/ixje/neo-go-legacy/pkg/util"
+	"github.com/ixje/neo-go-legacy/pkg/wallet"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli"
+)
+
+// testRPC is a fake RPC node replying to the methods from results. Values
+// of the results are encoded as JSON, errors are returned as RPC errors and
+// all other methods are not found.
+type testRPC struct {
+	*httptest.Server
+
+	lock     sync.Mutex
+	results  map[string]interface{}
+	requests map[string][]json.RawMessage
+}
+
+func newTestRPC(t *testing.T, results map[string]interface{}) *testRPC {
+	rpc := &testRPC{
+		results:  results,
+		requests: make(map[string][]json.RawMessage),
+	}
+	rpc.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var r struct {
+			ID     json.RawMessage   `json:"id"`
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		require.NoError(t, json.NewDecoder(req.Body).Decode(&r))
+
+		rpc.lock.Lock()
+		rpc.requests[r.Method] = r.Params
+		res, ok := rpc.results[r.Method]
+		rpc.lock.Unlock()
+
+		resp := response.Raw{
+			HeaderAndError: response.HeaderAndError{
+				Header: response.Header{ID: r.ID, JSONRPC: "2.0"},
+			},
+		}
+		if !ok {
+			resp.Error = response.NewMethodNotFoundError("", nil)
+		} else if err, ok := res.(error); ok {
+			resp.Error = response.NewInternalServerError(err.Error(), nil)
+		} else {
+			data, err := json.Marshal(res)
+			require.NoError(t, err)
+			resp.Result = data
+		}
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		require.NoError(t, json.NewEncoder(w).Encode(resp))
+	}))
+	t.Cleanup(rpc.Close)
+	return rpc
+}
+
+// params returns parameters of the last request of the method.
+func (r *testRPC) params(method string) []json.RawMessage {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.requests[method]
+}
+
+// sentTx returns the transaction sent last with sendrawtransaction.
+func (r *testRPC) sentTx(t *testing.T) *transaction.Transaction {
+	params := r.params("sendrawtransaction")
+	require.Equal(t, 1, len(params))
+	var s string
+	require.NoError(t, json.Unmarshal(params[0], &s))
+	data, err := hex.DecodeString(s)
+	require.NoError(t, err)
+	tx := new(transaction.Transaction)
+	br := io.NewBinReaderFromBuf(data)
+	tx.DecodeBinary(br)
+	require.NoError(t, br.Err)
+	return tx
+}
+
+// testUnspents returns getunspents result with the unspents of the given
+// values, every unspent is the output of a different transaction.
+func testUnspents(addr string, asset util.Uint256, values ...util.Fixed8) result.Unspents {
+	b := result.UnspentBalanceInfo{AssetHash: asset}
+	for i, v := range values {
+		b.Unspents = append(b.Unspents, state.UnspentBalance{Tx: util.Uint256{byte(i + 1)}, Value: v})
+		b.Amount += v
+	}
+	return result.Unspents{Address: addr, Balance: []result.UnspentBalanceInfo{b}}
+}
+
+// newTestSigner starts the external signer with the keys given and returns
+// its address.
+func newTestSigner(t *testing.T, privs ...*keys.PrivateKey) string {
+	sock := filepath.Join(t.TempDir(), "signer.sock")
+	l, err := net.Listen("unix", sock)
+	require.NoError(t, err)
+	t.Cleanup(func() { l.Close() })
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				_ = wallet.ServeExternalSigner(conn, privs)
+			}()
+		}
+	}()
+	return "unix:" + sock
+}
+
+// newTestWallet creates the wallet with n new accounts in the directory.
+// Keys are encrypted with "pass" using weak scrypt parameters.
+func newTestWallet(t *testing.T, dir string, n int) (string, []*wallet.Account) {
+	path := filepath.Join(dir, "wallet.json")
+	scrypt := keys.ScryptParams{N: 2, R: 1, P: 1}
+	w, err := wallet.NewWalletWithScrypt(path, scrypt)
+	require.NoError(t, err)
+	accs := make([]*wallet.Account, n)
+	for i := range accs {
+		accs[i], err = wallet.NewAccount()
+		require.NoError(t, err)
+		require.NoError(t, accs[i].EncryptWithScrypt("pass", scrypt))
+		w.AddAccount(accs[i])
+	}
+	require.NoError(t, w.Save())
+	w.Close()
+	return path, accs
+}
+
+// runWalletCmd runs the wallet command with the arguments given (without
+// "wallet") and returns its standard output.
+func runWalletCmd(t *testing.T, args ...string) (string, error) {
+	app := cli.NewApp()
+	app.Commands = NewCommands()
+	app.ErrWriter = ioutil.Discard
+
+	exiter, errWriter := cli.OsExiter, cli.ErrWriter
+	cli.OsExiter, cli.ErrWriter = func(int) {}, ioutil.Discard
+	defer func() { cli.OsExiter, cli.ErrWriter = exiter, errWriter }()
+
+	out, err := ioutil.TempFile(t.TempDir(), "stdout")
+	require.NoError(t, err)
+	defer out.Close()
+	stdout := os.Stdout
+	os.Stdout = out
+	err = app.Run(append([]string{"neo-go", "wallet"}, args...))
+	os.Stdout = stdout
+
+	data, rerr := ioutil.ReadFile(out.Name())
+	require.NoError(t, rerr)
+	return string(data), err
+}
@@ -0,0 +1,461 @@
+package wallet
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ixje/neo-go-legacy/cli/flags"
+	"github.com/ixje/neo-go-legacy/pkg/core"
+	"github.com/ixje/neo-go-legacy/pkg/rpc/client"
+	"github.com/ixje/neo-go-legacy/pkg/rpc/response/result"
+	"github.com/ixje/neo-go-legacy/pkg/util"
+	"github.com/ixje/neo-go-legacy/pkg/wallet"
+	"github.com/urfave/cli"
+)
+
+// Transfer directions of history entries.
+const (
+	directionIn  = "in"
+	directionOut = "out"
+)
+
+// historyEntry is a single UTXO or NEP-5 transfer of the account. Fees are
+// only known for the history retrieved with getalltransfertx and they are
+// reported once per transaction.
+type historyEntry struct {
+	Account      string       `json:"account"`
+	TxID         util.Uint256 `json:"txid"`
+	Block        uint32       `json:"block_index"`
+	Timestamp    uint32       `json:"timestamp"`
+	Kind         string       `json:"kind"`
+	Direction    string       `json:"direction"`
+	Asset        string       `json:"asset"`
+	Symbol       string       `json:"symbol,omitempty"`
+	Amount       string       `json:"amount"`
+	Counterparty string       `json:"counterparty,omitempty"`
+	SystemFee    string       `json:"sys_fee,omitempty"`
+	NetworkFee   string       `json:"net_fee,omitempty"`
+}
+
+var historyCSVHeader = []string{"account", "txid", "block_index", "timestamp", "time",
+	"kind", "direction", "asset", "symbol", "amount", "counterparty", "sys_fee", "net_fee"}
+
+// historyQuery contains RPC parameters for history requests.
+type historyQuery struct {
+	start, end  uint32
+	limit, page int
+}
+
+// historyAssets resolves and caches asset names and token decimals.
+type historyAssets struct {
+	c      *client.Client
+	tokens map[util.Uint160]*wallet.Token
+}
+
+func getHistory(ctx *cli.Context) error {
+	kind := ctx.String("kind")
+	if kind != "all" && kind != "utxo" && kind != "nep5" {
+		return cli.NewExitError(fmt.Errorf("unknown transfer kind %q", kind), 1)
+	}
+	format := ctx.String("format")
+	if format != "text" && format != "json" && format != "csv" {
+		return cli.NewExitError(fmt.Errorf("unknown output format %q", format), 1)
+	}
+	q, err := getHistoryQuery(ctx)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+
+	wall, err := openWallet(ctx.String("path"))
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	defer wall.Close()
+
+	accs := wall.Accounts
+	if addr := ctx.Generic("address").(*flags.Address); addr.IsSet {
+		acc := wall.GetAccount(addr.Uint160())
+		if acc == nil {
+			return cli.NewExitError(fmt.Errorf("wallet contains no account for '%s'", addr), 1)
+		}
+		accs = []*wallet.Account{acc}
+	}
+
+	gctx, cancel := getGoContext(ctx)
+	defer cancel()
+
+	c, err := client.New(gctx, ctx.String("rpc"), client.Options{})
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+
+	assets := &historyAssets{c: c, tokens: make(map[util.Uint160]*wallet.Token)}
+	for _, tok := range wall.Extra.Tokens {
+		assets.tokens[tok.Hash] = tok
+	}
+	var res []historyEntry
+	for _, acc := range accs {
+		var entries []historyEntry
+		switch kind {
+		case "all":
+			entries, err = getAllTransfersHistory(c, acc, q, assets)
+		case "utxo":
+			entries, err = getUTXOHistory(c, acc, q)
+		case "nep5":
+			entries, err = getNEP5History(c, acc, q, assets)
+		}
+		if err != nil {
+			return cli.NewExitError(fmt.Errorf("account %s: %v", acc.Address, err), 1)
+		}
+		res = append(res, entries...)
+	}
+	// Newest transfers go first just like in RPC results.
+	sort.SliceStable(res, func(i, j int) bool {
+		return res[i].Timestamp > res[j].Timestamp
+	})
+
+	var w io.Writer = os.Stdout
+	if out := ctx.String("out"); out != "" {
+		f, err := os.Create(out)
+		if err != nil {
+			return cli.NewExitError(err, 1)
+		}
+		defer f.Close()
+		w = f
+	}
+	switch format {
+	case "json":
+		err = writeHistoryJSON(w, res)
+	case "csv":
+		err = writeHistoryCSV(w, res)
+	default:
+		err = writeHistoryText(w, res)
+	}
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	return nil
+}
+
+// getHistoryQuery returns RPC parameters from the command flags, time frame
+// defaults to the last week like it does in RPC server.
+func getHistoryQuery(ctx *cli.Context) (*historyQuery, error) {
+	now := time.Now()
+	q := &historyQuery{
+		start: uint32(now.Add(-time.Hour * 24 * 7).Unix()),
+		end:   uint32(now.Unix()),
+		limit: ctx.Int("limit"),
+		page:  ctx.Int("page"),
+	}
+	var err error
+	if s := ctx.String("start"); s != "" {
+		if q.start, err = parseHistoryTime(s); err != nil {
+			return nil, fmt.Errorf("invalid start time: %v", err)
+		}
+	}
+	if s := ctx.String("end"); s != "" {
+		if q.end, err = parseHistoryTime(s); err != nil {
+			return nil, fmt.Errorf("invalid end time: %v", err)
+		}
+	}
+	if q.start > q.end {
+		return nil, errors.New("start time is after the end time")
+	}
+	if q.limit <= 0 {
+		return nil, errors.New("limit must be positive")
+	}
+	if q.page < 0 {
+		return nil, errors.New("page can't be negative")
+	}
+	return q, nil
+}
+
+// parseHistoryTime parses Unix timestamp, date or RFC 3339 time.
+func parseHistoryTime(s string) (uint32, error) {
+	if ts, err := strconv.ParseUint(s, 10, 32); err == nil {
+		return uint32(ts), nil
+	}
+	for _, layout := range []string{"2006-01-02", time.RFC3339} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return uint32(t.Unix()), nil
+		}
+	}
+	return 0, fmt.Errorf("%q is neither Unix timestamp nor YYYY-MM-DD or RFC 3339 time", s)
+}
+
+// getAllTransfersHistory retrieves UTXO and NEP-5 transfers with getalltransfertx.
+// UTXO transfers are derived from transaction inputs and outputs, so that the
+// change is not counted as incoming transfer.
+func getAllTransfersHistory(c *client.Client, acc *wallet.Account, q *historyQuery, assets *historyAssets) ([]historyEntry, error) {
+	h, err := acc.ScriptHash()
+	if err != nil {
+		return nil, err
+	}
+	txes, err := c.GetAllTransferTx(h, q.start, q.end, q.limit, q.page)
+	if err != nil {
+		return nil, fmt.Errorf("can't get transfers: %v", err)
+	}
+	var res []historyEntry
+	for i := range txes {
+		tx := &txes[i]
+		first := len(res)
+		entries, err := utxoTransferEntries(acc.Address, tx)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, entries...)
+		for _, ev := range tx.Events {
+			e := newHistoryEntry(acc.Address, tx.TxID, tx.Index, tx.Timestamp, "nep5")
+			e.Direction = directionIn
+			if ev.Type == "send" {
+				e.Direction = directionOut
+			}
+			e.Counterparty = ev.Address
+			e.Asset = ev.Asset
+			e.Amount = ev.Value
+			if u, err := util.Uint160DecodeStringLE(ev.Asset); err == nil {
+				e.Symbol, e.Amount = assets.token(u, ev.Value)
+			}
+			res = append(res, e)
+		}
+		if len(res) > first {
+			res[first].SystemFee = tx.SystemFee
+			res[first].NetworkFee = tx.NetworkFee
+		}
+	}
+	return res, nil
+}
+
+// utxoTransferEntries returns entries for UTXO assets transferred to or from
+// the address in the transaction, one for every asset.
+func utxoTransferEntries(addr string, tx *result.TransferTx) ([]historyEntry, error) {
+	var (
+		assets  []string
+		amounts = make(map[string]util.Fixed8)
+		senders = make(map[string][]string)
+		recvs   = make(map[string][]string)
+	)
+	for _, el := range tx.Elements {
+		v, err := util.Fixed8FromString(el.Value)
+		if err != nil {
+			return nil, fmt.Errorf("bad transfer amount %q: %v", el.Value, err)
+		}
+		if _, ok := amounts[el.Asset]; !ok {
+			assets = append(assets, el.Asset)
+			amounts[el.Asset] = 0
+		}
+		switch {
+		case el.Type == "input" && el.Address == addr:
+			amounts[el.Asset] -= v
+		case el.Type == "input":
+			senders[el.Asset] = appendUnique(senders[el.Asset], el.Address)
+		case el.Address == addr:
+			amounts[el.Asset] += v
+		default:
+			recvs[el.Asset] = appendUnique(recvs[el.Asset], el.Address)
+		}
+	}
+	var res []historyEntry
+	for _, asset := range assets {
+		amount := amounts[asset]
+		if amount == 0 {
+			continue
+		}
+		e := newHistoryEntry(addr, tx.TxID, tx.Index, tx.Timestamp, "utxo")
+		e.Asset = asset
+		if amount > 0 {
+			e.Direction = directionIn
+			e.Counterparty = strings.Join(senders[asset], ";")
+		} else {
+			e.Direction = directionOut
+			e.Counterparty = strings.Join(recvs[asset], ";")
+			amount = -amount
+		}
+		e.Amount = amount.String()
+		if id, err := util.Uint256DecodeStringLE(asset); err == nil {
+			e.Symbol = utxoAssetSymbol(id)
+		}
+		res = append(res, e)
+	}
+	return res, nil
+}
+
+// getUTXOHistory retrieves UTXO transfers with getutxotransfers, they have
+// no counterparties and fees.
+func getUTXOHistory(c *client.Client, acc *wallet.Account, q *historyQuery) ([]historyEntry, error) {
+	trs, err := c.GetUTXOTransfers(acc.Address, &q.start, &q.end, &q.limit, &q.page)
+	if err != nil {
+		return nil, fmt.Errorf("can't get UTXO transfers: %v", err)
+	}
+	var res []historyEntry
+	add := func(assets []result.AssetUTXO, dir string) {
+		for _, a := range assets {
+			for _, tr := range a.Transactions {
+				e := newHistoryEntry(acc.Address, tr.TxHash, tr.Index, tr.Timestamp, "utxo")
+				e.Direction = dir
+				e.Asset = a.AssetHash.StringLE()
+				e.Symbol = a.AssetName
+				e.Amount = util.Fixed8(tr.Amount).String()
+				res = append(res, e)
+			}
+		}
+	}
+	add(trs.Received, directionIn)
+	add(trs.Sent, directionOut)
+	return res, nil
+}
+
+// getNEP5History retrieves NEP-5 transfers with getnep5transfers, they have
+// no fees.
+func getNEP5History(c *client.Client, acc *wallet.Account, q *historyQuery, assets *historyAssets) ([]historyEntry, error) {
+	trs, err := c.GetNEP5Transfers(acc.Address, &q.start, &q.end, &q.limit, &q.page)
+	if err != nil {
+		return nil, fmt.Errorf("can't get NEP5 transfers: %v", err)
+	}
+	var res []historyEntry
+	add := func(transfers []result.NEP5Transfer, dir string) {
+		for _, tr := range transfers {
+			e := newHistoryEntry(acc.Address, tr.TxHash, tr.Index, tr.Timestamp, "nep5")
+			e.Direction = dir
+			e.Asset = tr.Asset.StringLE()
+			e.Counterparty = tr.Address
+			e.Symbol, e.Amount = assets.token(tr.Asset, tr.Amount)
+			res = append(res, e)
+		}
+	}
+	add(trs.Received, directionIn)
+	add(trs.Sent, directionOut)
+	return res, nil
+}
+
+func newHistoryEntry(addr string, txid util.Uint256, index, ts uint32, kind string) historyEntry {
+	return historyEntry{
+		Account:   addr,
+		TxID:      txid,
+		Block:     index,
+		Timestamp: ts,
+		Kind:      kind,
+	}
+}
+
+// token returns the symbol of the token and the amount formatted according
+// to its decimals. Unknown tokens are just shown without decimals.
+func (a *historyAssets) token(h util.Uint160, amount string) (string, string) {
+	tok, ok := a.tokens[h]
+	if !ok {
+		tok, _ = a.c.NEP5TokenInfo(h)
+		a.tokens[h] = tok
+	}
+	if tok == nil {
+		return "", amount
+	}
+	return tok.Symbol, formatTokenAmount(amount, tok.Decimals)
+}
+
+// utxoAssetSymbol returns symbol for the governing and utility tokens.
+func utxoAssetSymbol(id util.Uint256) string {
+	switch id {
+	case core.GoverningTokenID():
+		return "NEO"
+	case core.UtilityTokenID():
+		return "GAS"
+	default:
+		return ""
+	}
+}
+
+func appendUnique(ss []string, s string) []string {
+	for i := range ss {
+		if ss[i] == s {
+			return ss
+		}
+	}
+	return append(ss, s)
+}
+
+func writeHistoryJSON(w io.Writer, entries []historyEntry) error {
+	if entries == nil {
+		entries = []historyEntry{}
+	}
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(data))
+	return err
+}
+
+func writeHistoryCSV(w io.Writer, entries []historyEntry) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(historyCSVHeader); err != nil {
+		return err
+	}
+	for _, e := range entries {
+		err := cw.Write([]string{
+			e.Account,
+			e.TxID.StringLE(),
+			strconv.FormatUint(uint64(e.Block), 10),
+			strconv.FormatUint(uint64(e.Timestamp), 10),
+			formatHistoryTime(e.Timestamp),
+			e.Kind,
+			e.Direction,
+			e.Asset,
+			e.Symbol,
+			e.Amount,
+			e.Counterparty,
+			e.SystemFee,
+			e.NetworkFee,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func writeHistoryText(w io.Writer, entries []historyEntry) error {
+	if len(entries) == 0 {
+		_, err := fmt.Fprintln(w, "no transfers found")
+		return err
+	}
+	for _, e := range entries {
+		name := e.Symbol
+		if name == "" {
+			name = e.Asset
+		}
+		party := "from"
+		if e.Direction == directionOut {
+			party = "to"
+		}
+		if e.Counterparty == "" {
+			party = ""
+		} else {
+			party = fmt.Sprintf(" %s %s", party, e.Counterparty)
+		}
+		_, err := fmt.Fprintf(w, "%s  %s  %-3s %s %s%s (%s, block %d)\n",
+			formatHistoryTime(e.Timestamp), e.Account, e.Direction, e.Amount, name,
+			party, e.TxID.StringLE(), e.Block)
+		if err != nil {
+			return err
+		}
+		if e.SystemFee != "" || e.NetworkFee != "" {
+			if _, err := fmt.Fprintf(w, "\tfees: system %s, network %s\n", e.SystemFee, e.NetworkFee); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func formatHistoryTime(ts uint32) string {
+	return time.Unix(int64(ts), 0).UTC().Format(time.RFC3339)
+}
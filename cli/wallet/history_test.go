@@ -0,0 +1,187 @@
+package wallet
+
+import (
+	"bytes"
+	"encoding/csv"
+	"testing"
+	"time"
+
+	"github.com/ixje/neo-go-legacy/pkg/core"
+	"github.com/ixje/neo-go-legacy/pkg/rpc/response/result"
+	"github.com/ixje/neo-go-legacy/pkg/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUTXOTransferEntries(t *testing.T) {
+	const (
+		own   = "AKkkumHbBipZ46UMZJoFynJMXzSRnBvKcs"
+		other = "ALq7AWrhAueN6mJNqk6FHJjnsEoPRytLdW"
+		third = "ALfnhLg7rUyL6Jr98bzzoxz5J7m64fbR4s"
+	)
+	neo := core.GoverningTokenID().StringLE()
+	gas := core.UtilityTokenID().StringLE()
+	el := func(typ, addr, value, asset string) result.TransferTxEvent {
+		return result.TransferTxEvent{Type: typ, Address: addr, Value: value, Asset: asset}
+	}
+	testCases := []struct {
+		name     string
+		elements []result.TransferTxEvent
+		expected []historyEntry
+	}{
+		{
+			name: "outgoing with change",
+			elements: []result.TransferTxEvent{
+				el("input", own, "10", neo),
+				el("output", other, "3", neo),
+				el("output", own, "7", neo),
+			},
+			expected: []historyEntry{{Direction: directionOut, Asset: neo, Symbol: "NEO",
+				Amount: "3", Counterparty: other}},
+		},
+		{
+			name: "incoming",
+			elements: []result.TransferTxEvent{
+				el("input", other, "5", gas),
+				el("input", third, "1", gas),
+				el("output", own, "4.5", gas),
+				el("output", other, "1.5", gas),
+			},
+			expected: []historyEntry{{Direction: directionIn, Asset: gas, Symbol: "GAS",
+				Amount: "4.5", Counterparty: other + ";" + third}},
+		},
+		{
+			name: "self transfer",
+			elements: []result.TransferTxEvent{
+				el("input", own, "10", neo),
+				el("output", own, "10", neo),
+			},
+		},
+		{
+			name: "several assets",
+			elements: []result.TransferTxEvent{
+				el("input", own, "10", neo),
+				el("input", own, "1", gas),
+				el("output", other, "10", neo),
+				el("output", own, "0.9", gas),
+			},
+			expected: []historyEntry{
+				{Direction: directionOut, Asset: neo, Symbol: "NEO", Amount: "10", Counterparty: other},
+				{Direction: directionOut, Asset: gas, Symbol: "GAS", Amount: "0.1"},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tx := &result.TransferTx{
+				TxID:      util.Uint256{1, 2, 3},
+				Timestamp: 123,
+				Index:     42,
+				Elements:  tc.elements,
+			}
+			for i := range tc.expected {
+				tc.expected[i].Account = own
+				tc.expected[i].TxID = tx.TxID
+				tc.expected[i].Block = tx.Index
+				tc.expected[i].Timestamp = tx.Timestamp
+				tc.expected[i].Kind = "utxo"
+			}
+			actual, err := utxoTransferEntries(own, tx)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, actual)
+		})
+	}
+
+	t.Run("bad amount", func(t *testing.T) {
+		tx := &result.TransferTx{Elements: []result.TransferTxEvent{el("input", own, "abc", neo)}}
+		_, err := utxoTransferEntries(own, tx)
+		require.Error(t, err)
+	})
+}
+
+func TestParseHistoryTime(t *testing.T) {
+	testCases := []struct {
+		in       string
+		expected uint32
+		fail     bool
+	}{
+		{in: "1591012800", expected: 1591012800},
+		{in: "2020-06-01", expected: 1590969600},
+		{in: "2020-06-01T12:00:00Z", expected: 1591012800},
+		{in: "2020-06-01T15:00:00+03:00", expected: 1591012800},
+		{in: "", fail: true},
+		{in: "-1", fail: true},
+		{in: "4294967296", fail: true},
+		{in: "01.06.2020", fail: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.in, func(t *testing.T) {
+			actual, err := parseHistoryTime(tc.in)
+			if tc.fail {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func TestWriteHistoryCSV(t *testing.T) {
+	ts := uint32(time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC).Unix())
+	testCases := []struct {
+		name     string
+		entries  []historyEntry
+		expected [][]string
+	}{
+		{
+			name:     "empty",
+			expected: [][]string{historyCSVHeader},
+		},
+		{
+			name: "entries",
+			entries: []historyEntry{
+				{
+					Account:      "AKkkumHbBipZ46UMZJoFynJMXzSRnBvKcs",
+					TxID:         util.Uint256{1},
+					Block:        42,
+					Timestamp:    ts,
+					Kind:         "utxo",
+					Direction:    directionOut,
+					Asset:        "asset",
+					Symbol:       "NEO",
+					Amount:       "3",
+					Counterparty: "one;two",
+					SystemFee:    "0",
+					NetworkFee:   "0.001",
+				},
+				{
+					Account:   "AKkkumHbBipZ46UMZJoFynJMXzSRnBvKcs",
+					TxID:      util.Uint256{2},
+					Block:     43,
+					Timestamp: ts + 15,
+					Kind:      "nep5",
+					Direction: directionIn,
+					Asset:     "token, with comma",
+					Amount:    "1.5",
+				},
+			},
+			expected: [][]string{
+				historyCSVHeader,
+				{"AKkkumHbBipZ46UMZJoFynJMXzSRnBvKcs", util.Uint256{1}.StringLE(), "42", "1591012800",
+					"2020-06-01T12:00:00Z", "utxo", "out", "asset", "NEO", "3", "one;two", "0", "0.001"},
+				{"AKkkumHbBipZ46UMZJoFynJMXzSRnBvKcs", util.Uint256{2}.StringLE(), "43", "1591012815",
+					"2020-06-01T12:00:15Z", "nep5", "in", "token, with comma", "", "1.5", "", "", ""},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			buf := bytes.NewBuffer(nil)
+			require.NoError(t, writeHistoryCSV(buf, tc.entries))
+
+			actual, err := csv.NewReader(buf).ReadAll()
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, actual)
+		})
+	}
+}
@@ -29,6 +29,7 @@ func newMultisigCommands() []cli.Command {
 					Name:  "addr",
 					Usage: "Address to use",
 				},
+				signerFlag,
 			},
 		},
 	}
@@ -60,17 +61,13 @@ func signMultisig(ctx *cli.Context) error {
 		return cli.NewExitError("verifiable item is not a transaction", 1)
 	}
 	printTxInfo(tx)
-	fmt.Println("Enter password to unlock wallet and sign the transaction")
-	pass, err := readPassword("Password > ")
+	closeSigner, err := setAccountSigner(ctx, wall, acc)
 	if err != nil {
-		return cli.NewExitError(err, 1)
-	} else if err := acc.Decrypt(pass); err != nil {
 		return cli.NewExitError(fmt.Errorf("can't unlock an account: %v", err), 1)
 	}
+	defer closeSigner()
 
-	priv := acc.PrivateKey()
-	sign := priv.Sign(tx.GetSignedPart())
-	if err := c.AddSignature(acc.Contract, priv.PublicKey(), sign); err != nil {
+	if err := c.Sign(acc.Contract, acc.Signer()); err != nil {
 		return cli.NewExitError(fmt.Errorf("can't add signature: %v", err), 1)
 	} else if err := writeParameterContext(c, ctx.String("out")); err != nil {
 		return cli.NewExitError(err, 1)
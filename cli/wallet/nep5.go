@@ -95,8 +95,9 @@ func newNEP5Commands() []cli.Command {
 				},
 				flags.Fixed8Flag{
 					Name:  "gas",
-					Usage: "Amount of GAS to attach to a tx",
+					Usage: "Amount of GAS to attach to a tx (estimated by the node if not specified)",
 				},
+				signerFlag,
 			},
 		},
 	}
@@ -334,12 +335,18 @@ func transferNEP5(ctx *cli.Context) error {
 	}
 
 	gas := flags.Fixed8FromContext(ctx, "gas")
+	if !ctx.IsSet("gas") {
+		gas, err = c.EstimateNEP5TransferFee(from, to, token, amount)
+		if err != nil {
+			return cli.NewExitError(fmt.Errorf("can't estimate system fee: %v", err), 1)
+		}
+	}
 
-	if pass, err := readPassword("Password > "); err != nil {
-		return cli.NewExitError(err, 1)
-	} else if err := acc.Decrypt(pass); err != nil {
+	closeSigner, err := setAccountSigner(ctx, wall, acc)
+	if err != nil {
 		return cli.NewExitError(err, 1)
 	}
+	defer closeSigner()
 
 	hash, err := c.TransferNEP5(acc, to, token, amount, gas)
 	if err != nil {
@@ -0,0 +1,125 @@
+package wallet
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ixje/neo-go-legacy/cli/flags"
+	"github.com/ixje/neo-go-legacy/pkg/crypto/keys"
+	"github.com/ixje/neo-go-legacy/pkg/wallet"
+	"github.com/urfave/cli"
+)
+
+// backupSuffix is appended to the wallet path to get the location of the
+// previous wallet version when the wallet file is rewritten.
+const backupSuffix = ".bak"
+
+var scryptFlags = []cli.Flag{
+	cli.IntFlag{
+		Name:  "scrypt-n",
+		Usage: "scrypt CPU/memory cost parameter (power of 2)",
+		Value: keys.NEP2ScryptParams().N,
+	},
+	cli.IntFlag{
+		Name:  "scrypt-r",
+		Usage: "scrypt block size parameter",
+		Value: keys.NEP2ScryptParams().R,
+	},
+	cli.IntFlag{
+		Name:  "scrypt-p",
+		Usage: "scrypt parallelization parameter",
+		Value: keys.NEP2ScryptParams().P,
+	},
+}
+
+func changePassword(ctx *cli.Context) error {
+	wall, err := openWallet(ctx.String("path"))
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	defer wall.Close()
+
+	var accs []*wallet.Account
+	if addrFlag := ctx.Generic("address").(*flags.Address); addrFlag.IsSet {
+		acc := wall.GetAccount(addrFlag.Uint160())
+		if acc == nil {
+			return cli.NewExitError(fmt.Errorf("wallet contains no account for '%s'", addrFlag), 1)
+		} else if acc.IsWatchOnly() {
+			return cli.NewExitError(fmt.Errorf("account %s is watch-only", acc.Address), 1)
+		}
+		accs = []*wallet.Account{acc}
+	} else {
+		accs = wall.Accounts
+	}
+
+	oldPass, err := readPassword("Enter current password > ")
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	newPass, err := readPassword("Enter new password > ")
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	newPassCheck, err := readPassword("Confirm new password > ")
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	} else if newPass != newPassCheck {
+		return cli.NewExitError(errPhraseMismatch, 1)
+	}
+
+	if err := wall.ChangePassword(accs, oldPass, newPass); err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	return rewriteWallet(wall)
+}
+
+func upgradeScrypt(ctx *cli.Context) error {
+	params, err := getScryptParams(ctx)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	wall, err := openWallet(ctx.String("path"))
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	defer wall.Close()
+
+	pass, err := readPassword("Enter wallet password > ")
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	if err := wall.ChangeScrypt(pass, params); err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	return rewriteWallet(wall)
+}
+
+// rewriteWallet atomically saves the wallet keeping the previous version
+// next to it.
+func rewriteWallet(wall *wallet.Wallet) error {
+	backup := wall.Path() + backupSuffix
+	if err := wall.Rewrite(backup); err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	fmt.Printf("wallet updated, previous version is saved to %s\n", backup)
+	return nil
+}
+
+// getScryptParams returns scrypt parameters specified by scryptFlags.
+func getScryptParams(ctx *cli.Context) (keys.ScryptParams, error) {
+	params := keys.ScryptParams{
+		N: ctx.Int("scrypt-n"),
+		R: ctx.Int("scrypt-r"),
+		P: ctx.Int("scrypt-p"),
+	}
+	if params.N <= 1 || params.N&(params.N-1) != 0 {
+		return params, errors.New("scrypt N must be a power of 2 greater than 1")
+	}
+	if params.R <= 0 || params.P <= 0 {
+		return params, errors.New("scrypt r and p must be positive")
+	}
+	if uint64(params.R)*uint64(params.P) >= 1<<30 {
+		return params, errors.New("scrypt r*p is too large")
+	}
+	return params, nil
+}
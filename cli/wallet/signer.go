@@ -0,0 +1,40 @@
+package wallet
+
+import (
+	"github.com/ixje/neo-go-legacy/pkg/wallet"
+	"github.com/urfave/cli"
+)
+
+var signerFlag = cli.StringFlag{
+	Name:  "signer",
+	Usage: "External signer (unix:<socket>, tcp:<host:port> or exec:<command>) holding account key",
+}
+
+// setAccountSigner prepares the account for signing. It uses the external
+// signer given with --signer flag if it's set and decrypts account key with
+// the password read from the terminal otherwise. The function returned
+// closes the external signer connection.
+func setAccountSigner(ctx *cli.Context, wall *wallet.Wallet, acc *wallet.Account) (func(), error) {
+	addr := ctx.String("signer")
+	if addr == "" {
+		pass, err := readPassword("Enter wallet password > ")
+		if err != nil {
+			return nil, err
+		} else if err := acc.DecryptWithScrypt(pass, wall.Scrypt); err != nil {
+			return nil, err
+		}
+		return func() {}, nil
+	}
+
+	s, err := wallet.DialExternalSigner(addr)
+	if err != nil {
+		return nil, err
+	}
+	signer, err := s.SignerFor(acc)
+	if err != nil {
+		s.Close()
+		return nil, err
+	}
+	acc.SetSigner(signer)
+	return func() { s.Close() }, nil
+}
@@ -0,0 +1,614 @@
+package wallet
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"unicode/utf8"
+
+	"github.com/ixje/neo-go-legacy/cli/flags"
+	"github.com/ixje/neo-go-legacy/pkg/core/transaction"
+	"github.com/ixje/neo-go-legacy/pkg/crypto/hash"
+	"github.com/ixje/neo-go-legacy/pkg/encoding/address"
+	"github.com/ixje/neo-go-legacy/pkg/rpc/client"
+	"github.com/ixje/neo-go-legacy/pkg/rpc/request"
+	"github.com/ixje/neo-go-legacy/pkg/smartcontract"
+	"github.com/ixje/neo-go-legacy/pkg/smartcontract/context"
+	"github.com/ixje/neo-go-legacy/pkg/util"
+	"github.com/ixje/neo-go-legacy/pkg/vm"
+	"github.com/ixje/neo-go-legacy/pkg/wallet"
+	"github.com/urfave/cli"
+	"gopkg.in/yaml.v2"
+)
+
+var (
+	txOutFlag = cli.StringFlag{
+		Name:  "out",
+		Usage: "file to put unsigned JSON transaction to",
+	}
+	txFromFlag = flags.AddressFlag{
+		Name:  "from",
+		Usage: "Address to pay from (and to sign the transaction with)",
+	}
+	netFeeFlag = flags.Fixed8Flag{
+		Name:  "gas",
+		Usage: "Network fee to add to the transaction",
+	}
+	sysFeeFlag = flags.Fixed8Flag{
+		Name:  "sysgas",
+		Usage: "System fee to add to the transaction (estimated by the node if not specified)",
+	}
+)
+
+func newTxCommands() []cli.Command {
+	return []cli.Command{
+		{
+			Name:  "build",
+			Usage: "build an unsigned transaction using RPC node",
+			Description: `Creates a transaction and saves it to the file as a parameter context
+   (JSON), so that it can be signed with 'wallet tx sign' on a machine
+   without network access and then sent with 'wallet tx send'. Building
+   doesn't need the wallet, only the address to pay from.`,
+			Subcommands: []cli.Command{
+				{
+					Name:  "transfer",
+					Usage: "transfer NEO/GAS",
+					UsageText: "transfer --rpc <endpoint> --from <addr> --to <addr> --amount <amount>" +
+						" --asset [NEO|GAS|<hex-id>] --out <file>",
+					Action: buildTransferTx,
+					Flags: []cli.Flag{
+						rpcFlag,
+						timeoutFlag,
+						r14Flag,
+						txOutFlag,
+						txFromFlag,
+						toAddrFlag,
+						cli.StringFlag{
+							Name:  "amount",
+							Usage: "Amount of asset to send",
+						},
+						cli.StringFlag{
+							Name:  "asset",
+							Usage: "Asset ID",
+						},
+						coinSelectionFlag,
+					},
+				},
+				{
+					Name:  "nep5",
+					Usage: "transfer NEP5 tokens",
+					UsageText: "nep5 --rpc <endpoint> --from <addr> --to <addr> --token <hash-or-name>" +
+						" --amount <amount> [--path <wallet>] [--gas <amount>] --out <file>",
+					Action: buildNEP5Tx,
+					Flags: []cli.Flag{
+						walletPathFlag,
+						rpcFlag,
+						timeoutFlag,
+						txOutFlag,
+						txFromFlag,
+						toAddrFlag,
+						cli.StringFlag{
+							Name:  "token",
+							Usage: "Token to use (looked up in the wallet if it's given and then via RPC)",
+						},
+						cli.StringFlag{
+							Name:  "amount",
+							Usage: "Amount of asset to send",
+						},
+						flags.Fixed8Flag{
+							Name:  "gas",
+							Usage: "Amount of GAS to attach to a tx (estimated by the node if not specified)",
+						},
+					},
+				},
+				{
+					Name:      "claim",
+					Usage:     "claim GAS",
+					UsageText: "claim --rpc <endpoint> --address <addr> --out <file>",
+					Action:    buildClaimTx,
+					Flags: []cli.Flag{
+						rpcFlag,
+						timeoutFlag,
+						txOutFlag,
+						flags.AddressFlag{
+							Name:  "address, a",
+							Usage: "Address to claim GAS for",
+						},
+					},
+				},
+				{
+					Name:  "invoke",
+					Usage: "invoke a contract method",
+					UsageText: "invoke --rpc <endpoint> --from <addr> [--gas <amount>] [--sysgas <amount>]" +
+						" --out <file> <scripthash> <method> [<arg>...]",
+					Description: `Arguments are specified the same way as for 'contract invokefunction'.`,
+					Action:      buildInvokeTx,
+					Flags: []cli.Flag{
+						rpcFlag,
+						timeoutFlag,
+						txOutFlag,
+						txFromFlag,
+						netFeeFlag,
+						sysFeeFlag,
+					},
+				},
+				{
+					Name:  "deploy",
+					Usage: "deploy a contract",
+					UsageText: "deploy --rpc <endpoint> --from <addr> --in <file.avm> --config <file.yml>" +
+						" [--gas <amount>] --out <file>",
+					Action: buildDeployTx,
+					Flags: []cli.Flag{
+						rpcFlag,
+						timeoutFlag,
+						txOutFlag,
+						txFromFlag,
+						netFeeFlag,
+						cli.StringFlag{
+							Name:  "in, i",
+							Usage: "Input file for the smart contract (*.avm)",
+						},
+						cli.StringFlag{
+							Name:  "config, c",
+							Usage: "configuration input file (*.yml)",
+						},
+					},
+				},
+			},
+		},
+		{
+			Name:      "sign",
+			Usage:     "sign a transaction",
+			UsageText: "sign --path <path> --address <addr> --in <file> [--out <file>]",
+			Description: `Adds a signature of the account to the transaction. The transaction
+   is saved back to the input file unless --out is given. When all the
+   signatures for the account are present, its witness is added to the
+   transaction.`,
+			Action: signTx,
+			Flags: []cli.Flag{
+				walletPathFlag,
+				inFlag,
+				cli.StringFlag{
+					Name:  "out",
+					Usage: "file to put signed JSON transaction to",
+				},
+				flags.AddressFlag{
+					Name:  "address, a",
+					Usage: "Address to sign with",
+				},
+				signerFlag,
+			},
+		},
+		{
+			Name:      "inspect",
+			Usage:     "print transaction details",
+			UsageText: "inspect --in <file>",
+			Action:    inspectTx,
+			Flags: []cli.Flag{
+				inFlag,
+			},
+		},
+		{
+			Name:      "send",
+			Usage:     "send a signed transaction",
+			UsageText: "send --rpc <endpoint> --in <file>",
+			Action:    sendTx,
+			Flags: []cli.Flag{
+				rpcFlag,
+				timeoutFlag,
+				inFlag,
+			},
+		},
+	}
+}
+
+// getFromAddress returns the address specified with the 'from' flag.
+func getFromAddress(ctx *cli.Context) (*flags.Address, error) {
+	from := ctx.Generic("from").(*flags.Address)
+	if !from.IsSet {
+		return nil, errors.New("'from' address was not provided")
+	}
+	return from, nil
+}
+
+// newClient returns RPC client for the endpoint specified with the 'rpc' flag.
+func newClient(ctx *cli.Context, opts client.Options) (*client.Client, func(), error) {
+	gctx, cancel := getGoContext(ctx)
+	c, err := client.New(gctx, ctx.String("rpc"), opts)
+	if err != nil {
+		cancel()
+		return nil, nil, err
+	}
+	return c, cancel, nil
+}
+
+// writeTxContext saves the unsigned transaction as a parameter context to
+// the file specified with the 'out' flag.
+func writeTxContext(ctx *cli.Context, tx *transaction.Transaction) error {
+	out := ctx.String("out")
+	if out == "" {
+		return cli.NewExitError("output file was not provided", 1)
+	}
+	if err := writeParameterContext(context.NewTransactionContext(tx), out); err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	fmt.Println(tx.Hash().StringLE())
+	return nil
+}
+
+func buildTransferTx(ctx *cli.Context) error {
+	from, err := getFromAddress(ctx)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	to := ctx.Generic("to").(*flags.Address)
+	if !to.IsSet {
+		return cli.NewExitError("'to' address was not provided", 1)
+	}
+	asset, err := getAssetID(ctx.String("asset"))
+	if err != nil {
+		return cli.NewExitError(fmt.Errorf("invalid asset id: %v", err), 1)
+	}
+	amount, err := util.Fixed8FromString(ctx.String("amount"))
+	if err != nil {
+		return cli.NewExitError(fmt.Errorf("invalid amount: %v", err), 1)
+	}
+	selector, err := getCoinSelector(ctx.String("coin-selection"))
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+
+	c, cancel, err := newClient(ctx, client.Options{CoinSelector: selector})
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	defer cancel()
+
+	tx := transaction.NewContractTX()
+	if err := request.AddInputsAndUnspentsToTx(tx, from.String(), asset, amount, c); err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	tx.Attributes = append(tx.Attributes, remarkAttributes(ctx)...)
+	tx.AddOutput(transaction.NewOutput(asset, amount, to.Uint160()))
+	return writeTxContext(ctx, tx)
+}
+
+func buildNEP5Tx(ctx *cli.Context) error {
+	from, err := getFromAddress(ctx)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	to := ctx.Generic("to").(*flags.Address)
+	if !to.IsSet {
+		return cli.NewExitError("'to' address was not provided", 1)
+	}
+
+	c, cancel, err := newClient(ctx, client.Options{})
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	defer cancel()
+
+	name := ctx.String("token")
+	var token *wallet.Token
+	if path := ctx.String("path"); path != "" {
+		wall, err := openWallet(path)
+		if err != nil {
+			return cli.NewExitError(err, 1)
+		}
+		token, _ = getMatchingToken(wall, name)
+		wall.Close()
+	}
+	if token == nil {
+		if token, err = getMatchingTokenRPC(c, from.Uint160(), name); err != nil {
+			return cli.NewExitError(err, 1)
+		}
+	}
+
+	amount, err := util.FixedNFromString(ctx.String("amount"), int(token.Decimals))
+	if err != nil {
+		return cli.NewExitError(fmt.Errorf("invalid amount: %v", err), 1)
+	}
+	gas := flags.Fixed8FromContext(ctx, "gas")
+	if !ctx.IsSet("gas") {
+		gas, err = c.EstimateNEP5TransferFee(from.Uint160(), to.Uint160(), token, amount)
+		if err != nil {
+			return cli.NewExitError(fmt.Errorf("can't estimate system fee: %v", err), 1)
+		}
+	}
+	tx, err := c.CreateNEP5TransferTx(from.Uint160(), to.Uint160(), token, amount, gas)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	return writeTxContext(ctx, tx)
+}
+
+func buildClaimTx(ctx *cli.Context) error {
+	addr := ctx.Generic("address").(*flags.Address)
+	if !addr.IsSet {
+		return cli.NewExitError("address was not provided", 1)
+	}
+
+	c, cancel, err := newClient(ctx, client.Options{})
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	defer cancel()
+
+	tx, err := newClaimTx(c, addr.Uint160())
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	} else if tx == nil {
+		return cli.NewExitError("nothing to claim", 1)
+	}
+	return writeTxContext(ctx, tx)
+}
+
+func buildInvokeTx(ctx *cli.Context) error {
+	from, err := getFromAddress(ctx)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	args := ctx.Args()
+	if len(args) < 2 {
+		return cli.NewExitError("contract script hash and method are required", 1)
+	}
+	contract, method := args[0], args[1]
+	params := make([]smartcontract.Parameter, 0, len(args)-2)
+	for i, s := range args[2:] {
+		param, err := smartcontract.NewParameterFromString(s)
+		if err != nil {
+			return cli.NewExitError(fmt.Errorf("failed to parse argument #%d: %v", i+3, err), 1)
+		}
+		params = append(params, *param)
+	}
+
+	c, cancel, err := newClient(ctx, client.Options{})
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	defer cancel()
+
+	resp, err := c.InvokeFunction(contract, method, params, []util.Uint160{from.Uint160()})
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	script, err := hex.DecodeString(resp.Script)
+	if err != nil || len(script) == 0 {
+		return cli.NewExitError("bad script returned from the RPC node", 1)
+	}
+	sysfee := flags.Fixed8FromContext(ctx, "sysgas")
+	if !ctx.IsSet("sysgas") {
+		if sysfee, err = c.EstimateSystemFee(script, []util.Uint160{from.Uint160()}); err != nil {
+			return cli.NewExitError(fmt.Errorf("failed to estimate system fee: %v", err), 1)
+		}
+	}
+	tx, err := c.CreateInvocationTx(script, from.Uint160(), sysfee, flags.Fixed8FromContext(ctx, "gas"))
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	return writeTxContext(ctx, tx)
+}
+
+func buildDeployTx(ctx *cli.Context) error {
+	from, err := getFromAddress(ctx)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	avm, err := ioutil.ReadFile(ctx.String("in"))
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	confBytes, err := ioutil.ReadFile(ctx.String("config"))
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	// The same configuration file is used by 'contract deploy'.
+	var conf struct {
+		Contract smartcontract.ContractDetails `yaml:"project"`
+	}
+	if err := yaml.Unmarshal(confBytes, &conf); err != nil {
+		return cli.NewExitError(fmt.Errorf("bad config: %v", err), 1)
+	}
+	script, err := request.CreateDeploymentScript(avm, &conf.Contract)
+	if err != nil {
+		return cli.NewExitError(fmt.Errorf("failed to create deployment script: %v", err), 1)
+	}
+	sysfee := smartcontract.GetDeploymentPrice(request.DetailsToSCProperties(&conf.Contract))
+
+	c, cancel, err := newClient(ctx, client.Options{})
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	defer cancel()
+
+	tx, err := c.CreateInvocationTx(script, from.Uint160(), sysfee, flags.Fixed8FromContext(ctx, "gas"))
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	fmt.Printf("Contract: %s\n", hash.Hash160(avm).StringLE())
+	return writeTxContext(ctx, tx)
+}
+
+func signTx(ctx *cli.Context) error {
+	wall, err := openWallet(ctx.String("path"))
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	defer wall.Close()
+
+	addr := ctx.Generic("address").(*flags.Address)
+	if !addr.IsSet {
+		return cli.NewExitError("address was not provided", 1)
+	}
+	acc := wall.GetAccount(addr.Uint160())
+	if acc == nil {
+		return cli.NewExitError(fmt.Errorf("wallet contains no account for '%s'", addr), 1)
+	}
+
+	in := ctx.String("in")
+	pc, err := readParameterContext(in)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	tx, ok := pc.Verifiable.(*transaction.Transaction)
+	if !ok {
+		return cli.NewExitError("verifiable item is not a transaction", 1)
+	}
+	printTxSummary(tx, pc)
+
+	closeSigner, err := setAccountSigner(ctx, wall, acc)
+	if err != nil {
+		return cli.NewExitError(fmt.Errorf("can't unlock an account: %v", err), 1)
+	}
+	defer closeSigner()
+
+	if err := pc.Sign(acc.Contract, acc.Signer()); err != nil {
+		return cli.NewExitError(fmt.Errorf("can't add signature: %v", err), 1)
+	}
+	if w, err := pc.GetWitness(acc.Contract); err == nil {
+		addWitness(tx, *w)
+	}
+
+	out := ctx.String("out")
+	if out == "" {
+		out = in
+	}
+	if err := writeParameterContext(pc, out); err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	fmt.Println(tx.Hash().StringLE())
+	return nil
+}
+
+// addWitness adds the witness to the transaction replacing the one with the
+// same verification script. Witnesses are kept sorted by script hashes the
+// same way hashes for verifying are.
+func addWitness(tx *transaction.Transaction, w transaction.Witness) {
+	h := w.ScriptHash()
+	for i := range tx.Scripts {
+		if tx.Scripts[i].ScriptHash().Equals(h) {
+			tx.Scripts[i] = w
+			return
+		}
+	}
+	tx.Scripts = append(tx.Scripts, w)
+	sort.Slice(tx.Scripts, func(i, j int) bool {
+		return tx.Scripts[i].ScriptHash().Less(tx.Scripts[j].ScriptHash())
+	})
+}
+
+func inspectTx(ctx *cli.Context) error {
+	pc, err := readParameterContext(ctx.String("in"))
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	tx, ok := pc.Verifiable.(*transaction.Transaction)
+	if !ok {
+		return cli.NewExitError("verifiable item is not a transaction", 1)
+	}
+	printTxSummary(tx, pc)
+	return nil
+}
+
+func sendTx(ctx *cli.Context) error {
+	pc, err := readParameterContext(ctx.String("in"))
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	tx, ok := pc.Verifiable.(*transaction.Transaction)
+	if !ok {
+		return cli.NewExitError("verifiable item is not a transaction", 1)
+	}
+	if len(tx.Scripts) == 0 {
+		return cli.NewExitError("transaction is not signed", 1)
+	}
+	for h := range pc.Items {
+		if !hasWitness(tx, h) {
+			return cli.NewExitError(fmt.Errorf("signatures for %s are incomplete", address.Uint160ToString(h)), 1)
+		}
+	}
+
+	c, cancel, err := newClient(ctx, client.Options{})
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	defer cancel()
+
+	if err := c.SendRawTransaction(tx); err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	fmt.Println(tx.Hash().StringLE())
+	return nil
+}
+
+func hasWitness(tx *transaction.Transaction, h util.Uint160) bool {
+	for i := range tx.Scripts {
+		if tx.Scripts[i].ScriptHash().Equals(h) {
+			return true
+		}
+	}
+	return false
+}
+
+// printTxSummary prints human-readable transaction details along with the
+// signing status.
+func printTxSummary(tx *transaction.Transaction, pc *context.ParameterContext) {
+	fmt.Printf("Type: %s\n", tx.Type)
+	printTxInfo(tx)
+	for i := range tx.Attributes {
+		attr := &tx.Attributes[i]
+		fmt.Printf("Attribute%02d: %s %s\n", i, attr.Usage, formatAttributeData(attr))
+	}
+	switch data := tx.Data.(type) {
+	case *transaction.ClaimTX:
+		for i := range data.Claims {
+			fmt.Printf("Claim%02d: [%2d] %s\n", i, data.Claims[i].PrevIndex, data.Claims[i].PrevHash.StringLE())
+		}
+	case *transaction.InvocationTX:
+		fmt.Printf("System fee: %s GAS\n", data.Gas)
+		fmt.Printf("Script: %s\n", hex.EncodeToString(data.Script))
+		v := vm.New()
+		v.LoadScript(data.Script)
+		v.PrintOps()
+	}
+	hashes := make([]util.Uint160, 0, len(pc.Items))
+	for h := range pc.Items {
+		hashes = append(hashes, h)
+	}
+	sort.Slice(hashes, func(i, j int) bool { return hashes[i].Less(hashes[j]) })
+	for _, h := range hashes {
+		item := pc.Items[h]
+		var sigs int
+		for i := range item.Parameters {
+			if item.Parameters[i].Value != nil {
+				sigs++
+			}
+		}
+		fmt.Printf("Signatures of %s: %d of %d", address.Uint160ToString(h), sigs, len(item.Parameters))
+		if len(item.Signatures) > sigs {
+			// Multisig signatures are only put into parameters when
+			// there are enough of them.
+			fmt.Printf(" (%d collected)", len(item.Signatures))
+		}
+		fmt.Println()
+	}
+	fmt.Printf("Witnesses: %d\n", len(tx.Scripts))
+}
+
+// formatAttributeData returns the attribute data as an address for script
+// hashes, as a string for readable remarks and as a hex string otherwise.
+func formatAttributeData(attr *transaction.Attribute) string {
+	switch {
+	case attr.Usage == transaction.Script && len(attr.Data) == util.Uint160Size:
+		h, err := util.Uint160DecodeBytesBE(attr.Data)
+		if err == nil {
+			return address.Uint160ToString(h)
+		}
+	case attr.Usage >= transaction.Remark && utf8.Valid(attr.Data):
+		return fmt.Sprintf("%q", attr.Data)
+	}
+	return hex.EncodeToString(attr.Data)
+}
@@ -0,0 +1,141 @@
+package wallet
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/ixje/neo-go-legacy/pkg/core"
+	"github.com/ixje/neo-go-legacy/pkg/core/transaction"
+	"github.com/ixje/neo-go-legacy/pkg/crypto/keys"
+	"github.com/ixje/neo-go-legacy/pkg/encoding/address"
+	"github.com/ixje/neo-go-legacy/pkg/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTxRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	walletPath, accs := newTestWallet(t, dir, 3)
+	privs := make([]*keys.PrivateKey, len(accs))
+	for i := range accs {
+		privs[i] = accs[i].PrivateKey()
+	}
+	signer := newTestSigner(t, privs...)
+	gas := core.UtilityTokenID()
+	rpc := newTestRPC(t, map[string]interface{}{
+		"getunspents":        testUnspents(accs[0].Address, gas, util.Fixed8FromInt64(10)),
+		"sendrawtransaction": true,
+	})
+	txPath := filepath.Join(dir, "tx.json")
+
+	out, err := runWalletCmd(t, "tx", "build", "transfer", "--rpc", rpc.URL,
+		"--from", accs[0].Address, "--to", accs[1].Address, "--asset", "GAS",
+		"--amount", "1.5", "--remark14", "hello", "--out", txPath)
+	require.NoError(t, err)
+	pc, err := readParameterContext(txPath)
+	require.NoError(t, err)
+	tx := pc.Verifiable.(*transaction.Transaction)
+	require.Equal(t, tx.Hash().StringLE()+"\n", out)
+	require.Equal(t, 1, len(tx.Inputs))
+	require.Equal(t, []transaction.Output{
+		{AssetID: gas, Amount: util.Fixed8FromFloat(8.5), ScriptHash: accs[0].Contract.ScriptHash()},
+		{AssetID: gas, Amount: util.Fixed8FromFloat(1.5), ScriptHash: accs[1].Contract.ScriptHash(), Position: 1},
+	}, tx.Outputs)
+
+	out, err = runWalletCmd(t, "tx", "inspect", "--in", txPath)
+	require.NoError(t, err)
+	require.Contains(t, out, "Type: ContractTransaction\n")
+	require.Contains(t, out, "Attribute00: Remark14 \"hello\"\n")
+	require.NotContains(t, out, "Signatures of")
+	require.Contains(t, out, "Witnesses: 0\n")
+
+	_, err = runWalletCmd(t, "tx", "send", "--rpc", rpc.URL, "--in", txPath)
+	require.EqualError(t, err, "transaction is not signed")
+
+	// Every account adds its own parameter context item.
+	for i := len(accs) - 1; i >= 0; i-- {
+		out, err = runWalletCmd(t, "tx", "sign", "--path", walletPath, "--address", accs[i].Address,
+			"--in", txPath, "--signer", signer)
+		require.NoError(t, err)
+		require.True(t, strings.HasSuffix(out, tx.Hash().StringLE()+"\n"))
+	}
+
+	out, err = runWalletCmd(t, "tx", "inspect", "--in", txPath)
+	require.NoError(t, err)
+	require.Contains(t, out, "Witnesses: 3\n")
+	hashes := make([]util.Uint160, len(accs))
+	for i := range accs {
+		hashes[i] = accs[i].Contract.ScriptHash()
+	}
+	sort.Slice(hashes, func(i, j int) bool { return hashes[i].Less(hashes[j]) })
+	var lines []string
+	for _, h := range hashes {
+		lines = append(lines, "Signatures of "+address.Uint160ToString(h)+": 1 of 1")
+	}
+	require.Contains(t, out, strings.Join(lines, "\n"))
+
+	out, err = runWalletCmd(t, "tx", "send", "--rpc", rpc.URL, "--in", txPath)
+	require.NoError(t, err)
+	pc, err = readParameterContext(txPath)
+	require.NoError(t, err)
+	tx = pc.Verifiable.(*transaction.Transaction)
+	require.Equal(t, tx.Hash().StringLE()+"\n", out)
+	var sent string
+	require.NoError(t, json.Unmarshal(rpc.params("sendrawtransaction")[0], &sent))
+	require.Equal(t, hex.EncodeToString(tx.Bytes()), sent)
+}
+
+func TestTxFlags(t *testing.T) {
+	dir := t.TempDir()
+	walletPath, accs := newTestWallet(t, dir, 2)
+	gas := core.UtilityTokenID()
+	rpc := newTestRPC(t, map[string]interface{}{
+		"getunspents": testUnspents(accs[0].Address, gas, util.Fixed8FromInt64(10)),
+	})
+	txPath := filepath.Join(dir, "tx.json")
+	_, err := runWalletCmd(t, "tx", "build", "transfer", "--rpc", rpc.URL,
+		"--from", accs[0].Address, "--to", accs[1].Address, "--asset", "GAS",
+		"--amount", "1", "--out", txPath)
+	require.NoError(t, err)
+	other, err := keys.NewPrivateKey()
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name   string
+		args   []string
+		errMsg string
+	}{
+		{"build, no from", []string{"build", "transfer", "--rpc", rpc.URL, "--to", accs[1].Address,
+			"--asset", "GAS", "--amount", "1", "--out", txPath}, "'from' address was not provided"},
+		{"build, no to", []string{"build", "transfer", "--rpc", rpc.URL, "--from", accs[0].Address,
+			"--asset", "GAS", "--amount", "1", "--out", txPath}, "'to' address was not provided"},
+		{"build, bad asset", []string{"build", "transfer", "--rpc", rpc.URL, "--from", accs[0].Address,
+			"--to", accs[1].Address, "--asset", "BTC", "--amount", "1", "--out", txPath}, "invalid asset id"},
+		{"build, bad amount", []string{"build", "transfer", "--rpc", rpc.URL, "--from", accs[0].Address,
+			"--to", accs[1].Address, "--asset", "GAS", "--amount", "one", "--out", txPath}, "invalid amount"},
+		{"build, insufficient funds", []string{"build", "transfer", "--rpc", rpc.URL, "--from", accs[0].Address,
+			"--to", accs[1].Address, "--asset", "GAS", "--amount", "11", "--out", txPath}, ""},
+		{"build, no out", []string{"build", "transfer", "--rpc", rpc.URL, "--from", accs[0].Address,
+			"--to", accs[1].Address, "--asset", "GAS", "--amount", "1"}, "output file was not provided"},
+		{"build invoke, no method", []string{"build", "invoke", "--rpc", rpc.URL, "--from", accs[0].Address,
+			"--out", txPath, util.Uint160{1}.StringLE()}, "contract script hash and method are required"},
+		{"sign, no address", []string{"sign", "--path", walletPath, "--in", txPath}, "address was not provided"},
+		{"sign, unknown address", []string{"sign", "--path", walletPath, "--in", txPath,
+			"--address", address.Uint160ToString(other.GetScriptHash())}, "wallet contains no account"},
+		{"sign, no wallet", []string{"sign", "--in", txPath, "--address", accs[0].Address}, ""},
+		{"sign, no input", []string{"sign", "--path", walletPath, "--in", filepath.Join(dir, "none.json"),
+			"--address", accs[0].Address}, ""},
+		{"inspect, no input", []string{"inspect", "--in", filepath.Join(dir, "none.json")}, ""},
+		{"send, not signed", []string{"send", "--rpc", rpc.URL, "--in", txPath}, "transaction is not signed"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := runWalletCmd(t, append([]string{"tx"}, tc.args...)...)
+			require.Error(t, err)
+			require.Contains(t, err.Error(), tc.errMsg)
+		})
+	}
+}
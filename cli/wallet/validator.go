@@ -0,0 +1,213 @@
+package wallet
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/ixje/neo-go-legacy/cli/flags"
+	"github.com/ixje/neo-go-legacy/pkg/core/transaction"
+	"github.com/ixje/neo-go-legacy/pkg/crypto/keys"
+	"github.com/ixje/neo-go-legacy/pkg/rpc/client"
+	"github.com/ixje/neo-go-legacy/pkg/util"
+	"github.com/ixje/neo-go-legacy/pkg/vm"
+	"github.com/ixje/neo-go-legacy/pkg/wallet"
+	"github.com/urfave/cli"
+)
+
+func newCandidateCommands() []cli.Command {
+	stateFlags := []cli.Flag{
+		walletPathFlag,
+		rpcFlag,
+		timeoutFlag,
+		outFlag,
+		flags.AddressFlag{
+			Name:  "address, a",
+			Usage: "Address of the candidate account (it also pays fees)",
+		},
+		netFeeFlag,
+		signerFlag,
+	}
+	return []cli.Command{
+		{
+			Name:      "register",
+			Usage:     "register account key as a validator candidate",
+			UsageText: "register --path <path> --rpc <node> --address <addr> [--gas <netfee>] [--out <file>]",
+			Description: fmt.Sprintf(`Registers the public key of the account as a validator candidate
+   with StateTX, it costs %s GAS of system fee.`, transaction.ValidatorRegistrationFee),
+			Action: registerCandidate,
+			Flags:  stateFlags,
+		},
+		{
+			Name:      "unregister",
+			Usage:     "cancel validator candidate registration",
+			UsageText: "unregister --path <path> --rpc <node> --address <addr> [--gas <netfee>] [--out <file>]",
+			Action:    unregisterCandidate,
+			Flags:     stateFlags,
+		},
+		{
+			Name:      "list",
+			Usage:     "list validator candidates and their votes",
+			UsageText: "list --rpc <node>",
+			Action:    listCandidates,
+			Flags: []cli.Flag{
+				rpcFlag,
+				timeoutFlag,
+			},
+		},
+	}
+}
+
+func registerCandidate(ctx *cli.Context) error {
+	return handleCandidate(ctx, true)
+}
+
+func unregisterCandidate(ctx *cli.Context) error {
+	return handleCandidate(ctx, false)
+}
+
+func handleCandidate(ctx *cli.Context, register bool) error {
+	wall, err := openWallet(ctx.String("path"))
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	defer wall.Close()
+
+	acc, closeSigner, err := unlockAccount(ctx, wall)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	defer closeSigner()
+	if acc.Contract == nil || !vm.IsSignatureContract(acc.Contract.Script) {
+		return cli.NewExitError("candidate account must be a simple signature one", 1)
+	}
+
+	c, cancel, err := newClient(ctx, client.Options{})
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	defer cancel()
+
+	var sysfee util.Fixed8
+	if register {
+		sysfee = transaction.ValidatorRegistrationFee
+	}
+	desc := transaction.NewValidatorStateDescriptor(acc.Signer().PublicKey(), register)
+	tx, err := c.CreateStateTx([]*transaction.StateDescriptor{desc},
+		acc.Contract.ScriptHash(), sysfee, flags.Fixed8FromContext(ctx, "gas"))
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	if err := signAndProcessTx(c, acc, tx, ctx.String("out")); err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	fmt.Println(tx.Hash().StringLE())
+	return nil
+}
+
+func listCandidates(ctx *cli.Context) error {
+	c, cancel, err := newClient(ctx, client.Options{})
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	defer cancel()
+
+	vals, err := c.GetValidators()
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	for _, v := range vals {
+		var active string
+		if v.Active {
+			active = " (active)"
+		}
+		fmt.Printf("%s: %s votes%s\n", hex.EncodeToString(v.PublicKey.Bytes()), v.Votes, active)
+	}
+	return nil
+}
+
+func voteForCandidates(ctx *cli.Context) error {
+	wall, err := openWallet(ctx.String("path"))
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	defer wall.Close()
+
+	var votes keys.PublicKeys
+	for _, s := range ctx.Args() {
+		key, err := keys.NewPublicKeyFromString(s)
+		if err != nil {
+			return cli.NewExitError(fmt.Errorf("invalid candidate key %s: %v", s, err), 1)
+		}
+		if !votes.Contains(key) {
+			votes = append(votes, key)
+		}
+	}
+
+	acc, closeSigner, err := unlockAccount(ctx, wall)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	defer closeSigner()
+
+	c, cancel, err := newClient(ctx, client.Options{})
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	defer cancel()
+
+	if len(votes) != 0 {
+		if err := checkCandidates(c, votes); err != nil {
+			return cli.NewExitError(err, 1)
+		}
+	}
+
+	h := acc.Contract.ScriptHash()
+	desc := transaction.NewVotesStateDescriptor(h, votes)
+	tx, err := c.CreateStateTx([]*transaction.StateDescriptor{desc}, h, 0, flags.Fixed8FromContext(ctx, "gas"))
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	if err := signAndProcessTx(c, acc, tx, ctx.String("out")); err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	fmt.Println(tx.Hash().StringLE())
+	return nil
+}
+
+// checkCandidates ensures that all keys are registered as candidates, as
+// otherwise the transaction will be rejected by the node.
+func checkCandidates(c *client.Client, votes keys.PublicKeys) error {
+	vals, err := c.GetValidators()
+	if err != nil {
+		return fmt.Errorf("can't get candidates: %v", err)
+	}
+	registered := make(keys.PublicKeys, len(vals))
+	for i := range vals {
+		registered[i] = &vals[i].PublicKey
+	}
+	for _, key := range votes {
+		if !registered.Contains(key) {
+			return fmt.Errorf("%s is not a registered candidate", hex.EncodeToString(key.Bytes()))
+		}
+	}
+	return nil
+}
+
+// unlockAccount returns the wallet account for the --address flag prepared
+// for signing with setAccountSigner along with the function closing the signer.
+func unlockAccount(ctx *cli.Context, wall *wallet.Wallet) (*wallet.Account, func(), error) {
+	addr := ctx.Generic("address").(*flags.Address)
+	if !addr.IsSet {
+		return nil, nil, errors.New("address was not provided")
+	}
+	acc := wall.GetAccount(addr.Uint160())
+	if acc == nil {
+		return nil, nil, fmt.Errorf("wallet contains no account for '%s'", addr)
+	}
+	closeSigner, err := setAccountSigner(ctx, wall, acc)
+	if err != nil {
+		return nil, nil, err
+	}
+	return acc, closeSigner, nil
+}
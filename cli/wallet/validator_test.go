@@ -0,0 +1,133 @@
+package wallet
+
+import (
+	"encoding/hex"
+	"path/filepath"
+	"testing"
+
+	"github.com/ixje/neo-go-legacy/pkg/core"
+	"github.com/ixje/neo-go-legacy/pkg/core/transaction"
+	"github.com/ixje/neo-go-legacy/pkg/crypto/keys"
+	"github.com/ixje/neo-go-legacy/pkg/rpc/response/result"
+	"github.com/ixje/neo-go-legacy/pkg/util"
+	"github.com/ixje/neo-go-legacy/pkg/wallet"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCandidate(t *testing.T) {
+	dir := t.TempDir()
+	walletPath, accs := newTestWallet(t, dir, 2)
+	acc := accs[0]
+	pub := acc.PrivateKey().PublicKey()
+	signer := newTestSigner(t, acc.PrivateKey(), accs[1].PrivateKey())
+
+	// Multisig account using the same key can't be registered.
+	multi, err := wallet.NewAccountFromWIF(acc.PrivateKey().WIF())
+	require.NoError(t, err)
+	require.NoError(t, multi.ConvertMultisig(1, keys.PublicKeys{pub, accs[1].PrivateKey().PublicKey()}))
+	w, err := wallet.NewWalletFromFile(walletPath)
+	require.NoError(t, err)
+	w.AddAccount(multi)
+	require.NoError(t, w.Save())
+	w.Close()
+
+	gas := core.UtilityTokenID()
+	other, err := keys.NewPrivateKey()
+	require.NoError(t, err)
+	rpc := newTestRPC(t, map[string]interface{}{
+		"getunspents":        testUnspents(acc.Address, gas, util.Fixed8FromInt64(1002)),
+		"sendrawtransaction": true,
+		"getvalidators": []result.Validator{
+			{PublicKey: *pub, Votes: util.Fixed8FromInt64(100), Active: true},
+			{PublicKey: *accs[1].PrivateKey().PublicKey(), Votes: util.Fixed8FromInt64(5)},
+		},
+	})
+	args := []string{"--rpc", rpc.URL, "--path", walletPath, "--address", acc.Address, "--signer", signer}
+
+	t.Run("register", func(t *testing.T) {
+		out, err := runWalletCmd(t, append([]string{"candidate", "register", "--gas", "0.5"}, args...)...)
+		require.NoError(t, err)
+		tx := rpc.sentTx(t)
+		require.Equal(t, tx.Hash().StringLE()+"\n", out)
+		require.Equal(t, transaction.StateType, tx.Type)
+		require.Equal(t, []*transaction.StateDescriptor{transaction.NewValidatorStateDescriptor(pub, true)},
+			tx.Data.(*transaction.StateTX).Descriptors)
+		require.Equal(t, 1, len(tx.Inputs))
+		require.Equal(t, []transaction.Output{
+			{AssetID: gas, Amount: util.Fixed8FromFloat(1.5), ScriptHash: acc.Contract.ScriptHash()},
+		}, tx.Outputs)
+		require.Equal(t, 1, len(tx.Scripts))
+	})
+	t.Run("unregister, out, inspect and send", func(t *testing.T) {
+		txPath := filepath.Join(dir, "unregister.json")
+		out, err := runWalletCmd(t, append([]string{"candidate", "unregister", "--out", txPath}, args...)...)
+		require.NoError(t, err)
+		pc, err := readParameterContext(txPath)
+		require.NoError(t, err)
+		tx := pc.Verifiable.(*transaction.Transaction)
+		require.Equal(t, tx.Hash().StringLE()+"\n", out)
+		require.Equal(t, []*transaction.StateDescriptor{transaction.NewValidatorStateDescriptor(pub, false)},
+			tx.Data.(*transaction.StateTX).Descriptors)
+		// No fees are paid, so there are no inputs.
+		require.Equal(t, 0, len(tx.Inputs))
+
+		out, err = runWalletCmd(t, "tx", "inspect", "--in", txPath)
+		require.NoError(t, err)
+		require.Contains(t, out, "Type: StateTransaction\n")
+		require.Contains(t, out, "Signatures of "+acc.Address+": 1 of 1\n")
+
+		_, err = runWalletCmd(t, "tx", "sign", "--path", walletPath, "--address", acc.Address,
+			"--in", txPath, "--signer", signer)
+		require.NoError(t, err)
+		_, err = runWalletCmd(t, "tx", "send", "--rpc", rpc.URL, "--in", txPath)
+		require.NoError(t, err)
+		require.Equal(t, tx.Hash(), rpc.sentTx(t).Hash())
+	})
+	t.Run("list", func(t *testing.T) {
+		out, err := runWalletCmd(t, "candidate", "list", "--rpc", rpc.URL)
+		require.NoError(t, err)
+		require.Equal(t, hex.EncodeToString(pub.Bytes())+": 100 votes (active)\n"+
+			hex.EncodeToString(accs[1].PrivateKey().PublicKey().Bytes())+": 5 votes\n", out)
+	})
+	t.Run("vote", func(t *testing.T) {
+		keyArgs := []string{hex.EncodeToString(pub.Bytes()), hex.EncodeToString(pub.Bytes()),
+			hex.EncodeToString(accs[1].PrivateKey().PublicKey().Bytes())}
+		_, err := runWalletCmd(t, append(append([]string{"vote"}, args...), keyArgs...)...)
+		require.NoError(t, err)
+		h := acc.Contract.ScriptHash()
+		require.Equal(t, []*transaction.StateDescriptor{transaction.NewVotesStateDescriptor(h,
+			keys.PublicKeys{pub, accs[1].PrivateKey().PublicKey()})},
+			rpc.sentTx(t).Data.(*transaction.StateTX).Descriptors)
+
+		_, err = runWalletCmd(t, append([]string{"vote"}, args...)...)
+		require.NoError(t, err)
+		require.Equal(t, []*transaction.StateDescriptor{transaction.NewVotesStateDescriptor(h, nil)},
+			rpc.sentTx(t).Data.(*transaction.StateTX).Descriptors)
+	})
+
+	testCases := []struct {
+		name   string
+		args   []string
+		errMsg string
+	}{
+		{"register, no wallet", []string{"candidate", "register", "--rpc", rpc.URL, "--address", acc.Address}, ""},
+		{"register, no address", []string{"candidate", "register", "--rpc", rpc.URL, "--path", walletPath},
+			"address was not provided"},
+		{"register, unknown address", []string{"candidate", "register", "--rpc", rpc.URL, "--path", walletPath,
+			"--address", other.Address()}, "wallet contains no account"},
+		{"register, multisig", []string{"candidate", "register", "--rpc", rpc.URL, "--path", walletPath,
+			"--address", multi.Address, "--signer", signer}, "candidate account must be a simple signature one"},
+		{"register, insufficient funds", append([]string{"candidate", "register", "--gas", "3"}, args...), ""},
+		{"vote, bad key", append([]string{"vote"}, append(args, "02abcd")...), "invalid candidate key"},
+		{"vote, not a candidate", append([]string{"vote"}, append(args, hex.EncodeToString(other.PublicKey().Bytes()))...),
+			"is not a registered candidate"},
+		{"list, no RPC", []string{"candidate", "list"}, ""},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := runWalletCmd(t, tc.args...)
+			require.Error(t, err)
+			require.Contains(t, err.Error(), tc.errMsg)
+		})
+	}
+}
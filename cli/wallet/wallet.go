@@ -76,6 +76,11 @@ var (
 		Name:  "force",
 		Usage: "Do not ask for a confirmation",
 	}
+	coinSelectionFlag = cli.StringFlag{
+		Name:  "coin-selection",
+		Usage: "Unspents selection strategy: smallest, largest, exact or privacy",
+		Value: "smallest",
+	}
 )
 
 // NewCommands returns 'wallet' command.
@@ -102,13 +107,17 @@ func NewCommands() []cli.Command {
 				Name:   "init",
 				Usage:  "create a new wallet",
 				Action: createWallet,
-				Flags: []cli.Flag{
+				Flags: append([]cli.Flag{
 					walletPathFlag,
 					cli.BoolFlag{
 						Name:  "account, a",
 						Usage: "Create a new account",
 					},
-				},
+					cli.BoolFlag{
+						Name:  "mnemonic, m",
+						Usage: "Generate BIP-39 mnemonic and create the first account derived from it",
+					},
+				}, scryptFlags...),
 			},
 			{
 				Name:   "create",
@@ -116,6 +125,29 @@ func NewCommands() []cli.Command {
 				Action: addAccount,
 				Flags: []cli.Flag{
 					walletPathFlag,
+					cli.BoolFlag{
+						Name:  "derive",
+						Usage: "Derive the next account from the mnemonic",
+					},
+				},
+			},
+			{
+				Name:      "recover",
+				Usage:     "recover accounts derived from the mnemonic",
+				UsageText: "recover -p path -r rpc [--gap n]",
+				Description: `Derives accounts from the mnemonic using m/44'/888'/0'/0/i paths and
+   adds the ones having any transfers on chain to the wallet. The search
+   stops after the number of consecutive unused accounts given by --gap.`,
+				Action: recoverAccounts,
+				Flags: []cli.Flag{
+					walletPathFlag,
+					rpcFlag,
+					timeoutFlag,
+					cli.IntFlag{
+						Name:  "gap",
+						Usage: "Number of consecutive unused accounts to stop the search after",
+						Value: wallet.DefaultGapLimit,
+					},
 				},
 			},
 			{
@@ -173,6 +205,125 @@ func NewCommands() []cli.Command {
 					},
 				},
 			},
+			{
+				Name:      "import-watch",
+				Usage:     "import watch-only account",
+				UsageText: "import-watch --path <path> --address <address-or-hash> [--name <name>]",
+				Action:    importWatchOnly,
+				Flags: []cli.Flag{
+					walletPathFlag,
+					cli.StringFlag{
+						Name:  "address, a",
+						Usage: "Address or LE script hash of the account",
+					},
+					cli.StringFlag{
+						Name:  "name, n",
+						Usage: "Optional account name",
+					},
+				},
+			},
+			{
+				Name:      "balance",
+				Usage:     "show balances of wallet accounts",
+				UsageText: "balance --path <path> --rpc <node> [--address <address-or-hash>] [--json]",
+				Description: `Shows NEO/GAS and other UTXO asset balances, unclaimed GAS and NEP-5
+   token balances for every account in the wallet including watch-only ones.`,
+				Action: getBalance,
+				Flags: []cli.Flag{
+					walletPathFlag,
+					rpcFlag,
+					timeoutFlag,
+					cli.StringFlag{
+						Name:  "address, a",
+						Usage: "Show balance of this account only",
+					},
+					cli.BoolFlag{
+						Name:  "json",
+						Usage: "Print balances in JSON format",
+					},
+				},
+			},
+			{
+				Name:  "history",
+				Usage: "show transfers history of wallet accounts",
+				UsageText: "history --path <path> --rpc <node> [--address <address>] [--kind all|utxo|nep5]" +
+					" [--start <time>] [--end <time>] [--limit <n>] [--page <n>] [--format text|json|csv] [--out <file>]",
+				Description: `Shows incoming and outgoing UTXO and NEP-5 transfers of every account
+   in the wallet (or the one given by --address) newest first. By default
+   transfers are retrieved with getalltransfertx which provides
+   counterparties and fees, --kind utxo and --kind nep5 use
+   getutxotransfers and getnep5transfers instead. Times can be given as
+   Unix timestamps, YYYY-MM-DD dates or RFC 3339 times, the last week is
+   shown by default. --limit and --page are passed to RPC as is, so
+   they're applied to every account separately.`,
+				Action: getHistory,
+				Flags: []cli.Flag{
+					walletPathFlag,
+					rpcFlag,
+					timeoutFlag,
+					flags.AddressFlag{
+						Name:  "address, a",
+						Usage: "Show history of this account only",
+					},
+					cli.StringFlag{
+						Name:  "kind",
+						Usage: "Transfers to show: all, utxo or nep5",
+						Value: "all",
+					},
+					cli.StringFlag{
+						Name:  "start",
+						Usage: "Show transfers made since this time",
+					},
+					cli.StringFlag{
+						Name:  "end",
+						Usage: "Show transfers made before this time",
+					},
+					cli.IntFlag{
+						Name:  "limit",
+						Usage: "Maximum number of transfers (transactions for --kind all) per request",
+						Value: 1000,
+					},
+					cli.IntFlag{
+						Name:  "page",
+						Usage: "Page of transfers to show",
+					},
+					cli.StringFlag{
+						Name:  "format",
+						Usage: "Output format: text, json or csv",
+						Value: "text",
+					},
+					cli.StringFlag{
+						Name:  "out",
+						Usage: "File to export history to",
+					},
+				},
+			},
+			{
+				Name:      "change-password",
+				Usage:     "change password of wallet accounts",
+				UsageText: "change-password --path <path> [--address <addr>]",
+				Description: `Re-encrypts keys of all accounts (or the one given by --address) with
+   the new password. The wallet file is replaced atomically, its previous
+   version is saved with .bak suffix.`,
+				Action: changePassword,
+				Flags: []cli.Flag{
+					walletPathFlag,
+					flags.AddressFlag{
+						Name:  "address, a",
+						Usage: "Address of the account to change password for",
+					},
+				},
+			},
+			{
+				Name:      "upgrade-scrypt",
+				Usage:     "re-encrypt wallet keys with new scrypt parameters",
+				UsageText: "upgrade-scrypt --path <path> [--scrypt-n <N>] [--scrypt-r <r>] [--scrypt-p <p>]",
+				Description: `Re-encrypts keys of all accounts with the given scrypt parameters
+   keeping the password, so all accounts must share it. The wallet file is
+   replaced atomically, its previous version is saved with .bak suffix.`,
+				Action: upgradeScrypt,
+				Flags:  append([]cli.Flag{walletPathFlag}, scryptFlags...),
+			},
 			{
 				Name:      "remove",
 				Usage:     "remove an account from the wallet",
@@ -187,7 +338,17 @@ func NewCommands() []cli.Command {
 				Name:  "transfer",
 				Usage: "transfer NEO/GAS",
 				UsageText: "transfer --path <path> --from <addr> --to <addr>" +
-					" --amount <amount> --asset [NEO|GAS|<hex-id>] [--out <path>]",
+					" --amount <amount> --asset [NEO|GAS|<hex-id>] [--out <path>]\n" +
+					"   transfer --path <path> --from <addr> --batch <file> [--max-size <bytes>] [--out <path>]",
+				Description: `Transfers an asset to the given address. With --batch option
+   payments are read from the CSV or JSON file instead, so that many
+   recipients are paid by one transaction. CSV files have one
+   "address,asset,amount" record per line, JSON files contain an array
+   of {"address": ..., "asset": ..., "amount": ...} objects. Transfers
+   are split into several transactions if they don't fit in --max-size
+   bytes (the free transaction size limit of public networks by default,
+   bigger transactions need network fee which is not added), when --out
+   is used each transaction is saved to a separate file then.`,
 				Action: transferAsset,
 				Flags: []cli.Flag{
 					walletPathFlag,
@@ -205,8 +366,97 @@ func NewCommands() []cli.Command {
 						Name:  "asset",
 						Usage: "Asset ID",
 					},
+					cli.StringFlag{
+						Name:  "batch",
+						Usage: "CSV or JSON file with transfers to make",
+					},
+					cli.IntFlag{
+						Name:  "max-size",
+						Usage: "Maximum size of a batch transaction",
+						Value: maxFreeTxSize,
+					},
+					coinSelectionFlag,
+					signerFlag,
+				},
+			},
+			{
+				Name:  "consolidate",
+				Usage: "merge small unspents of an account",
+				UsageText: "consolidate --path <path> --address <addr> [--asset [NEO|GAS|<hex-id>]]" +
+					" [--outputs <n>] [--below <amount>] [--max-size <bytes>] [--out <path>]",
+				Description: `Merges the smallest unspents of the account into a few outputs
+   sent to the same address. One transaction is created for every asset
+   (or just the one given with --asset). As many unspents are merged as
+   fit in --max-size bytes which is the free transaction size limit of
+   public networks by default, run the command again to merge the rest.`,
+				Action: consolidateUnspents,
+				Flags: []cli.Flag{
+					walletPathFlag,
+					rpcFlag,
+					r14Flag,
+					timeoutFlag,
+					outFlag,
+					flags.AddressFlag{
+						Name:  "address, a",
+						Usage: "Address to merge unspents of",
+					},
+					cli.StringFlag{
+						Name:  "asset",
+						Usage: "Asset ID",
+					},
+					cli.IntFlag{
+						Name:  "outputs",
+						Usage: "Number of outputs to create",
+						Value: 1,
+					},
+					cli.StringFlag{
+						Name:  "below",
+						Usage: "Only merge unspents with value below this amount",
+					},
+					cli.IntFlag{
+						Name:  "max-size",
+						Usage: "Maximum size of a transaction",
+						Value: maxFreeTxSize,
+					},
+					signerFlag,
+				},
+			},
+			{
+				Name:        "asset",
+				Usage:       "register and issue UTXO assets",
+				Subcommands: newAssetCommands(),
+			},
+			{
+				Name:        "candidate",
+				Usage:       "work with validator candidates",
+				Subcommands: newCandidateCommands(),
+			},
+			{
+				Name:      "vote",
+				Usage:     "vote for validator candidates",
+				UsageText: "vote --path <path> --rpc <node> --address <addr> [--gas <netfee>] [--out <file>] [<key> ...]",
+				Description: `Sets votes of the account to the given candidate public keys replacing
+   previous ones, all NEO of the account is counted for every candidate.
+   Votes are removed if no keys are given.`,
+				Action: voteForCandidates,
+				Flags: []cli.Flag{
+					walletPathFlag,
+					rpcFlag,
+					timeoutFlag,
+					outFlag,
+					flags.AddressFlag{
+						Name:  "address, a",
+						Usage: "Address of the voting account (it also pays fees)",
+					},
+					netFeeFlag,
+					signerFlag,
 				},
 			},
+			{
+				Name:        "tx",
+				Usage:       "build, sign and send transactions in separate steps",
+				Subcommands: newTxCommands(),
+			},
 			{
 				Name:        "multisig",
 				Usage:       "work with multisig address",
@@ -241,7 +491,7 @@ func claimGas(ctx *cli.Context) error {
 	pass, err := readPassword("Enter password > ")
 	if err != nil {
 		return cli.NewExitError(err, 1)
-	} else if err := acc.Decrypt(pass); err != nil {
+	} else if err := acc.DecryptWithScrypt(pass, wall.Scrypt); err != nil {
 		return cli.NewExitError(err, 1)
 	}
 
@@ -252,14 +502,33 @@ func claimGas(ctx *cli.Context) error {
 	if err != nil {
 		return cli.NewExitError(err, 1)
 	}
-	info, err := c.GetClaimable(addrFlag.String())
+	tx, err := newClaimTx(c, scriptHash)
 	if err != nil {
 		return cli.NewExitError(err, 1)
-	} else if info.Unclaimed == 0 || len(info.Spents) == 0 {
+	} else if tx == nil {
 		fmt.Println("Nothing to claim")
 		return nil
 	}
 
+	_ = acc.SignTx(tx)
+	if err := c.SendRawTransaction(tx); err != nil {
+		return cli.NewExitError(err, 1)
+	}
+
+	fmt.Println(tx.Hash().StringLE())
+	return nil
+}
+
+// newClaimTx returns an unsigned transaction claiming all the unclaimed GAS
+// of the account or nil if there is nothing to claim.
+func newClaimTx(c *client.Client, scriptHash util.Uint160) (*transaction.Transaction, error) {
+	info, err := c.GetClaimable(address.Uint160ToString(scriptHash))
+	if err != nil {
+		return nil, err
+	} else if info.Unclaimed == 0 || len(info.Spents) == 0 {
+		return nil, nil
+	}
+
 	var claim transaction.ClaimTX
 	for i := range info.Spents {
 		claim.Claims = append(claim.Claims, transaction.Input{
@@ -278,14 +547,7 @@ func claimGas(ctx *cli.Context) error {
 		Amount:     info.Unclaimed,
 		ScriptHash: scriptHash,
 	})
-
-	_ = acc.SignTx(tx)
-	if err := c.SendRawTransaction(tx); err != nil {
-		return cli.NewExitError(err, 1)
-	}
-
-	fmt.Println(tx.Hash().StringLE())
-	return nil
+	return tx, nil
 }
 
 func addAccount(ctx *cli.Context) error {
@@ -296,6 +558,18 @@ func addAccount(ctx *cli.Context) error {
 
 	defer wall.Close()
 
+	if ctx.Bool("derive") {
+		mnemonic, err := readMnemonic()
+		if err != nil {
+			return cli.NewExitError(err, 1)
+		}
+		err = deriveAccount(wall, mnemonic, wall.NextDerivationIndex())
+		if err != nil {
+			return cli.NewExitError(err, 1)
+		}
+		return nil
+	}
+
 	if err := createAccount(ctx, wall); err != nil {
 		return cli.NewExitError(err, 1)
 	}
@@ -327,7 +601,7 @@ func exportKeys(ctx *cli.Context) error {
 
 loop:
 	for _, a := range wall.Accounts {
-		if addr != "" && a.Address != addr {
+		if (addr != "" && a.Address != addr) || a.IsWatchOnly() {
 			continue
 		}
 
@@ -347,7 +621,7 @@ loop:
 				return cli.NewExitError(err, 1)
 			}
 
-			pk, err := keys.NEP2Decrypt(wif, pass)
+			pk, err := keys.NEP2DecryptWithParams(wif, pass, wall.Scrypt)
 			if err != nil {
 				return cli.NewExitError(err, 1)
 			}
@@ -384,7 +658,7 @@ func importMultisig(ctx *cli.Context) error {
 		}
 	}
 
-	acc, err := newAccountFromWIF(ctx.String("wif"))
+	acc, err := newAccountFromWIF(ctx.String("wif"), wall.Scrypt)
 	if err != nil {
 		return cli.NewExitError(err, 1)
 	}
@@ -408,7 +682,7 @@ func importWallet(ctx *cli.Context) error {
 
 	defer wall.Close()
 
-	acc, err := newAccountFromWIF(ctx.String("wif"))
+	acc, err := newAccountFromWIF(ctx.String("wif"), wall.Scrypt)
 	if err != nil {
 		return cli.NewExitError(err, 1)
 	}
@@ -494,6 +768,10 @@ func transferAsset(ctx *cli.Context) error {
 
 	remark14 := ctx.String("remark14")
 
+	if batch := ctx.String("batch"); batch != "" {
+		return transferBatch(ctx, wall, acc, batch)
+	}
+
 	asset, err := getAssetID(ctx.String("asset"))
 	if err != nil {
 		return cli.NewExitError(fmt.Errorf("invalid asset id: %v", err), 1)
@@ -504,17 +782,20 @@ func transferAsset(ctx *cli.Context) error {
 		return cli.NewExitError(fmt.Errorf("invalid amount: %v", err), 1)
 	}
 
-	pass, err := readPassword("Enter wallet password > ")
+	closeSigner, err := setAccountSigner(ctx, wall, acc)
 	if err != nil {
 		return cli.NewExitError(err, 1)
-	} else if err := acc.Decrypt(pass); err != nil {
-		return cli.NewExitError(err, 1)
 	}
+	defer closeSigner()
 
 	gctx, cancel := getGoContext(ctx)
 	defer cancel()
 
-	c, err := client.New(gctx, ctx.String("rpc"), client.Options{})
+	selector, err := getCoinSelector(ctx.String("coin-selection"))
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	c, err := client.New(gctx, ctx.String("rpc"), client.Options{CoinSelector: selector})
 	if err != nil {
 		return cli.NewExitError(err, 1)
 	}
@@ -543,29 +824,34 @@ func transferAsset(ctx *cli.Context) error {
 		Position:   1,
 	})
 
-	if outFile := ctx.String("out"); outFile != "" {
-		priv := acc.PrivateKey()
-		pub := priv.PublicKey()
-		sign := priv.Sign(tx.GetSignedPart())
-		c := context2.NewParameterContext("Neo.Core.ContractTransaction", tx)
-		if err := c.AddSignature(acc.Contract, pub, sign); err != nil {
-			return cli.NewExitError(fmt.Errorf("can't add signature: %v", err), 1)
-		} else if data, err := json.Marshal(c); err != nil {
-			return cli.NewExitError(fmt.Errorf("can't marshal tx to JSON: %v", err), 1)
-		} else if err := ioutil.WriteFile(outFile, data, 0644); err != nil {
-			return cli.NewExitError(fmt.Errorf("can't write tx to file: %v", err), 1)
-		}
-	} else {
-		_ = acc.SignTx(tx)
-		if err := c.SendRawTransaction(tx); err != nil {
-			return cli.NewExitError(err, 1)
-		}
+	if err := signAndProcessTx(c, acc, tx, ctx.String("out")); err != nil {
+		return cli.NewExitError(err, 1)
 	}
-
 	fmt.Println(tx.Hash().StringLE())
 	return nil
 }
 
+// signAndProcessTx signs the transaction with the account and
+// either saves it to the file as a parameter context (if it's given) or
+// sends it.
+func signAndProcessTx(c *client.Client, acc *wallet.Account, tx *transaction.Transaction, outFile string) error {
+	if outFile != "" {
+		pc := context2.NewTransactionContext(tx)
+		if err := pc.Sign(acc.Contract, acc.Signer()); err != nil {
+			return fmt.Errorf("can't add signature: %v", err)
+		} else if data, err := json.Marshal(pc); err != nil {
+			return fmt.Errorf("can't marshal tx to JSON: %v", err)
+		} else if err := ioutil.WriteFile(outFile, data, 0644); err != nil {
+			return fmt.Errorf("can't write tx to file: %v", err)
+		}
+		return nil
+	}
+	if err := acc.SignTx(tx); err != nil {
+		return fmt.Errorf("can't sign tx: %v", err)
+	}
+	return c.SendRawTransaction(tx)
+}
+
 func getGoContext(ctx *cli.Context) (context.Context, func()) {
 	if dur := ctx.Duration("timeout"); dur != 0 {
 		return context.WithTimeout(context.Background(), dur)
@@ -584,8 +870,11 @@ func dumpWallet(ctx *cli.Context) error {
 			return cli.NewExitError(err, 1)
 		}
 		for i := range wall.Accounts {
+			if wall.Accounts[i].IsWatchOnly() {
+				continue
+			}
 			// Just testing the decryption here.
-			err := wall.Accounts[i].Decrypt(pass)
+			err := wall.Accounts[i].DecryptWithScrypt(pass, wall.Scrypt)
 			if err != nil {
 				return cli.NewExitError(err, 1)
 			}
@@ -600,7 +889,11 @@ func createWallet(ctx *cli.Context) error {
 	if len(path) == 0 {
 		return cli.NewExitError(errNoPath, 1)
 	}
-	wall, err := wallet.NewWallet(path)
+	scrypt, err := getScryptParams(ctx)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	wall, err := wallet.NewWalletWithScrypt(path, scrypt)
 	if err != nil {
 		return cli.NewExitError(err, 1)
 	}
@@ -608,7 +901,11 @@ func createWallet(ctx *cli.Context) error {
 		return cli.NewExitError(err, 1)
 	}
 
-	if ctx.Bool("account") {
+	if ctx.Bool("mnemonic") {
+		if err := createMnemonicAccount(wall); err != nil {
+			return cli.NewExitError(err, 1)
+		}
+	} else if ctx.Bool("account") {
 		if err := createAccount(ctx, wall); err != nil {
 			return cli.NewExitError(err, 1)
 		}
@@ -667,7 +964,7 @@ func getAssetID(s string) (util.Uint256, error) {
 	}
 }
 
-func newAccountFromWIF(wif string) (*wallet.Account, error) {
+func newAccountFromWIF(wif string, scrypt keys.ScryptParams) (*wallet.Account, error) {
 	// note: NEP2 strings always have length of 58 even though
 	// base58 strings can have different lengths even if slice lengths are equal
 	if len(wif) == 58 {
@@ -676,7 +973,7 @@ func newAccountFromWIF(wif string) (*wallet.Account, error) {
 			return nil, err
 		}
 
-		return wallet.NewAccountFromEncryptedWIF(wif, pass)
+		return newAccountFromNEP2(wif, pass, scrypt)
 	}
 
 	acc, err := wallet.NewAccountFromWIF(wif)
@@ -691,13 +988,28 @@ func newAccountFromWIF(wif string) (*wallet.Account, error) {
 	}
 
 	acc.Label = name
-	if err := acc.Encrypt(pass); err != nil {
+	if err := acc.EncryptWithScrypt(pass, scrypt); err != nil {
 		return nil, err
 	}
 
 	return acc, nil
 }
 
+// newAccountFromNEP2 decrypts the NEP-2 key with the default scrypt parameters
+// and re-encrypts it with the wallet ones if they differ.
+func newAccountFromNEP2(wif, pass string, scrypt keys.ScryptParams) (*wallet.Account, error) {
+	acc, err := wallet.NewAccountFromEncryptedWIF(wif, pass)
+	if err != nil {
+		return nil, err
+	}
+	if scrypt != keys.NEP2ScryptParams() {
+		if err := acc.EncryptWithScrypt(pass, scrypt); err != nil {
+			return nil, err
+		}
+	}
+	return acc, nil
+}
+
 func addAccountAndSave(w *wallet.Wallet, acc *wallet.Account) error {
 	for i := range w.Accounts {
 		if w.Accounts[i].Address == acc.Address {
@@ -0,0 +1,39 @@
+package wallet
+
+import (
+	"testing"
+
+	"github.com/ixje/neo-go-legacy/pkg/crypto/keys"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewAccountFromNEP2(t *testing.T) {
+	const (
+		nep2    = "6PYLHmDf6AjF4AsVtosmxHuPYeuyJL3SLuw7J1U8i7HxKAnYNsp61HYRfF"
+		pass    = "city of zion"
+		addr    = "ALq7AWrhAueN6mJNqk6FHJjnsEoPRytLdW"
+		privHex = "7d128a6d096f0c14c3a25a2b0c41cf79661bfcb4a8cc95aaaea28bde4d732344"
+	)
+
+	t.Run("default scrypt", func(t *testing.T) {
+		acc, err := newAccountFromNEP2(nep2, pass, keys.NEP2ScryptParams())
+		require.NoError(t, err)
+		require.Equal(t, nep2, acc.EncryptedWIF)
+		require.Equal(t, addr, acc.Address)
+	})
+	t.Run("custom scrypt", func(t *testing.T) {
+		scrypt := keys.ScryptParams{N: 2, R: 1, P: 1}
+		acc, err := newAccountFromNEP2(nep2, pass, scrypt)
+		require.NoError(t, err)
+		require.NotEqual(t, nep2, acc.EncryptedWIF)
+		require.Equal(t, addr, acc.Address)
+
+		require.Error(t, acc.Decrypt(pass))
+		require.NoError(t, acc.DecryptWithScrypt(pass, scrypt))
+		require.Equal(t, privHex, acc.PrivateKey().String())
+	})
+	t.Run("bad password", func(t *testing.T) {
+		_, err := newAccountFromNEP2(nep2, "bad", keys.ScryptParams{N: 2, R: 1, P: 1})
+		require.Error(t, err)
+	})
+}
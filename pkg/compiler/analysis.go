@@ -6,7 +6,7 @@ import (
 	"go/constant"
 	"go/types"
 
-	"golang.org/x/tools/go/loader"
+	"golang.org/x/tools/go/packages"
 )
 
 var (
@@ -17,7 +17,7 @@ var (
 		"VerifySignature", "AppCall",
 		"FromAddress", "Equals",
 		"panic", "DynAppCall",
-		"delete", "Remove",
+		"delete", "Remove", "recover",
 	}
 )
 
@@ -49,21 +49,23 @@ func typeAndValueForField(fld *types.Var) (types.TypeAndValue, error) {
 	}
 }
 
-// countGlobals counts the global variables in the program to add
+// countGlobals counts the global variables in the package files to add
 // them with the stack size of the function.
-func countGlobals(f ast.Node) (i int64) {
-	ast.Inspect(f, func(node ast.Node) bool {
-		switch node.(type) {
-		// Skip all function declarations.
-		case *ast.FuncDecl:
-			return false
-		// After skipping all funcDecls we are sure that each value spec
-		// is a global declared variable or constant.
-		case *ast.ValueSpec:
-			i++
-		}
-		return true
-	})
+func countGlobals(files []*ast.File) (i int64) {
+	for _, f := range files {
+		ast.Inspect(f, func(node ast.Node) bool {
+			switch node.(type) {
+			// Skip all function declarations.
+			case *ast.FuncDecl:
+				return false
+			// After skipping all funcDecls we are sure that each value spec
+			// is a global declared variable or constant.
+			case *ast.ValueSpec:
+				i++
+			}
+			return true
+		})
+	}
 	return
 }
 
@@ -78,6 +80,12 @@ func isExprNil(e ast.Expr) bool {
 	return ok && v.Name == "nil"
 }
 
+// isBlank checks if the given expression is a blank identifier.
+func isBlank(e ast.Expr) bool {
+	v, ok := e.(*ast.Ident)
+	return ok && v.Name == "_"
+}
+
 // makeBoolFromIdent creates a bool type from an *ast.Ident.
 func makeBoolFromIdent(ident *ast.Ident, tinfo *types.Info) (types.TypeAndValue, error) {
 	var b bool
@@ -95,26 +103,16 @@ func makeBoolFromIdent(ident *ast.Ident, tinfo *types.Info) (types.TypeAndValue,
 	}, nil
 }
 
-// resolveEntryPoint returns the function declaration of the entrypoint and the corresponding file.
-func resolveEntryPoint(entry string, pkg *loader.PackageInfo) (*ast.FuncDecl, *ast.File) {
-	var (
-		main *ast.FuncDecl
-		file *ast.File
-	)
-	for _, f := range pkg.Files {
-		ast.Inspect(f, func(n ast.Node) bool {
-			switch t := n.(type) {
-			case *ast.FuncDecl:
-				if t.Name.Name == entry {
-					main = t
-					file = f
-					return false
-				}
+// resolveEntryPoint returns the function declaration of the entrypoint.
+func resolveEntryPoint(entry string, pkg *packages.Package) *ast.FuncDecl {
+	for _, f := range pkg.Syntax {
+		for _, decl := range f.Decls {
+			if fd, ok := decl.(*ast.FuncDecl); ok && fd.Recv == nil && fd.Name.Name == entry {
+				return fd
 			}
-			return true
-		})
+		}
 	}
-	return main, file
+	return nil
 }
 
 // indexOfStruct returns the index of the given field inside that struct.
@@ -144,11 +142,11 @@ func lastStmtIsReturn(decl *ast.FuncDecl) (b bool) {
 	return false
 }
 
-func analyzeFuncUsage(pkgs map[*types.Package]*loader.PackageInfo) funcUsage {
+func analyzeFuncUsage(pkgs []*packages.Package) funcUsage {
 	usage := funcUsage{}
 
 	for _, pkg := range pkgs {
-		for _, f := range pkg.Files {
+		for _, f := range pkg.Syntax {
 			ast.Inspect(f, func(node ast.Node) bool {
 				switch n := node.(type) {
 				case *ast.CallExpr:
@@ -158,6 +156,11 @@ func analyzeFuncUsage(pkgs map[*types.Package]*loader.PackageInfo) funcUsage {
 					case *ast.SelectorExpr:
 						usage[t.Sel.Name] = true
 					}
+				case *ast.Ident:
+					// Functions can also be used as values.
+					if _, ok := pkg.TypesInfo.Uses[n].(*types.Func); ok {
+						usage[n.Name] = true
+					}
 				}
 				return true
 			})
@@ -186,35 +189,11 @@ func isBuiltin(expr ast.Expr) bool {
 	return false
 }
 
-func (c *codegen) isCompoundArrayType(t ast.Expr) bool {
-	switch s := t.(type) {
-	case *ast.ArrayType:
-		return true
-	case *ast.Ident:
-		arr, ok := c.typeInfo.Types[s].Type.Underlying().(*types.Slice)
-		return ok && !isByte(arr.Elem())
-	}
-	return false
-}
-
 func isByte(t types.Type) bool {
 	e, ok := t.(*types.Basic)
 	return ok && e.Kind() == types.Byte
 }
 
-func (c *codegen) isStructType(t ast.Expr) (int, bool) {
-	switch s := t.(type) {
-	case *ast.StructType:
-		return s.Fields.NumFields(), true
-	case *ast.Ident:
-		st, ok := c.typeInfo.Types[s].Type.Underlying().(*types.Struct)
-		if ok {
-			return st.NumFields(), true
-		}
-	}
-	return 0, false
-}
-
 func isByteArray(lit *ast.CompositeLit, tInfo *types.Info) bool {
 	if len(lit.Elts) == 0 {
 		if typ, ok := lit.Type.(*ast.ArrayType); ok {
@@ -234,7 +213,7 @@ func isSyscall(fun *funcScope) bool {
 	if fun.selector == nil {
 		return false
 	}
-	_, ok := syscalls[fun.selector.Name][fun.name]
+	_, ok := getSyscall(fun.selector.Name, fun.pkgPath, fun.name)
 	return ok
 }
 
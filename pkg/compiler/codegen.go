@@ -9,7 +9,6 @@ import (
 	"go/token"
 	"go/types"
 	"math"
-	"sort"
 	"strconv"
 	"strings"
 
@@ -18,7 +17,7 @@ import (
 	"github.com/ixje/neo-go-legacy/pkg/vm"
 	"github.com/ixje/neo-go-legacy/pkg/vm/emit"
 	"github.com/ixje/neo-go-legacy/pkg/vm/opcode"
-	"golang.org/x/tools/go/loader"
+	"golang.org/x/tools/go/packages"
 )
 
 // The identifier of the entry function. Default set to Main.
@@ -40,6 +39,16 @@ type codegen struct {
 	// Current funcScope being converted.
 	scope *funcScope
 
+	// Function values in the order of their identifiers.
+	funcValues []*funcValue
+	// A mapping from function literals to their values.
+	funcLits map[*ast.FuncLit]*funcValue
+	// A mapping from functions used as values to their values.
+	funcRefs map[types.Object]*funcValue
+
+	// Named types with methods, type tag is the index in this slice plus one.
+	taggedTypes []*types.Named
+
 	// A mapping from label's names to their ids.
 	labels map[labelWithType]uint16
 	// A list of nested label names together with evaluation stack depth.
@@ -56,6 +65,15 @@ type codegen struct {
 	// containing info about mapping from opcode's offset
 	// to a text span in the source file.
 	sequencePoints map[string][]DebugSeqPoint
+	// documents contains paths to all source files sequence points refer to.
+	documents []string
+	// docIndex is a mapping from the file path to its index in documents.
+	docIndex map[string]int
+
+	// events are notifications sent by the contract.
+	events []EventDebugInfo
+	// declaredEvents are names of events declared in the contract.
+	declaredEvents map[string]bool
 
 	// Label table for recording jump destinations.
 	l []int
@@ -143,6 +161,11 @@ func (c *codegen) convertBasicType(t types.TypeAndValue, typ *types.Basic) {
 }
 
 func (c *codegen) emitLoadLocal(name string) {
+	if v, ok := c.scope.getCaptured(name); ok {
+		c.emitLoadEnv(v.depth)
+		c.emitLoadField(v.pos)
+		return
+	}
 	pos := c.scope.loadLocal(name)
 	if pos < 0 {
 		c.prog.Err = fmt.Errorf("cannot load local variable with position: %d", pos)
@@ -170,6 +193,17 @@ func (c *codegen) emitStoreLocal(pos int) {
 	emit.Opcode(c.prog.BinWriter, opcode.SETITEM)
 }
 
+// emitStoreVar stores the item from the top of the stack into the variable
+// which can be either local or captured from the enclosing function.
+func (c *codegen) emitStoreVar(name string) {
+	if v, ok := c.scope.getCaptured(name); ok {
+		c.emitLoadEnv(v.depth)
+		c.emitStoreStructField(v.pos)
+		return
+	}
+	c.emitStoreLocal(c.scope.loadLocal(name))
+}
+
 func (c *codegen) emitLoadField(i int) {
 	emit.Int(c.prog.BinWriter, int64(i))
 	emit.Opcode(c.prog.BinWriter, opcode.PICKITEM)
@@ -181,32 +215,34 @@ func (c *codegen) emitStoreStructField(i int) {
 	emit.Opcode(c.prog.BinWriter, opcode.SETITEM)
 }
 
-// convertGlobals traverses the AST and only converts global declarations.
-// If we call this in convertFuncDecl then it will load all global variables
-// into the scope of the function.
-func (c *codegen) convertGlobals(f ast.Node) {
-	ast.Inspect(f, func(node ast.Node) bool {
-		switch n := node.(type) {
-		case *ast.FuncDecl:
-			return false
-		case *ast.GenDecl:
-			// constants are loaded directly so there is no need
-			// to store them as a local variables
-			if n.Tok != token.CONST {
-				ast.Walk(c, n)
+// convertGlobals traverses the AST of all package files and only converts
+// global declarations. If we call this in convertFuncDecl then it will load all
+// global variables into the scope of the function.
+func (c *codegen) convertGlobals(files []*ast.File) {
+	for _, f := range files {
+		ast.Inspect(f, func(node ast.Node) bool {
+			switch n := node.(type) {
+			case *ast.FuncDecl:
+				return false
+			case *ast.GenDecl:
+				// constants are loaded directly so there is no need
+				// to store them as a local variables
+				if n.Tok != token.CONST {
+					ast.Walk(c, n)
+				}
 			}
-		}
-		return true
-	})
+			return true
+		})
+	}
 }
 
-func (c *codegen) convertFuncDecl(file ast.Node, decl *ast.FuncDecl) {
+func (c *codegen) convertFuncDecl(files []*ast.File, decl *ast.FuncDecl) {
 	var (
 		f  *funcScope
 		ok bool
 	)
 
-	f, ok = c.funcs[decl.Name.Name]
+	f, ok = c.funcs[methodName(decl)]
 	if ok {
 		// If this function is a syscall we will not convert it to bytecode.
 		if isSyscall(f) {
@@ -223,26 +259,30 @@ func (c *codegen) convertFuncDecl(file ast.Node, decl *ast.FuncDecl) {
 
 	// All globals copied into the scope of the function need to be added
 	// to the stack size of the function.
-	emit.Int(c.prog.BinWriter, f.stackSize()+countGlobals(file))
+	emit.Int(c.prog.BinWriter, f.stackSize()+countGlobals(files))
 	emit.Opcode(c.prog.BinWriter, opcode.NEWARRAY)
 	emit.Opcode(c.prog.BinWriter, opcode.TOALTSTACK)
 
+	// Closure environment is passed before all other arguments.
+	if f.lit != nil {
+		l := c.scope.newLocal(envLocal)
+		c.emitStoreLocal(l)
+	}
+
 	// We need to handle methods, which in Go, is just syntactic sugar.
 	// The method receiver will be passed in as first argument.
 	// We check if this declaration has a receiver and load it into scope.
-	//
-	// FIXME: For now we will hard cast this to a struct. We can later fine tune this
-	// to support other types.
 	if decl.Recv != nil {
 		for _, arg := range decl.Recv.List {
-			ident := arg.Names[0]
-			// Currently only method receives for struct types is supported.
-			_, ok := c.typeInfo.Defs[ident].Type().Underlying().(*types.Struct)
-			if !ok {
-				c.prog.Err = fmt.Errorf("method receives for non-struct types is not yet supported")
+			if _, ok := arg.Type.(*ast.StarExpr); ok {
+				c.prog.Err = errors.New("pointer receivers are not supported")
 				return
 			}
-			l := c.scope.newLocal(ident.Name)
+			name := "_"
+			if len(arg.Names) != 0 {
+				name = arg.Names[0].Name
+			}
+			l := c.scope.newLocal(name)
 			c.emitStoreLocal(l)
 		}
 	}
@@ -254,10 +294,19 @@ func (c *codegen) convertFuncDecl(file ast.Node, decl *ast.FuncDecl) {
 			c.emitStoreLocal(l)
 		}
 	}
+	if err := c.analyzeDefers(decl.Body); err != nil {
+		c.prog.Err = err
+		return
+	}
+	if len(f.defers) != 0 {
+		c.scope.newLocal(panicLocal)
+		c.scope.newLocal(panickingLocal)
+	}
+
 	// Load in all the global variables in to the scope of the function.
 	// This is not necessary for syscalls.
 	if !isSyscall(f) {
-		c.convertGlobals(file)
+		c.convertGlobals(files)
 	}
 
 	ast.Walk(c, decl.Body)
@@ -267,6 +316,7 @@ func (c *codegen) convertFuncDecl(file ast.Node, decl *ast.FuncDecl) {
 	// This can be the case with void and named-return functions.
 	if !lastStmtIsReturn(decl) {
 		c.saveSequencePoint(decl.Body)
+		c.emitDefers()
 		emit.Opcode(c.prog.BinWriter, opcode.FROMALTSTACK)
 		emit.Opcode(c.prog.BinWriter, opcode.DROP)
 		emit.Opcode(c.prog.BinWriter, opcode.RET)
@@ -293,22 +343,27 @@ func (c *codegen) Visit(node ast.Node) ast.Visitor {
 					c.scope.newLocal(id.Name)
 					c.registerDebugVariable(id.Name, t.Type)
 				}
-				if len(t.Values) != 0 {
+				if len(t.Values) == 1 && len(t.Names) > 1 {
+					// Multiple values are returned with the first one on top.
+					ast.Walk(c, t.Values[0])
+					for _, id := range t.Names {
+						if id.Name == "_" {
+							emit.Opcode(c.prog.BinWriter, opcode.DROP)
+						} else {
+							c.emitStoreLocal(c.scope.loadLocal(id.Name))
+						}
+					}
+				} else if len(t.Values) != 0 {
 					for i, val := range t.Values {
-						ast.Walk(c, val)
+						c.walkConverted(val, c.typeInfo.TypeOf(t.Names[i]))
 						l := c.scope.loadLocal(t.Names[i].Name)
 						c.emitStoreLocal(l)
 					}
-				} else if c.isCompoundArrayType(t.Type) {
-					emit.Opcode(c.prog.BinWriter, opcode.PUSH0)
-					emit.Opcode(c.prog.BinWriter, opcode.NEWARRAY)
-					l := c.scope.loadLocal(t.Names[0].Name)
-					c.emitStoreLocal(l)
-				} else if n, ok := c.isStructType(t.Type); ok {
-					emit.Int(c.prog.BinWriter, int64(n))
-					emit.Opcode(c.prog.BinWriter, opcode.NEWSTRUCT)
-					l := c.scope.loadLocal(t.Names[0].Name)
-					c.emitStoreLocal(l)
+				} else if n.Tok != token.CONST {
+					for _, id := range t.Names {
+						c.emitZeroValue(c.typeInfo.TypeOf(id))
+						c.emitStoreLocal(c.scope.loadLocal(id.Name))
+					}
 				}
 			}
 		}
@@ -332,40 +387,36 @@ func (c *codegen) Visit(node ast.Node) ast.Visitor {
 					c.registerDebugVariable(t.Name, n.Rhs[i])
 				}
 				if !isAssignOp && (i == 0 || !multiRet) {
-					ast.Walk(c, n.Rhs[i])
+					if multiRet || t.Name == "_" {
+						ast.Walk(c, n.Rhs[i])
+					} else {
+						c.walkConverted(n.Rhs[i], c.typeInfo.TypeOf(t))
+					}
 				}
 				if t.Name == "_" {
 					emit.Opcode(c.prog.BinWriter, opcode.DROP)
 				} else {
-					l := c.scope.loadLocal(t.Name)
-					c.emitStoreLocal(l)
+					c.emitStoreVar(t.Name)
 				}
 
 			case *ast.SelectorExpr:
-				switch expr := t.X.(type) {
-				case *ast.Ident:
-					if !isAssignOp {
-						ast.Walk(c, n.Rhs[i])
-					}
-					typ := c.typeInfo.ObjectOf(expr).Type().Underlying()
-					if strct, ok := typ.(*types.Struct); ok {
-						c.emitLoadLocal(expr.Name)            // load the struct
-						i := indexOfStruct(strct, t.Sel.Name) // get the index of the field
-						c.emitStoreStructField(i)             // store the field
-					}
-				default:
-					c.prog.Err = fmt.Errorf("nested selector assigns not supported yet")
-					return nil
+				if !isAssignOp {
+					c.walkConverted(n.Rhs[i], c.typeInfo.TypeOf(t))
+				}
+				typ := c.typeInfo.TypeOf(t.X).Underlying()
+				if strct, ok := typ.(*types.Struct); ok {
+					ast.Walk(c, t.X)                      // load the struct
+					i := indexOfStruct(strct, t.Sel.Name) // get the index of the field
+					c.emitStoreStructField(i)             // store the field
 				}
 
 			// Assignments to index expressions.
 			// slice[0] = 10
 			case *ast.IndexExpr:
 				if !isAssignOp {
-					ast.Walk(c, n.Rhs[i])
+					c.walkConverted(n.Rhs[i], c.typeInfo.TypeOf(t))
 				}
-				name := t.X.(*ast.Ident).Name
-				c.emitLoadLocal(name)
+				ast.Walk(c, t.X)
 				switch ind := t.Index.(type) {
 				case *ast.BasicLit:
 					indexStr := ind.Value
@@ -375,13 +426,10 @@ func (c *codegen) Visit(node ast.Node) ast.Visitor {
 						return nil
 					}
 					c.emitStoreStructField(index)
-				case *ast.Ident:
-					c.emitLoadLocal(ind.Name)
+				default:
+					ast.Walk(c, ind)
 					emit.Opcode(c.prog.BinWriter, opcode.ROT)
 					emit.Opcode(c.prog.BinWriter, opcode.SETITEM)
-				default:
-					c.prog.Err = fmt.Errorf("unsupported index expression")
-					return nil
 				}
 			}
 		}
@@ -420,22 +468,21 @@ func (c *codegen) Visit(node ast.Node) ast.Visitor {
 		}
 		c.dropItems(cnt)
 
-		if len(n.Results) == 0 {
-			results := c.scope.decl.Type.Results
-			if results.NumFields() != 0 {
-				// function with named returns
-				for i := len(results.List) - 1; i >= 0; i-- {
-					names := results.List[i].Names
-					for j := len(names) - 1; j >= 0; j-- {
-						c.emitLoadLocal(names[j].Name)
-					}
-				}
-			}
+		results := c.scope.decl.Type.Results
+		named := results.NumFields() != 0 && len(results.List[0].Names) != 0
+		if len(n.Results) != 0 && (!named || len(c.scope.defers) == 0) {
+			c.walkResults(n.Results)
+			c.emitDefers()
 		} else {
-			// first result should be on top of the stack
-			for i := len(n.Results) - 1; i >= 0; i-- {
-				ast.Walk(c, n.Results[i])
+			if len(n.Results) != 0 {
+				// Deferred functions can change named results,
+				// thus they are assigned before running them.
+				c.walkResults(n.Results)
+				c.storeNamedResults()
 			}
+			c.emitDefers()
+			// function with named returns
+			c.emitNamedResults()
 		}
 
 		c.saveSequencePoint(n)
@@ -449,6 +496,9 @@ func (c *codegen) Visit(node ast.Node) ast.Visitor {
 		lElse := c.newLabel()
 		lElseEnd := c.newLabel()
 
+		if n.Init != nil {
+			ast.Walk(c, n.Init)
+		}
 		if n.Cond != nil {
 			ast.Walk(c, n.Cond)
 			emit.Jmp(c.prog.BinWriter, opcode.JMPIFNOT, lElse)
@@ -468,6 +518,9 @@ func (c *codegen) Visit(node ast.Node) ast.Visitor {
 		return nil
 
 	case *ast.SwitchStmt:
+		if n.Init != nil {
+			ast.Walk(c, n.Init)
+		}
 		ast.Walk(c, n.Tag)
 
 		switchEnd, label := c.generateLabel(labelEnd)
@@ -534,21 +587,19 @@ func (c *codegen) Visit(node ast.Node) ast.Visitor {
 			c.emitLoadConst(tv)
 		} else if n.Name == "nil" {
 			c.emitDefault(new(types.Slice))
+		} else if fn, ok := c.typeInfo.ObjectOf(n).(*types.Func); ok {
+			c.emitFuncRef(fn)
 		} else {
 			c.emitLoadLocal(n.Name)
 		}
 		return nil
 
 	case *ast.CompositeLit:
-		var typ types.Type
-
-		switch t := n.Type.(type) {
-		case *ast.Ident:
-			typ = c.typeInfo.ObjectOf(t).Type().Underlying()
-		case *ast.SelectorExpr:
-			typ = c.typeInfo.ObjectOf(t.Sel).Type().Underlying()
-		case *ast.MapType:
-			typ = c.typeInfo.TypeOf(t)
+		switch typ := c.typeInfo.TypeOf(n).Underlying().(type) {
+		case *types.Struct:
+			c.convertStruct(n)
+		case *types.Map:
+			c.convertMap(n)
 		default:
 			ln := len(n.Elts)
 			// ByteArrays needs a different approach than normal arrays.
@@ -556,19 +607,15 @@ func (c *codegen) Visit(node ast.Node) ast.Visitor {
 				c.convertByteArray(n)
 				return nil
 			}
+			var elem types.Type
+			if t, ok := typ.(*types.Slice); ok {
+				elem = t.Elem()
+			}
 			for i := ln - 1; i >= 0; i-- {
-				ast.Walk(c, n.Elts[i])
+				c.walkConverted(n.Elts[i], elem)
 			}
 			emit.Int(c.prog.BinWriter, int64(ln))
 			emit.Opcode(c.prog.BinWriter, opcode.PACK)
-			return nil
-		}
-
-		switch typ.(type) {
-		case *types.Struct:
-			c.convertStruct(n)
-		case *types.Map:
-			c.convertMap(n)
 		}
 
 		return nil
@@ -613,6 +660,16 @@ func (c *codegen) Visit(node ast.Node) ast.Visitor {
 				return nil
 			}
 
+			if n.Op == token.EQL || n.Op == token.NEQ {
+				if isExprNil(n.X) {
+					c.emitNilCheck(n.Y, n.Op)
+					return nil
+				} else if isExprNil(n.Y) {
+					c.emitNilCheck(n.X, n.Op)
+					return nil
+				}
+			}
+
 			ast.Walk(c, n.X)
 			ast.Walk(c, n.Y)
 
@@ -625,10 +682,6 @@ func (c *codegen) Visit(node ast.Node) ast.Visitor {
 					emit.Opcode(c.prog.BinWriter, opcode.ADD)
 				}
 			case token.EQL, token.NEQ:
-				if isExprNil(n.X) || isExprNil(n.Y) {
-					c.prog.Err = errors.New("comparison with `nil` is not supported, use `len(..) == 0` instead")
-					return nil
-				}
 				c.emitEquality(n.X, n.Op)
 			default:
 				c.convertToken(n.Op)
@@ -644,6 +697,19 @@ func (c *codegen) Visit(node ast.Node) ast.Visitor {
 			isBuiltin = isBuiltin(n.Fun)
 		)
 
+		if c.isFuncValueCall(n) {
+			c.convertFuncValueCall(n)
+			return nil
+		}
+
+		if tv := c.typeInfo.Types[n.Fun]; tv.IsType() {
+			if _, ok := n.Fun.(*ast.ArrayType); !ok {
+				// Conversion between types.
+				c.walkConverted(n.Args[0], tv.Type)
+				return nil
+			}
+		}
+
 		switch fun := n.Fun.(type) {
 		case *ast.Ident:
 			f, ok = c.funcs[fun.Name]
@@ -655,19 +721,34 @@ func (c *codegen) Visit(node ast.Node) ast.Visitor {
 			// If this is a method call we need to walk the AST to load the struct locally.
 			// Otherwise this is a function call from a imported package and we can call it
 			// directly.
-			if c.typeInfo.Selections[fun] != nil {
+			if sel := c.typeInfo.Selections[fun]; sel != nil {
+				if types.IsInterface(sel.Recv()) {
+					c.convertInterfaceCall(n, fun)
+					return nil
+				}
 				ast.Walk(c, fun.X)
 				// Dont forget to add 1 extra argument when its a method.
 				numArgs++
+
+				name := methodNameFromFunc(sel.Obj().(*types.Func))
+				f, ok = c.funcs[name]
+				if !ok {
+					c.prog.Err = fmt.Errorf("could not resolve method %s", name)
+					return nil
+				}
+				break
 			}
 
 			f, ok = c.funcs[fun.Sel.Name]
-			// @FIXME this could cause runtime errors.
-			f.selector = fun.X.(*ast.Ident)
 			if !ok {
 				c.prog.Err = fmt.Errorf("could not resolve function %s", fun.Sel.Name)
 				return nil
 			}
+			// @FIXME this could cause runtime errors.
+			f.selector = fun.X.(*ast.Ident)
+			if pkg, ok := c.typeInfo.Uses[f.selector].(*types.PkgName); ok {
+				f.pkgPath = pkg.Imported().Path()
+			}
 		case *ast.ArrayType:
 			// For now we will assume that there are only byte slice conversions.
 			// E.g. []byte("foobar") or []byte(scriptHash).
@@ -680,8 +761,13 @@ func (c *codegen) Visit(node ast.Node) ast.Visitor {
 		args := transformArgs(n.Fun, n.Args)
 
 		// Handle the arguments
-		for _, arg := range args {
-			ast.Walk(c, arg)
+		if !isBuiltin && len(args) == len(n.Args) {
+			sig, _ := c.typeInfo.TypeOf(n.Fun).(*types.Signature)
+			c.walkArgs(sig, args)
+		} else {
+			for _, arg := range args {
+				ast.Walk(c, arg)
+			}
 		}
 		// Do not swap for builtin functions.
 		if !isBuiltin {
@@ -695,7 +781,7 @@ func (c *codegen) Visit(node ast.Node) ast.Visitor {
 			// We can be sure builtins are of type *ast.Ident.
 			c.convertBuiltin(n)
 		case isSyscall(f):
-			c.convertSyscall(n, f.selector.Name, f.name)
+			c.convertSyscall(n, f)
 		default:
 			emit.Call(c.prog.BinWriter, opcode.CALL, f.label)
 		}
@@ -703,18 +789,19 @@ func (c *codegen) Visit(node ast.Node) ast.Visitor {
 		return nil
 
 	case *ast.SelectorExpr:
-		switch t := n.X.(type) {
-		case *ast.Ident:
-			typ := c.typeInfo.ObjectOf(t).Type().Underlying()
-			if strct, ok := typ.(*types.Struct); ok {
-				c.emitLoadLocal(t.Name) // load the struct
-				i := indexOfStruct(strct, n.Sel.Name)
-				c.emitLoadField(i) // load the field
-			}
-		default:
-			c.prog.Err = fmt.Errorf("nested selectors not supported yet")
+		if fn, ok := c.typeInfo.ObjectOf(n.Sel).(*types.Func); ok && c.typeInfo.Selections[n] == nil {
+			c.emitFuncRef(fn)
+			return nil
+		}
+		typ := c.typeInfo.TypeOf(n.X)
+		if typ == nil {
 			return nil
 		}
+		if strct, ok := typ.Underlying().(*types.Struct); ok {
+			ast.Walk(c, n.X) // load the struct
+			i := indexOfStruct(strct, n.Sel.Name)
+			c.emitLoadField(i) // load the field
+		}
 		return nil
 
 	case *ast.UnaryExpr:
@@ -746,8 +833,7 @@ func (c *codegen) Visit(node ast.Node) ast.Visitor {
 		// for i := 0; i < 10; i++ {}
 		// Where the post stmt is ( i++ )
 		if ident, ok := n.X.(*ast.Ident); ok {
-			pos := c.scope.loadLocal(ident.Name)
-			c.emitStoreLocal(pos)
+			c.emitStoreVar(ident.Name)
 		}
 		return nil
 
@@ -851,10 +937,8 @@ func (c *codegen) Visit(node ast.Node) ast.Visitor {
 		return nil
 
 	case *ast.RangeStmt:
-		// currently only simple for-range loops are supported
-		// for i := range ...
-		if n.Value != nil {
-			c.prog.Err = errors.New("range loops with value variable are not supported")
+		if _, ok := c.typeInfo.TypeOf(n.X).Underlying().(*types.Map); ok || n.Value != nil {
+			c.convertRangeWithValue(n)
 			return nil
 		}
 
@@ -880,11 +964,9 @@ func (c *codegen) Visit(node ast.Node) ast.Visitor {
 		emit.Opcode(c.prog.BinWriter, opcode.LTE) // finish if len <= i
 		emit.Jmp(c.prog.BinWriter, opcode.JMPIF, end)
 
-		if n.Key != nil {
+		if n.Key != nil && !isBlank(n.Key) {
 			emit.Opcode(c.prog.BinWriter, opcode.DUP)
-
-			pos := c.scope.loadLocal(n.Key.(*ast.Ident).Name)
-			c.emitStoreLocal(pos)
+			c.emitStoreVar(n.Key.(*ast.Ident).Name)
 		}
 
 		ast.Walk(c, n.Body)
@@ -907,12 +989,91 @@ func (c *codegen) Visit(node ast.Node) ast.Visitor {
 	// For this to work properly, we only need to walk the expression
 	// not the assertion type.
 	case *ast.TypeAssertExpr:
-		ast.Walk(c, n.X)
+		c.convertTypeAssert(n)
+		return nil
+
+	case *ast.FuncLit:
+		c.convertFuncLitValue(n)
+		return nil
+
+	case *ast.DeferStmt:
+		c.convertDefer(n)
 		return nil
 	}
 	return c
 }
 
+// convertRangeWithValue converts range loops over maps and range loops with
+// value variable. Maps are iterated over the array of their keys.
+func (c *codegen) convertRangeWithValue(n *ast.RangeStmt) {
+	_, isMap := c.typeInfo.TypeOf(n.X).Underlying().(*types.Map)
+
+	start, label := c.generateLabel(labelStart)
+	end := c.newNamedLabel(labelEnd, label)
+	post := c.newNamedLabel(labelPost, label)
+
+	lastFor := c.currentFor
+	lastSwitch := c.currentSwitch
+	c.currentFor = label
+	c.currentSwitch = label
+
+	// The stack contains the collection being iterated, the array of keys
+	// (for maps), the number of elements and the current index.
+	ast.Walk(c, n.X)
+	sz := 3
+	if isMap {
+		emit.Opcode(c.prog.BinWriter, opcode.DUP)
+		emit.Opcode(c.prog.BinWriter, opcode.KEYS)
+		sz++
+	}
+	emit.Opcode(c.prog.BinWriter, opcode.DUP)
+	emit.Opcode(c.prog.BinWriter, opcode.ARRAYSIZE)
+	emit.Opcode(c.prog.BinWriter, opcode.PUSH0)
+
+	c.pushStackLabel(label, sz)
+	c.setLabel(start)
+
+	emit.Opcode(c.prog.BinWriter, opcode.OVER)
+	emit.Opcode(c.prog.BinWriter, opcode.OVER)
+	emit.Opcode(c.prog.BinWriter, opcode.LTE) // finish if len <= i
+	emit.Jmp(c.prog.BinWriter, opcode.JMPIF, end)
+
+	// Key is either the current index or the element of the keys array.
+	emit.Opcode(c.prog.BinWriter, opcode.DUP)
+	if isMap {
+		emit.Opcode(c.prog.BinWriter, opcode.PUSH3)
+		emit.Opcode(c.prog.BinWriter, opcode.PICK)
+		emit.Opcode(c.prog.BinWriter, opcode.SWAP)
+		emit.Opcode(c.prog.BinWriter, opcode.PICKITEM)
+	}
+	if n.Value != nil && !isBlank(n.Value) {
+		emit.Opcode(c.prog.BinWriter, opcode.DUP)
+		emit.Int(c.prog.BinWriter, int64(sz+1))
+		emit.Opcode(c.prog.BinWriter, opcode.PICK)
+		emit.Opcode(c.prog.BinWriter, opcode.SWAP)
+		emit.Opcode(c.prog.BinWriter, opcode.PICKITEM)
+		c.emitStoreVar(n.Value.(*ast.Ident).Name)
+	}
+	if n.Key != nil && !isBlank(n.Key) {
+		c.emitStoreVar(n.Key.(*ast.Ident).Name)
+	} else {
+		emit.Opcode(c.prog.BinWriter, opcode.DROP)
+	}
+
+	ast.Walk(c, n.Body)
+
+	c.setLabel(post)
+
+	emit.Opcode(c.prog.BinWriter, opcode.INC)
+	emit.Jmp(c.prog.BinWriter, opcode.JMP, start)
+
+	c.setLabel(end)
+	c.dropStackLabel()
+
+	c.currentFor = lastFor
+	c.currentSwitch = lastSwitch
+}
+
 func isFallthroughStmt(c ast.Node) bool {
 	s, ok := c.(*ast.BranchStmt)
 	return ok && s.Tok == token.FALLTHROUGH
@@ -997,6 +1158,20 @@ func (c *codegen) emitEquality(expr ast.Expr, op token.Token) {
 	}
 }
 
+// emitNilCheck compares expr with nil. There is no null value in the VM,
+// so nil slices, maps and interfaces are indistinguishable from
+// the empty ones.
+func (c *codegen) emitNilCheck(expr ast.Expr, op token.Token) {
+	ast.Walk(c, expr)
+	emit.Opcode(c.prog.BinWriter, opcode.ARRAYSIZE)
+	emit.Opcode(c.prog.BinWriter, opcode.PUSH0)
+	if op == token.EQL {
+		emit.Opcode(c.prog.BinWriter, opcode.NUMEQUAL)
+	} else {
+		emit.Opcode(c.prog.BinWriter, opcode.NUMNOTEQUAL)
+	}
+}
+
 // getByteArray returns byte array value from constant expr.
 // Only literals are supported.
 func (c *codegen) getByteArray(expr ast.Expr) []byte {
@@ -1024,14 +1199,16 @@ func (c *codegen) getByteArray(expr ast.Expr) []byte {
 	}
 }
 
-func (c *codegen) convertSyscall(expr *ast.CallExpr, api, name string) {
-	api, ok := syscalls[api][name]
+func (c *codegen) convertSyscall(expr *ast.CallExpr, f *funcScope) {
+	name := f.name
+	api, ok := getSyscall(f.selector.Name, f.pkgPath, name)
 	if !ok {
 		c.prog.Err = fmt.Errorf("unknown VM syscall api: %s", name)
 		return
 	}
 	switch name {
 	case "Notify":
+		c.registerEvent(expr)
 		numArgs := len(expr.Args)
 		emit.Int(c.prog.BinWriter, int64(numArgs))
 		emit.Opcode(c.prog.BinWriter, opcode.PACK)
@@ -1073,7 +1250,9 @@ func (c *codegen) convertBuiltin(expr *ast.CallExpr) {
 		}
 	case "panic":
 		arg := expr.Args[0]
-		if isExprNil(arg) {
+		if len(c.scope.defers) != 0 {
+			c.convertPanicWithDefers(arg)
+		} else if isExprNil(arg) {
 			emit.Opcode(c.prog.BinWriter, opcode.DROP)
 			emit.Opcode(c.prog.BinWriter, opcode.THROW)
 		} else if isStringType(c.typeInfo.Types[arg].Type) {
@@ -1096,6 +1275,8 @@ func (c *codegen) convertBuiltin(expr *ast.CallExpr) {
 			c.prog.Err = errNotSupported
 		}
 		emit.Opcode(c.prog.BinWriter, opcode.REMOVE)
+	case "recover":
+		c.convertRecover()
 	case "SHA256":
 		emit.Opcode(c.prog.BinWriter, opcode.SHA256)
 	case "SHA1":
@@ -1179,12 +1360,13 @@ func (c *codegen) convertByteArray(lit *ast.CompositeLit) {
 }
 
 func (c *codegen) convertMap(lit *ast.CompositeLit) {
+	typ := c.typeInfo.TypeOf(lit).Underlying().(*types.Map)
 	emit.Opcode(c.prog.BinWriter, opcode.NEWMAP)
 	for i := range lit.Elts {
 		elem := lit.Elts[i].(*ast.KeyValueExpr)
 		emit.Opcode(c.prog.BinWriter, opcode.DUP)
 		ast.Walk(c, elem.Key)
-		ast.Walk(c, elem.Value)
+		c.walkConverted(elem.Value, typ.Elem())
 		emit.Opcode(c.prog.BinWriter, opcode.SETITEM)
 	}
 }
@@ -1219,7 +1401,7 @@ func (c *codegen) convertStruct(lit *ast.CompositeLit) {
 				pos := indexOfStruct(strct, fieldName)
 				emit.Int(c.prog.BinWriter, int64(pos))
 
-				ast.Walk(c, f.Value)
+				c.walkConverted(f.Value, sField.Type())
 
 				emit.Opcode(c.prog.BinWriter, opcode.SETITEM)
 				fieldAdded = true
@@ -1238,7 +1420,7 @@ func (c *codegen) convertStruct(lit *ast.CompositeLit) {
 
 		emit.Opcode(c.prog.BinWriter, opcode.DUP)
 		emit.Int(c.prog.BinWriter, int64(i))
-		c.emitDefault(typeAndVal.Type)
+		c.emitZeroValue(typeAndVal.Type)
 		emit.Opcode(c.prog.BinWriter, opcode.SETITEM)
 	}
 }
@@ -1264,6 +1446,39 @@ func (c *codegen) emitDefault(typ types.Type) {
 	}
 }
 
+// emitZeroValue emits zero value of the specified type. Fields of structs
+// and elements of fixed-size arrays are initialized recursively.
+func (c *codegen) emitZeroValue(typ types.Type) {
+	switch t := typ.Underlying().(type) {
+	case *types.Struct:
+		emit.Int(c.prog.BinWriter, int64(t.NumFields()))
+		emit.Opcode(c.prog.BinWriter, opcode.NEWSTRUCT)
+		for i := 0; i < t.NumFields(); i++ {
+			emit.Opcode(c.prog.BinWriter, opcode.DUP)
+			emit.Int(c.prog.BinWriter, int64(i))
+			c.emitZeroValue(t.Field(i).Type())
+			emit.Opcode(c.prog.BinWriter, opcode.SETITEM)
+		}
+		return
+	case *types.Array:
+		if isByte(t.Elem()) {
+			emit.Bytes(c.prog.BinWriter, make([]byte, t.Len()))
+			return
+		}
+		for i := int64(0); i < t.Len(); i++ {
+			c.emitZeroValue(t.Elem())
+		}
+		emit.Int(c.prog.BinWriter, t.Len())
+		emit.Opcode(c.prog.BinWriter, opcode.PACK)
+		return
+	}
+	if c.scTypeFromGo(typ.Underlying()) == "Any" {
+		c.emitDefault(new(types.Slice))
+		return
+	}
+	c.emitDefault(typ.Underlying())
+}
+
 func (c *codegen) convertToken(tok token.Token) {
 	switch tok {
 	case token.ADD_ASSIGN:
@@ -1326,81 +1541,108 @@ func (c *codegen) newFunc(decl *ast.FuncDecl) *funcScope {
 	return f
 }
 
-func (c *codegen) compile(info *buildInfo, pkg *loader.PackageInfo) error {
+func (c *codegen) compile(info *buildInfo, pkg *packages.Package) error {
 	// Resolve the entrypoint of the program.
-	main, mainFile := resolveEntryPoint(mainIdent, pkg)
+	main := resolveEntryPoint(mainIdent, pkg)
 	if main == nil {
 		c.prog.Err = fmt.Errorf("could not find func main. Did you forget to declare it? ")
 		return c.prog.Err
 	}
 
-	funUsage := analyzeFuncUsage(info.program.AllPackages)
+	funUsage := analyzeFuncUsage(info.program)
 
 	// Bring all imported functions into scope.
-	for _, pkg := range info.program.AllPackages {
-		for _, f := range pkg.Files {
+	for _, pkg := range info.program {
+		for _, f := range pkg.Syntax {
 			c.resolveFuncDecls(f)
 		}
 	}
 
-	// convert the entry point first.
-	c.convertFuncDecl(mainFile, main)
-
-	// sort map keys to generate code deterministically.
-	keys := make([]*types.Package, 0, len(info.program.AllPackages))
-	for p := range info.program.AllPackages {
-		keys = append(keys, p)
+	for _, pkg := range info.program {
+		c.analyzeTypes(pkg)
+		c.analyzeFuncValues(pkg)
+		c.resolveEvents(pkg)
 	}
-	sort.Slice(keys, func(i, j int) bool { return keys[i].Path() < keys[j].Path() })
+	if c.prog.Err != nil {
+		return c.prog.Err
+	}
+	c.typeInfo = pkg.TypesInfo
+
+	// convert the entry point first.
+	c.convertFuncDecl(pkg.Syntax, main)
 
 	// Generate the code for the program.
-	for _, k := range keys {
-		pkg := info.program.AllPackages[k]
-		c.typeInfo = &pkg.Info
+	for _, pkg := range info.program {
+		c.typeInfo = pkg.TypesInfo
 
-		for _, f := range pkg.Files {
+		for _, f := range pkg.Syntax {
 			for _, decl := range f.Decls {
 				switch n := decl.(type) {
 				case *ast.FuncDecl:
 					// Don't convert the function if it's not used. This will save a lot
 					// of bytecode space.
-					if n.Name.Name != mainIdent && funUsage.funcUsed(n.Name.Name) {
-						c.convertFuncDecl(f, n)
+					if (n.Name.Name != mainIdent || n.Recv != nil) && funUsage.funcUsed(n.Name.Name) {
+						c.convertFuncDecl(pkg.Syntax, n)
 					}
 				}
 			}
 		}
 	}
 
+	// Function literals are converted last, when scopes
+	// of the functions they capture variables from are known.
+	for _, fv := range c.funcValues {
+		if fv.lit != nil {
+			c.convertFuncLit(fv)
+		}
+	}
+
 	return c.prog.Err
 }
 
-func newCodegen(info *buildInfo, pkg *loader.PackageInfo) *codegen {
+func newCodegen(info *buildInfo, pkg *packages.Package) *codegen {
 	return &codegen{
 		buildInfo: info,
 		prog:      io.NewBufBinWriter(),
 		l:         []int{},
 		funcs:     map[string]*funcScope{},
 		labels:    map[labelWithType]uint16{},
-		typeInfo:  &pkg.Info,
+		typeInfo:  pkg.TypesInfo,
+		funcLits:  map[*ast.FuncLit]*funcValue{},
+		funcRefs:  map[types.Object]*funcValue{},
 
 		sequencePoints: make(map[string][]DebugSeqPoint),
+		docIndex:       make(map[string]int),
+		declaredEvents: make(map[string]bool),
 	}
 }
 
-// CodeGen compiles the program to bytecode.
+// CodeGen compiles the program to optimized bytecode.
 func CodeGen(info *buildInfo) ([]byte, *DebugInfo, error) {
-	pkg := info.program.Package(info.initialPackage)
+	return codeGen(info, &Options{})
+}
+
+func codeGen(info *buildInfo, o *Options) ([]byte, *DebugInfo, error) {
+	pkg := info.mainPackage
 	c := newCodegen(info, pkg)
 
 	if err := c.compile(info, pkg); err != nil {
 		return nil, nil, err
 	}
+	if err := c.checkStandards(pkg, o.Standards); err != nil {
+		return nil, nil, err
+	}
 
 	buf := c.prog.Bytes()
 	if err := c.writeJumps(buf); err != nil {
 		return nil, nil, err
 	}
+	if !o.NoOpt {
+		var err error
+		if buf, err = c.optimize(buf); err != nil {
+			return nil, nil, err
+		}
+	}
 	return buf, c.emitDebugInfo(), nil
 }
 
@@ -1408,7 +1650,7 @@ func (c *codegen) resolveFuncDecls(f *ast.File) {
 	for _, decl := range f.Decls {
 		switch n := decl.(type) {
 		case *ast.FuncDecl:
-			if n.Name.Name != mainIdent {
+			if n.Name.Name != mainIdent || n.Recv != nil {
 				c.newFunc(n)
 			}
 		}
@@ -1,18 +1,19 @@
 package compiler
 
 import (
-	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"go/parser"
+	"go/token"
 	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/ixje/neo-go-legacy/pkg/smartcontract"
-	"golang.org/x/tools/go/loader"
+	"golang.org/x/tools/go/packages"
 )
 
 const fileExt = "avm"
@@ -33,30 +34,116 @@ type Options struct {
 
 	// Contract metadata.
 	ContractDetails *smartcontract.ContractDetails
+
+	// Disable bytecode optimizations.
+	NoOpt bool
+
+	// Standards the contract must conform to (like "nep5").
+	Standards []string
 }
 
 type buildInfo struct {
-	initialPackage string
-	program        *loader.Program
+	fset        *token.FileSet
+	mainPackage *packages.Package
+	// program contains the main package and all of its dependencies.
+	program []*packages.Package
 }
 
-func getBuildInfo(src interface{}) (*buildInfo, error) {
-	conf := loader.Config{ParserMode: parser.ParseComments}
-	f, err := conf.ParseFile("", src)
+// getBuildInfo loads the program from the given source (file contents) or
+// from the given path if src is nil. Path can be either a Go file or a
+// directory with the package to compile (which can also be a root of Go
+// module), in the latter case all Go files of the package are used.
+func getBuildInfo(path string, src interface{}) (*buildInfo, error) {
+	conf := &packages.Config{
+		Mode: packages.NeedName | packages.NeedFiles | packages.NeedImports |
+			packages.NeedDeps | packages.NeedTypes | packages.NeedSyntax |
+			packages.NeedTypesInfo | packages.NeedTypesSizes,
+		Fset: token.NewFileSet(),
+	}
+	pattern, err := filepath.Abs(path)
 	if err != nil {
 		return nil, err
 	}
-	conf.CreateFromFiles("", f)
+	if src != nil {
+		// Source is placed into the overlay file which is loaded as a
+		// separate package using the module of the current directory.
+		if path == "" {
+			pattern = filepath.Join(pattern, "contract.go")
+		}
+		data, err := readSource(src)
+		if err != nil {
+			return nil, err
+		}
+		conf.Overlay = map[string][]byte{pattern: data}
+	} else {
+		fi, err := os.Stat(pattern)
+		if err != nil {
+			return nil, err
+		}
+		// Imports are to be resolved relative to the package (and its
+		// module), not the current directory.
+		if fi.IsDir() {
+			conf.Dir = pattern
+			pattern = "."
+		} else if !strings.HasSuffix(pattern, ".go") {
+			return nil, fmt.Errorf("%s is not a Go file", pattern)
+		} else {
+			conf.Dir = filepath.Dir(pattern)
+		}
+	}
 
-	prog, err := conf.Load()
+	pkgs, err := packages.Load(conf, pattern)
+	if err != nil {
+		return nil, err
+	}
+	if len(pkgs) != 1 {
+		return nil, fmt.Errorf("%s: expected one package, got %d", path, len(pkgs))
+	}
+	info := &buildInfo{
+		fset:        conf.Fset,
+		mainPackage: pkgs[0],
+	}
+	packages.Visit(pkgs, nil, func(pkg *packages.Package) {
+		if err == nil && len(pkg.Errors) != 0 {
+			err = pkg.Errors[0]
+		}
+		info.program = append(info.program, pkg)
+	})
 	if err != nil {
 		return nil, err
 	}
+	// Packages are processed in the same order to generate
+	// code deterministically.
+	sort.Slice(info.program, func(i, j int) bool {
+		return info.program[i].PkgPath < info.program[j].PkgPath
+	})
+	return info, nil
+}
 
-	return &buildInfo{
-		initialPackage: f.Name.Name,
-		program:        prog,
-	}, nil
+// readSource returns contents of the source given as a string, a byte
+// slice or an io.Reader.
+func readSource(src interface{}) ([]byte, error) {
+	switch s := src.(type) {
+	case string:
+		return []byte(s), nil
+	case []byte:
+		return s, nil
+	case io.Reader:
+		return ioutil.ReadAll(s)
+	default:
+		return nil, errors.New("invalid source")
+	}
+}
+
+// LoadProgram loads the package from the given Go file or package directory
+// with all its dependencies the same way the compiler does it. Dependencies
+// are available via Imports of the returned package.
+func LoadProgram(path string) (*packages.Package, error) {
+	info, err := getBuildInfo(path, nil)
+	if err != nil {
+		return nil, err
+	}
+	return info.mainPackage, nil
 }
 
 // Compile compiles a Go program into bytecode that can run on the NEO virtual machine.
@@ -71,18 +158,26 @@ func Compile(r io.Reader) ([]byte, error) {
 
 // CompileWithDebugInfo compiles a Go program into bytecode and emits debug info.
 func CompileWithDebugInfo(r io.Reader) ([]byte, *DebugInfo, error) {
-	ctx, err := getBuildInfo(r)
+	ctx, err := getBuildInfo("", r)
 	if err != nil {
 		return nil, nil, err
 	}
 	return CodeGen(ctx)
 }
 
-// CompileAndSave will compile and save the file to disk.
-func CompileAndSave(src string, o *Options) ([]byte, error) {
-	if !strings.HasSuffix(src, ".go") {
-		return nil, fmt.Errorf("%s is not a Go file", src)
+// CompilePath compiles a Go file or a package (all of its files) located in
+// the given directory into bytecode and emits debug info.
+func CompilePath(src string) ([]byte, *DebugInfo, error) {
+	ctx, err := getBuildInfo(src, nil)
+	if err != nil {
+		return nil, nil, err
 	}
+	return CodeGen(ctx)
+}
+
+// CompileAndSave will compile and save the file or package to disk.
+func CompileAndSave(src string, o *Options) ([]byte, error) {
+	src = filepath.Clean(src)
 	o.Outfile = strings.TrimSuffix(o.Outfile, fmt.Sprintf(".%s", fileExt))
 	if len(o.Outfile) == 0 {
 		o.Outfile = strings.TrimSuffix(src, ".go")
@@ -90,24 +185,19 @@ func CompileAndSave(src string, o *Options) ([]byte, error) {
 	if len(o.Ext) == 0 {
 		o.Ext = fileExt
 	}
-	b, err := ioutil.ReadFile(src)
+	ctx, err := getBuildInfo(src, nil)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("error while trying to compile smart contract: %v", err)
 	}
-	b, di, err := CompileWithDebugInfo(bytes.NewReader(b))
+	b, di, err := codeGen(ctx, o)
 	if err != nil {
-		return nil, fmt.Errorf("error while trying to compile smart contract file: %v", err)
+		return nil, fmt.Errorf("error while trying to compile smart contract: %v", err)
 	}
 	out := fmt.Sprintf("%s.%s", o.Outfile, o.Ext)
 	err = ioutil.WriteFile(out, b, os.ModePerm)
 	if o.DebugInfo == "" {
 		return b, err
 	}
-	p, err := filepath.Abs(src)
-	if err != nil {
-		return b, err
-	}
-	di.Documents = append(di.Documents, p)
 	data, err := json.Marshal(di)
 	if err != nil {
 		return b, err
@@ -118,7 +208,7 @@ func CompileAndSave(src string, o *Options) ([]byte, error) {
 	if o.ABIInfo == "" {
 		return b, err
 	}
-	abi := di.convertToABI(b, o.ContractDetails)
+	abi := di.convertToABI(b, o.ContractDetails, o.Standards...)
 	abiData, err := json.Marshal(abi)
 	if err != nil {
 		return b, err
@@ -1,12 +1,16 @@
 package compiler_test
 
 import (
+	"encoding/json"
 	"io/ioutil"
+	"math/big"
 	"os"
 	"path"
+	"path/filepath"
 	"testing"
 
 	"github.com/ixje/neo-go-legacy/pkg/compiler"
+	"github.com/ixje/neo-go-legacy/pkg/vm"
 	"github.com/stretchr/testify/require"
 )
 
@@ -60,6 +64,70 @@ func TestCompiler(t *testing.T) {
 	}
 }
 
+func TestCompilePackage(t *testing.T) {
+	const pkgPath = "testdata/multi"
+
+	b, di, err := compiler.CompilePath(pkgPath)
+	require.NoError(t, err)
+
+	v := vm.New()
+	v.Load(b)
+	require.NoError(t, v.Run())
+	require.Equal(t, big.NewInt(142), v.PopResult())
+
+	mainPath, err := filepath.Abs(filepath.Join(pkgPath, "main.go"))
+	require.NoError(t, err)
+	utilPath, err := filepath.Abs(filepath.Join(pkgPath, "util.go"))
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{mainPath, utilPath}, di.Documents)
+
+	docs := map[string]string{"Main": mainPath, "add": utilPath}
+	for _, m := range di.Methods {
+		require.NotEmpty(t, m.SeqPoints)
+		for _, sp := range m.SeqPoints {
+			require.Equal(t, docs[m.Name.Name], di.Documents[sp.Document], m.Name.Name)
+		}
+	}
+
+	t.Run("save", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "compile")
+		require.NoError(t, err)
+		defer os.RemoveAll(dir)
+
+		out := filepath.Join(dir, "multi.avm")
+		debug := filepath.Join(dir, "multi.debug.json")
+		res, err := compiler.CompileAndSave(pkgPath+"/", &compiler.Options{Outfile: out, DebugInfo: debug})
+		require.NoError(t, err)
+		require.Equal(t, b, res)
+
+		data, err := ioutil.ReadFile(out)
+		require.NoError(t, err)
+		require.Equal(t, b, data)
+
+		data, err = ioutil.ReadFile(debug)
+		require.NoError(t, err)
+		actual := new(compiler.DebugInfo)
+		require.NoError(t, json.Unmarshal(data, actual))
+		require.Equal(t, di.Documents, actual.Documents)
+	})
+
+	t.Run("module", func(t *testing.T) {
+		b, di, err := compiler.CompilePath("testdata/module")
+		require.NoError(t, err)
+
+		v := vm.New()
+		v.Load(b)
+		require.NoError(t, v.Run())
+		require.Equal(t, big.NewInt(42), v.PopResult())
+		require.Equal(t, 2, len(di.Documents))
+	})
+
+	t.Run("not a Go file", func(t *testing.T) {
+		_, _, err := compiler.CompilePath("testdata/module/go.mod")
+		require.Error(t, err)
+	})
+}
+
 func filterFilename(infos []os.FileInfo) string {
 	for _, info := range infos {
 		if !info.IsDir() {
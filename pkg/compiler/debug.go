@@ -87,6 +87,8 @@ type ABI struct {
 	EntryPoint string       `json:"entrypoint"`
 	Functions  []Method     `json:"functions"`
 	Events     []Event      `json:"events"`
+	// SupportedStandards are standards the contract was checked against.
+	SupportedStandards []string `json:"supported-standards,omitempty"`
 }
 
 // Metadata represents ABI contract metadata
@@ -115,11 +117,12 @@ type Event struct {
 }
 
 func (c *codegen) saveSequencePoint(n ast.Node) {
-	fset := c.buildInfo.program.Fset
+	fset := c.buildInfo.fset
 	start := fset.Position(n.Pos())
 	end := fset.Position(n.End())
 	c.sequencePoints[c.scope.name] = append(c.sequencePoints[c.scope.name], DebugSeqPoint{
 		Opcode:    c.prog.Len(),
+		Document:  c.getDocumentIndex(start.Filename),
 		StartLine: start.Line,
 		StartCol:  start.Offset,
 		EndLine:   end.Line,
@@ -127,10 +130,26 @@ func (c *codegen) saveSequencePoint(n ast.Node) {
 	})
 }
 
+// getDocumentIndex returns an index of the given file in the list of
+// documents adding it there if needed.
+func (c *codegen) getDocumentIndex(name string) int {
+	i, ok := c.docIndex[name]
+	if !ok {
+		i = len(c.documents)
+		c.docIndex[name] = i
+		c.documents = append(c.documents, name)
+	}
+	return i
+}
+
 func (c *codegen) emitDebugInfo() *DebugInfo {
 	d := &DebugInfo{
 		EntryPoint: mainIdent,
-		Events:     []EventDebugInfo{},
+		Documents:  c.documents,
+		Events:     c.events,
+	}
+	if d.Events == nil {
+		d.Events = []EventDebugInfo{}
 	}
 	for name, scope := range c.funcs {
 		m := c.methodInfoFromScope(name, scope)
@@ -303,7 +322,9 @@ func parsePairJSON(data []byte, sep string) (string, string, error) {
 	return ss[0], ss[1], nil
 }
 
-func (di *DebugInfo) convertToABI(contract []byte, cd *smartcontract.ContractDetails) ABI {
+// convertToABI creates ABI for the contract, operations required by the
+// given standards are listed as contract functions.
+func (di *DebugInfo) convertToABI(contract []byte, cd *smartcontract.ContractDetails, stds ...string) ABI {
 	methods := make([]Method, 0)
 	for _, method := range di.Methods {
 		if method.Name.Name == di.EntryPoint {
@@ -337,5 +358,7 @@ func (di *DebugInfo) convertToABI(contract []byte, cd *smartcontract.ContractDet
 		EntryPoint: di.EntryPoint,
 		Functions:  methods,
 		Events:     events,
+
+		SupportedStandards: stds,
 	}
 }
@@ -39,10 +39,10 @@ func methodArray() []bool { return nil }
 func methodStruct() struct{} { return struct{}{} }
 `
 
-	info, err := getBuildInfo(src)
+	info, err := getBuildInfo("foo.go", src)
 	require.NoError(t, err)
 
-	pkg := info.program.Package(info.initialPackage)
+	pkg := info.mainPackage
 	c := newCodegen(info, pkg)
 	require.NoError(t, c.compile(info, pkg))
 
@@ -176,10 +176,10 @@ func TestSequencePoints(t *testing.T) {
 		return false
 	}`
 
-	info, err := getBuildInfo(src)
+	info, err := getBuildInfo("foo.go", src)
 	require.NoError(t, err)
 
-	pkg := info.program.Package(info.initialPackage)
+	pkg := info.mainPackage
 	c := newCodegen(info, pkg)
 	require.NoError(t, c.compile(info, pkg))
 
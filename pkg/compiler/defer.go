@@ -0,0 +1,251 @@
+package compiler
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"github.com/ixje/neo-go-legacy/pkg/vm/emit"
+	"github.com/ixje/neo-go-legacy/pkg/vm/opcode"
+)
+
+// Names of the locals used to pass the panic to deferred functions.
+// They can't clash with Go identifiers.
+const (
+	panicLocal      = "<panic>"
+	panickingLocal  = "<panicking>"
+	deferFlagFormat = "<defer%d>"
+)
+
+// deferredCall is a call deferred till the return from the function. NeoVM
+// 2.x has no exception handling, so deferred calls are emitted at every exit
+// point of the function and are executed there if the corresponding defer
+// statement was executed before.
+type deferredCall struct {
+	// stmt is the defer statement.
+	stmt *ast.DeferStmt
+	// flag is the name of the local which is set to true
+	// when the defer statement is executed.
+	flag string
+	// values are the expressions evaluated by the defer statement.
+	values []ast.Expr
+	// names are the names of the locals values are stored to.
+	names []string
+	// call is the deferred call with all values replaced by their locals.
+	call *ast.CallExpr
+}
+
+// analyzeDefers collects all defer statements of the function body.
+func (c *codegen) analyzeDefers(body *ast.BlockStmt) error {
+	var (
+		err     error
+		inspect func(node ast.Node, inLoop bool)
+	)
+	inspect = func(node ast.Node, inLoop bool) {
+		ast.Inspect(node, func(node ast.Node) bool {
+			if err != nil {
+				return false
+			}
+			switch n := node.(type) {
+			case *ast.FuncLit:
+				return false
+			case *ast.ForStmt:
+				inspect(n.Body, true)
+				return false
+			case *ast.RangeStmt:
+				inspect(n.Body, true)
+				return false
+			case *ast.DeferStmt:
+				if inLoop {
+					err = errors.New("defer statements in loops are not supported")
+					return false
+				}
+				c.scope.defers = append(c.scope.defers, c.newDeferredCall(n))
+				return false
+			}
+			return true
+		})
+	}
+	inspect(body, false)
+	return err
+}
+
+// newDeferredCall creates a deferred call for the defer statement. Arguments
+// of the call, as well as the function value or the method receiver,
+// are evaluated when the defer statement is executed and are kept in locals
+// till the call.
+func (c *codegen) newDeferredCall(n *ast.DeferStmt) *deferredCall {
+	d := &deferredCall{
+		stmt: n,
+		flag: fmt.Sprintf(deferFlagFormat, len(c.scope.defers)),
+	}
+
+	call := *n.Call
+	call.Args = make([]ast.Expr, len(n.Call.Args))
+	for i, arg := range n.Call.Args {
+		call.Args[i] = c.addDeferredValue(d, arg)
+	}
+	if c.isFuncValueCall(n.Call) {
+		call.Fun = c.addDeferredValue(d, n.Call.Fun)
+	} else if fun, ok := n.Call.Fun.(*ast.SelectorExpr); ok && c.typeInfo.Selections[fun] != nil {
+		sel := *fun
+		sel.X = c.addDeferredValue(d, fun.X)
+		c.typeInfo.Selections[&sel] = c.typeInfo.Selections[fun]
+		call.Fun = &sel
+	}
+	c.typeInfo.Types[&call] = c.typeInfo.Types[n.Call]
+	d.call = &call
+	return d
+}
+
+// addDeferredValue adds the expression to the values evaluated by the defer
+// statement and returns an identifier referring to its local.
+func (c *codegen) addDeferredValue(d *deferredCall, expr ast.Expr) *ast.Ident {
+	name := fmt.Sprintf("%s.%d", d.flag, len(d.values))
+	typ := c.typeInfo.TypeOf(expr)
+	id := ast.NewIdent(name)
+	c.typeInfo.Types[id] = types.TypeAndValue{Type: typ}
+	c.typeInfo.Uses[id] = types.NewVar(token.NoPos, nil, name, typ)
+	d.values = append(d.values, expr)
+	d.names = append(d.names, name)
+	return id
+}
+
+// convertDefer evaluates the deferred call values and marks it for execution.
+func (c *codegen) convertDefer(n *ast.DeferStmt) {
+	var d *deferredCall
+	for i := range c.scope.defers {
+		if c.scope.defers[i].stmt == n {
+			d = c.scope.defers[i]
+		}
+	}
+	if d == nil {
+		c.prog.Err = errors.New("unexpected defer statement")
+		return
+	}
+
+	c.saveSequencePoint(n)
+	for i := range d.values {
+		ast.Walk(c, d.values[i])
+		c.emitStoreVar(d.names[i])
+	}
+	emit.Opcode(c.prog.BinWriter, opcode.PUSHT)
+	c.emitStoreVar(d.flag)
+}
+
+// emitDefers emits deferred calls of the current function in the reverse order.
+func (c *codegen) emitDefers() {
+	for i := len(c.scope.defers) - 1; i >= 0; i-- {
+		d := c.scope.defers[i]
+		skip := c.newLabel()
+		c.emitLoadLocal(d.flag)
+		emit.Jmp(c.prog.BinWriter, opcode.JMPIFNOT, skip)
+		ast.Walk(c, d.call)
+		// Results of deferred calls are discarded.
+		if t, ok := c.typeInfo.TypeOf(d.call).(*types.Tuple); ok {
+			c.dropItems(t.Len())
+		} else {
+			c.dropItems(1)
+		}
+		c.setLabel(skip)
+	}
+}
+
+// emitNamedResults loads named results of the current function on the stack.
+func (c *codegen) emitNamedResults() {
+	results := c.scope.decl.Type.Results
+	if results.NumFields() == 0 {
+		return
+	}
+	for i := len(results.List) - 1; i >= 0; i-- {
+		names := results.List[i].Names
+		for j := len(names) - 1; j >= 0; j-- {
+			c.emitLoadLocal(names[j].Name)
+		}
+	}
+}
+
+// storeNamedResults stores values from the stack into named results of
+// the current function, the first result is expected to be on top.
+func (c *codegen) storeNamedResults() {
+	results := c.scope.decl.Type.Results
+	for i := range results.List {
+		for _, name := range results.List[i].Names {
+			c.emitStoreVar(name.Name)
+		}
+	}
+}
+
+// convertPanicWithDefers emits panic which executes deferred calls of the
+// current function before aborting the execution. Any of them can stop
+// panicking via recover(), then the function returns normally.
+func (c *codegen) convertPanicWithDefers(arg ast.Expr) {
+	isNil := isExprNil(arg)
+	if isNil {
+		c.emitDefault(new(types.Slice))
+	} else if isStringType(c.typeInfo.Types[arg].Type) {
+		ast.Walk(c, arg)
+	} else {
+		c.prog.Err = errors.New("panic should have string or nil argument")
+		return
+	}
+	c.emitStoreVar(panicLocal)
+	emit.Opcode(c.prog.BinWriter, opcode.PUSHT)
+	c.emitStoreVar(panickingLocal)
+
+	c.emitDefers()
+
+	recovered := c.newLabel()
+	c.emitLoadLocal(panickingLocal)
+	emit.Jmp(c.prog.BinWriter, opcode.JMPIFNOT, recovered)
+	if !isNil {
+		c.emitLoadLocal(panicLocal)
+		emit.Syscall(c.prog.BinWriter, "Neo.Runtime.Log")
+	}
+	emit.Opcode(c.prog.BinWriter, opcode.THROW)
+
+	c.setLabel(recovered)
+	cnt := 0
+	for i := range c.labelList {
+		cnt += c.labelList[i].sz
+	}
+	c.dropItems(cnt)
+
+	results := c.scope.decl.Type.Results
+	if results.NumFields() != 0 && len(results.List[0].Names) != 0 {
+		c.emitNamedResults()
+	} else {
+		// Unnamed results have zero values.
+		for i := results.NumFields() - 1; i >= 0; i-- {
+			c.emitZeroValue(c.typeInfo.TypeOf(results.List[i].Type))
+		}
+	}
+	emit.Opcode(c.prog.BinWriter, opcode.FROMALTSTACK)
+	emit.Opcode(c.prog.BinWriter, opcode.DROP)
+	emit.Opcode(c.prog.BinWriter, opcode.RET)
+}
+
+// convertRecover emits recover() call. It stops panicking only when called
+// from a function literal deferred by the panicking function, otherwise
+// nil is returned.
+func (c *codegen) convertRecover() {
+	v, ok := c.scope.getCaptured(panickingLocal)
+	if !ok || v.depth != 1 {
+		c.emitDefault(new(types.Slice))
+		return
+	}
+
+	lNil := c.newLabel()
+	end := c.newLabel()
+	c.emitLoadLocal(panickingLocal)
+	emit.Jmp(c.prog.BinWriter, opcode.JMPIFNOT, lNil)
+	c.emitLoadLocal(panicLocal)
+	emit.Opcode(c.prog.BinWriter, opcode.PUSHF)
+	c.emitStoreVar(panickingLocal)
+	emit.Jmp(c.prog.BinWriter, opcode.JMP, end)
+	c.setLabel(lNil)
+	c.emitDefault(new(types.Slice))
+	c.setLabel(end)
+}
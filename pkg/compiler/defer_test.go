@@ -0,0 +1,236 @@
+package compiler_test
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ixje/neo-go-legacy/pkg/compiler"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefer(t *testing.T) {
+	t.Run("Order", func(t *testing.T) {
+		src := `
+		package testcase
+		func Main() (res int) {
+			defer func() { res *= 2 }()
+			defer func() { res++ }()
+			return 20
+		}`
+		eval(t, src, big.NewInt(42))
+	})
+	t.Run("ArgumentsEvaluatedEarly", func(t *testing.T) {
+		src := `
+		package testcase
+		func Main() (res int) {
+			x := 10
+			add := func(v int) { res += v }
+			defer add(x)
+			x = 32
+			return x
+		}`
+		eval(t, src, big.NewInt(42))
+	})
+	t.Run("Conditional", func(t *testing.T) {
+		src := `
+		package testcase
+		func Main() int {
+			return get(true) + get(false)
+		}
+		func get(ok bool) (res int) {
+			if ok {
+				defer func() { res = 40 }()
+			}
+			return 1
+		}`
+		eval(t, src, big.NewInt(41))
+	})
+	t.Run("VoidFunction", func(t *testing.T) {
+		src := `
+		package testcase
+		func Main() int {
+			arr := []int{0}
+			update(arr)
+			return arr[0]
+		}
+		func update(arr []int) {
+			defer set(arr, 40)
+			arr[0] = 1
+		}
+		func set(arr []int, v int) {
+			arr[0] = v + 2
+		}`
+		eval(t, src, big.NewInt(42))
+	})
+	t.Run("UnnamedResult", func(t *testing.T) {
+		src := `
+		package testcase
+		func Main() int {
+			x := []int{2}
+			return get(x) + x[0]
+		}
+		func get(x []int) int {
+			defer func() { x[0] = 0 }()
+			return x[0] * 21
+		}`
+		eval(t, src, big.NewInt(42))
+	})
+	t.Run("InLoop", func(t *testing.T) {
+		src := `
+		package testcase
+		func Main() int {
+			for i := 0; i < 2; i++ {
+				defer func() {}()
+			}
+			return 1
+		}`
+		_, err := compiler.Compile(strings.NewReader(src))
+		require.Error(t, err)
+	})
+}
+
+func TestRecover(t *testing.T) {
+	t.Run("Recovered", func(t *testing.T) {
+		src := `
+		package testcase
+		func Main() int {
+			return safeDiv(10, 0) + safeDiv(86, 2)
+		}
+		func safeDiv(a, b int) (res int) {
+			defer func() {
+				if recover() == "division by zero" {
+					res = -1
+				}
+			}()
+			if b == 0 {
+				panic("division by zero")
+			}
+			return a / b
+		}`
+		eval(t, src, big.NewInt(42))
+	})
+	t.Run("ZeroResult", func(t *testing.T) {
+		src := `
+		package testcase
+		func Main() int {
+			return get() + 42
+		}
+		func get() int {
+			defer func() {
+				_ = recover()
+			}()
+			panic("oops")
+		}`
+		eval(t, src, big.NewInt(42))
+	})
+	t.Run("Assign", func(t *testing.T) {
+		src := `
+		package testcase
+		func Main() int {
+			return get()
+		}
+		func get() (res int) {
+			defer func() {
+				x := recover()
+				if x == "boom" {
+					res = 3
+				}
+			}()
+			panic("boom")
+		}`
+		eval(t, src, big.NewInt(3))
+	})
+	t.Run("IfInit", func(t *testing.T) {
+		src := `
+		package testcase
+		func Main() int {
+			return get()
+		}
+		func get() (res int) {
+			defer func() {
+				if e := recover(); e == "boom" {
+					res = 3
+				}
+			}()
+			panic("boom")
+		}`
+		eval(t, src, big.NewInt(3))
+	})
+	t.Run("Argument", func(t *testing.T) {
+		src := `
+		package testcase
+		func Main() int {
+			return get()
+		}
+		func get() (res int) {
+			defer func() {
+				res = check(recover())
+			}()
+			panic("boom")
+		}
+		func check(e interface{}) int {
+			if e == "boom" {
+				return 3
+			}
+			return 0
+		}`
+		eval(t, src, big.NewInt(3))
+	})
+	t.Run("NoPanic", func(t *testing.T) {
+		src := `
+		package testcase
+		func Main() int {
+			return get()
+		}
+		func get() (res int) {
+			defer func() {
+				if e := recover(); e != nil {
+					res = 1
+				}
+			}()
+			return 3
+		}`
+		eval(t, src, big.NewInt(3))
+	})
+	t.Run("SwitchInit", func(t *testing.T) {
+		src := `
+		package testcase
+		func Main() int {
+			return get()
+		}
+		func get() (res int) {
+			defer func() {
+				switch e := recover(); e {
+				case "boom":
+					res = 3
+				}
+			}()
+			panic("boom")
+		}`
+		eval(t, src, big.NewInt(3))
+	})
+	t.Run("NotRecovered", func(t *testing.T) {
+		src := `
+		package testcase
+		func Main() int {
+			defer func() {}()
+			panic(nil)
+		}`
+		v := vmAndCompile(t, src)
+		require.Error(t, v.Run())
+	})
+	t.Run("DeclaredFunction", func(t *testing.T) {
+		src := `
+		package testcase
+		func Main() int {
+			defer rec()
+			panic(nil)
+		}
+		func rec() {
+			_ = recover()
+		}`
+		v := vmAndCompile(t, src)
+		require.Error(t, v.Run())
+	})
+}
@@ -0,0 +1,160 @@
+package compiler
+
+import (
+	"fmt"
+	"go/ast"
+	"go/constant"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// Events can be declared with functions having no results and a body
+// consisting of a single runtime.Notify call which passes a constant event
+// name followed by all function parameters in order, like:
+//
+//   func Transfer(from, to []byte, amount int) {
+//       runtime.Notify("transfer", from, to, amount)
+//   }
+//
+// Declared events are recorded in debug info and ABI with the names and
+// types of function parameters and every runtime.Notify call with the same
+// event name is checked to match the declaration.
+
+// resolveEvents registers events declared in the package.
+func (c *codegen) resolveEvents(pkg *packages.Package) {
+	c.typeInfo = pkg.TypesInfo
+	for _, f := range pkg.Syntax {
+		for _, decl := range f.Decls {
+			fd, ok := decl.(*ast.FuncDecl)
+			if !ok || fd.Recv != nil {
+				continue
+			}
+			name, ok := c.eventName(fd)
+			if !ok {
+				continue
+			}
+			if c.declaredEvents[name] {
+				c.prog.Err = fmt.Errorf("%s: event %q is already declared",
+					c.buildInfo.fset.Position(fd.Pos()), name)
+				return
+			}
+			params := make([]DebugParam, 0, fd.Type.Params.NumFields())
+			for _, field := range fd.Type.Params.List {
+				for _, id := range field.Names {
+					params = append(params, DebugParam{
+						Name: id.Name,
+						Type: c.scTypeFromExpr(field.Type),
+					})
+				}
+			}
+			c.declaredEvents[name] = true
+			c.events = append(c.events, EventDebugInfo{
+				ID:         name,
+				Name:       name,
+				Parameters: params,
+			})
+		}
+	}
+}
+
+// eventName returns the name of the event declared by the function.
+func (c *codegen) eventName(fd *ast.FuncDecl) (string, bool) {
+	if fd.Body == nil || len(fd.Body.List) != 1 || fd.Type.Results.NumFields() != 0 {
+		return "", false
+	}
+	stmt, ok := fd.Body.List[0].(*ast.ExprStmt)
+	if !ok {
+		return "", false
+	}
+	call, ok := stmt.X.(*ast.CallExpr)
+	if !ok || !isNotify(call) || len(call.Args) == 0 || call.Ellipsis.IsValid() {
+		return "", false
+	}
+	name, ok := c.constString(call.Args[0])
+	if !ok {
+		return "", false
+	}
+	var params []*ast.Ident
+	for _, field := range fd.Type.Params.List {
+		params = append(params, field.Names...)
+	}
+	if len(params) != len(call.Args)-1 {
+		return "", false
+	}
+	for i, arg := range call.Args[1:] {
+		id, ok := arg.(*ast.Ident)
+		if !ok || c.typeInfo.Uses[id] != c.typeInfo.Defs[params[i]] {
+			return "", false
+		}
+	}
+	return name, true
+}
+
+// isNotify checks if the call is a runtime.Notify call.
+func isNotify(call *ast.CallExpr) bool {
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != "Notify" {
+		return false
+	}
+	id, ok := sel.X.(*ast.Ident)
+	return ok && id.Name == "runtime"
+}
+
+// registerEvent checks that the notification sent with runtime.Notify
+// matches the event declaration or adds the event inferred from the call to
+// the list of contract events if it's not declared. Notifications are named
+// by their first argument which must be a constant string.
+func (c *codegen) registerEvent(expr *ast.CallExpr) {
+	if len(expr.Args) == 0 || expr.Ellipsis.IsValid() {
+		return
+	}
+	tv := c.typeInfo.Types[expr.Args[0]]
+	if tv.Value == nil || tv.Value.Kind() != constant.String {
+		return
+	}
+	name := constant.StringVal(tv.Value)
+	args := expr.Args[1:]
+	for i := range c.events {
+		if c.events[i].Name != name {
+			continue
+		}
+		if c.declaredEvents[name] {
+			c.checkEventArgs(&c.events[i], expr)
+		}
+		return
+	}
+
+	params := make([]DebugParam, len(args))
+	for i, arg := range args {
+		params[i].Name = fmt.Sprintf("arg%d", i+1)
+		if id, ok := arg.(*ast.Ident); ok {
+			params[i].Name = id.Name
+		}
+		params[i].Type = c.scTypeFromGo(c.typeInfo.TypeOf(arg))
+	}
+	c.events = append(c.events, EventDebugInfo{
+		ID:         name,
+		Name:       name,
+		Parameters: params,
+	})
+}
+
+// checkEventArgs checks that notification arguments match the declaration.
+func (c *codegen) checkEventArgs(e *EventDebugInfo, expr *ast.CallExpr) {
+	args := expr.Args[1:]
+	if len(args) != len(e.Parameters) {
+		pos := c.buildInfo.fset.Position(expr.Pos())
+		c.prog.Err = fmt.Errorf("%s: event %q has %d parameters, %d arguments are given",
+			pos, e.Name, len(e.Parameters), len(args))
+		return
+	}
+	for i, arg := range args {
+		typ := c.scTypeFromGo(c.typeInfo.TypeOf(arg))
+		if typ != e.Parameters[i].Type && typ != "Any" && e.Parameters[i].Type != "Any" {
+			c.prog.Err = fmt.Errorf("%s: parameter %s of event %q is %s, %s is given",
+				c.buildInfo.fset.Position(arg.Pos()), e.Parameters[i].Name, e.Name,
+				e.Parameters[i].Type, typ)
+			return
+		}
+	}
+}
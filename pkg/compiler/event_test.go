@@ -0,0 +1,102 @@
+package compiler
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventDeclarations(t *testing.T) {
+	compile := func(t *testing.T, src string) (*DebugInfo, error) {
+		info, err := getBuildInfo("foo.go", src)
+		require.NoError(t, err)
+		_, di, err := codeGen(info, &Options{})
+		return di, err
+	}
+
+	t.Run("declared", func(t *testing.T) {
+		src := `package foo
+		import "github.com/ixje/neo-go-legacy/pkg/interop/runtime"
+		func Main(op string, args []interface{}) bool {
+			from := args[0].([]byte)
+			Transfer(from, nil, 1)
+			runtime.Notify("transfer", args[0], from, 2)
+			runtime.Notify("log", op, len(args))
+			runtime.Notify("log", 1)
+			return true
+		}
+		// Transfer is an event.
+		func Transfer(from, to []byte, amount int) {
+			runtime.Notify("transfer", from, to, amount)
+		}`
+		di, err := compile(t, src)
+		require.NoError(t, err)
+		require.Equal(t, []EventDebugInfo{
+			{
+				ID:   "transfer",
+				Name: "transfer",
+				Parameters: []DebugParam{
+					{Name: "from", Type: "ByteArray"},
+					{Name: "to", Type: "ByteArray"},
+					{Name: "amount", Type: "Integer"},
+				},
+			},
+			{
+				ID:   "log",
+				Name: "log",
+				Parameters: []DebugParam{
+					{Name: "op", Type: "String"},
+					{Name: "arg2", Type: "Integer"},
+				},
+			},
+		}, di.Events)
+	})
+
+	testCases := []struct {
+		name   string
+		src    string
+		errMsg string
+	}{
+		{
+			name: "wrong number of arguments",
+			src: `package foo
+			import "github.com/ixje/neo-go-legacy/pkg/interop/runtime"
+			func Main() {
+				runtime.Notify("event", 1)
+			}
+			func Event(a int, b string) { runtime.Notify("event", a, b) }`,
+			errMsg: `foo.go:4:5: event "event" has 2 parameters, 1 arguments are given`,
+		},
+		{
+			name: "wrong argument type",
+			src: `package foo
+			import "github.com/ixje/neo-go-legacy/pkg/interop/runtime"
+			func Main() {
+				runtime.Notify("event", 1, true)
+			}
+			func Event(a int, b string) { runtime.Notify("event", a, b) }`,
+			errMsg: `foo.go:4:32: parameter b of event "event" is String, Boolean is given`,
+		},
+		{
+			name: "declared twice",
+			src: `package foo
+			import "github.com/ixje/neo-go-legacy/pkg/interop/runtime"
+			func Main() {}
+			func Event(a int) { runtime.Notify("event", a) }
+			func OtherEvent(b int) { runtime.Notify("event", b) }`,
+			errMsg: `foo.go:5:4: event "event" is already declared`,
+		},
+	}
+	// Positions refer to the absolute path of the file.
+	dir, err := os.Getwd()
+	require.NoError(t, err)
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := compile(t, tc.src)
+			require.Error(t, err)
+			require.Equal(t, filepath.Join(dir, tc.errMsg), err.Error())
+		})
+	}
+}
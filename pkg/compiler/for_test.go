@@ -3,13 +3,9 @@ package compiler_test
 import (
 	"fmt"
 	"math/big"
-	"strings"
 	"testing"
 
-	"github.com/ixje/neo-go-legacy/pkg/compiler"
-
 	"github.com/ixje/neo-go-legacy/pkg/vm"
-	"github.com/stretchr/testify/require"
 )
 
 func TestEntryPointWithMethod(t *testing.T) {
@@ -707,20 +703,80 @@ func TestForLoopRangeNoVariable(t *testing.T) {
 	eval(t, src, big.NewInt(3))
 }
 
-func TestForLoopRangeCompilerError(t *testing.T) {
+func TestForLoopRangeValue(t *testing.T) {
 	src := `
 	package foo
-	func f(a int) int { return 0 }
 	func Main() int {
 		arr := []int{1, 2, 3}
+		sum := 0
+		for i, v := range arr {
+			sum += i * v
+		}
 		for _, v := range arr {
-			f(v)
+			if v == 2 {
+				continue
+			}
+			sum += v
 		}
-		return 0
+		return sum
 	}`
 
-	_, err := compiler.Compile(strings.NewReader(src))
-	require.Error(t, err)
+	eval(t, src, big.NewInt(12))
+}
+
+func TestForLoopRangeMap(t *testing.T) {
+	t.Run("KeyValue", func(t *testing.T) {
+		src := `
+		package foo
+		func Main() int {
+			m := map[int]int{1: 10, 2: 20, 3: 30}
+			sum := 0
+			for k, v := range m {
+				sum += k * v
+			}
+			return sum
+		}`
+		eval(t, src, big.NewInt(140))
+	})
+	t.Run("Keys", func(t *testing.T) {
+		src := `
+		package foo
+		func Main() int {
+			m := map[string]int{"a": 1, "b": 2}
+			count := 0
+			for k := range m {
+				count += m[k]
+			}
+			return count
+		}`
+		eval(t, src, big.NewInt(3))
+	})
+	t.Run("Break", func(t *testing.T) {
+		src := `
+		package foo
+		func Main() int {
+			m := map[int]int{1: 10, 2: 20, 3: 30}
+			for _, v := range m {
+				if v == 20 {
+					return v + 22
+				}
+			}
+			return 0
+		}`
+		eval(t, src, big.NewInt(42))
+	})
+	t.Run("Empty", func(t *testing.T) {
+		src := `
+		package foo
+		func Main() int {
+			m := map[int]int{}
+			for range m {
+				return 1
+			}
+			return 2
+		}`
+		eval(t, src, big.NewInt(2))
+	})
 }
 
 func TestForLoopComplexConditions(t *testing.T) {
@@ -0,0 +1,261 @@
+package compiler_test
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ixje/neo-go-legacy/pkg/compiler"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFuncLiteral(t *testing.T) {
+	src := `
+		package testcase
+		func Main() int {
+			inc := func(x int) int {
+				return x + 1
+			}
+			return inc(inc(40))
+		}
+	`
+	eval(t, src, big.NewInt(42))
+}
+
+func TestFuncLiteralCalledDirectly(t *testing.T) {
+	src := `
+		package testcase
+		func Main() int {
+			return func(a, b int) int {
+				return a * b
+			}(6, 7)
+		}
+	`
+	eval(t, src, big.NewInt(42))
+}
+
+func TestClosureCapture(t *testing.T) {
+	src := `
+		package testcase
+		func Main() int {
+			x := 40
+			add := func(y int) int {
+				return x + y
+			}
+			x = 30
+			return add(12)
+		}
+	`
+	eval(t, src, big.NewInt(42))
+}
+
+func TestClosureModifiesCaptured(t *testing.T) {
+	src := `
+		package testcase
+		func Main() int {
+			sum := 0
+			add := func(x int) {
+				sum += x
+			}
+			for i := 0; i < 4; i++ {
+				add(i)
+			}
+			add(36)
+			return sum
+		}
+	`
+	evalWithoutStackChecks(t, src, big.NewInt(42))
+}
+
+func TestClosureCounter(t *testing.T) {
+	src := `
+		package testcase
+		func Main() int {
+			c1 := newCounter(10)
+			c2 := newCounter(30)
+			c1()
+			c1()
+			return c1() + c2()
+		}
+
+		func newCounter(start int) func() int {
+			n := start
+			return func() int {
+				n++
+				return n
+			}
+		}
+	`
+	evalWithoutStackChecks(t, src, big.NewInt(44))
+}
+
+func TestNestedClosures(t *testing.T) {
+	src := `
+		package testcase
+		func Main() int {
+			a := 1
+			f := func(b int) func(int) int {
+				return func(c int) int {
+					a *= 2
+					return a + b + c
+				}
+			}
+			g := f(10)
+			g(0)
+			return g(28)
+		}
+	`
+	evalWithoutStackChecks(t, src, big.NewInt(42))
+}
+
+func TestFuncCallback(t *testing.T) {
+	src := `
+		package testcase
+		func Main() int {
+			arr := []int{1, 2, 3, 4}
+			mul := 3
+			arr = apply(arr, func(x int) int { return x * mul })
+			return arr[0] + arr[1] + arr[2] + arr[3]
+		}
+
+		func apply(arr []int, f func(int) int) []int {
+			res := []int{}
+			for i := range arr {
+				res = append(res, f(arr[i]))
+			}
+			return res
+		}
+	`
+	eval(t, src, big.NewInt(30))
+}
+
+func TestFuncDeclAsValue(t *testing.T) {
+	src := `
+		package testcase
+		func Main() int {
+			op := add
+			a := op(20, 1)
+			op = mul
+			return call(op, a, 2)
+		}
+
+		func call(f func(int, int) int, a, b int) int {
+			return f(a, b)
+		}
+
+		func add(a, b int) int {
+			return a + b
+		}
+
+		func mul(a, b int) int {
+			return a * b
+		}
+	`
+	eval(t, src, big.NewInt(42))
+}
+
+func TestFuncValueInStruct(t *testing.T) {
+	src := `
+		package testcase
+		type handler struct {
+			name string
+			f    func(int) int
+		}
+
+		func Main() int {
+			h := handler{name: "double", f: func(x int) int { return x * 2 }}
+			return h.f(21)
+		}
+	`
+	eval(t, src, big.NewInt(42))
+}
+
+func TestGlobalFuncLiteral(t *testing.T) {
+	src := `
+		package testcase
+		var square = func(x int) int {
+			return x * x
+		}
+
+		func Main() int {
+			return square(6) + 6
+		}
+	`
+	eval(t, src, big.NewInt(42))
+}
+
+func TestSyscallAsValue(t *testing.T) {
+	src := `
+		package testcase
+		import "github.com/ixje/neo-go-legacy/pkg/interop/runtime"
+
+		func Main() int {
+			f := runtime.Log
+			f("log")
+			return 1
+		}
+	`
+	_, err := compiler.Compile(strings.NewReader(src))
+	require.Error(t, err)
+}
+
+func TestClosureInLoop(t *testing.T) {
+	t.Run("CapturesBodyVariable", func(t *testing.T) {
+		src := `
+		package testcase
+		func Main() []int {
+			var fs []func() int
+			for i := 0; i < 3; i++ {
+				x := 2 - i
+				fs = append(fs, func() int { return x })
+			}
+			return []int{fs[0](), fs[1](), fs[2]()}
+		}`
+		_, err := compiler.Compile(strings.NewReader(src))
+		require.Error(t, err)
+	})
+	t.Run("CapturesLoopVariable", func(t *testing.T) {
+		src := `
+		package testcase
+		func Main() int {
+			var f func() int
+			for _, v := range []int{1, 2} {
+				if v == 1 {
+					f = func() int { return v }
+				}
+			}
+			return f()
+		}`
+		_, err := compiler.Compile(strings.NewReader(src))
+		require.Error(t, err)
+	})
+	t.Run("CalledDirectly", func(t *testing.T) {
+		src := `
+		package testcase
+		func Main() int {
+			sum := 0
+			for i := 0; i < 3; i++ {
+				x := i * 2
+				func() { sum += x }()
+			}
+			return sum
+		}`
+		eval(t, src, big.NewInt(6))
+	})
+	t.Run("CapturesOuterVariable", func(t *testing.T) {
+		src := `
+		package testcase
+		func Main() int {
+			sum := 0
+			var fs []func()
+			for i := 0; i < 3; i++ {
+				fs = append(fs, func() { sum++ })
+			}
+			for i := range fs {
+				fs[i]()
+			}
+			return sum
+		}`
+		eval(t, src, big.NewInt(3))
+	})
+}
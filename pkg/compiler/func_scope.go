@@ -15,9 +15,21 @@ type funcScope struct {
 	// from other packages should have a selector.
 	selector *ast.Ident
 
+	// Import path of the package the function is imported from.
+	pkgPath string
+
 	// The declaration of the function in the AST. Nil if this scope is not a function.
 	decl *ast.FuncDecl
 
+	// Function literal this scope was lifted from. Nil for declared functions.
+	lit *ast.FuncLit
+
+	// Variables of the enclosing functions captured by the function literal.
+	captured map[string]capturedVar
+
+	// Calls deferred by the function in the order of defer statements.
+	defers []*deferredCall
+
 	// Program label of the scope
 	label uint16
 
@@ -41,9 +53,18 @@ type funcScope struct {
 	i int
 }
 
+// capturedVar describes the location of a variable captured by a closure.
+type capturedVar struct {
+	// depth is the number of enclosing functions to go through,
+	// 1 means the variable belongs to the function creating the closure.
+	depth int
+	// pos is the position of the variable in the locals of that function.
+	pos int
+}
+
 func newFuncScope(decl *ast.FuncDecl, label uint16) *funcScope {
 	return &funcScope{
-		name:      decl.Name.Name,
+		name:      methodName(decl),
 		decl:      decl,
 		label:     label,
 		locals:    map[string]int{},
@@ -95,14 +116,17 @@ func (c *funcScope) stackSize() int64 {
 			}
 		case *ast.ReturnStmt, *ast.IfStmt:
 			size++
-		// This handles the inline GenDecl like "var x = 2"
-		case *ast.GenDecl:
-			switch t := n.Specs[0].(type) {
-			case *ast.ValueSpec:
-				if len(t.Values) > 0 {
-					size++
-				}
-			}
+		// Key and value variables of range loops.
+		case *ast.RangeStmt:
+			size += 2
+		// Deferred call arguments are kept in locals together with the flag
+		// and function value or receiver. Also reserve space for the panic.
+		case *ast.DeferStmt:
+			size += len(n.Call.Args) + 4
+		// This handles the inline GenDecl like "var x = 2" as well as
+		// "var x, y int" where variables are initialized to zero values.
+		case *ast.ValueSpec:
+			size += len(n.Names)
 		}
 		return true
 	})
@@ -112,6 +136,10 @@ func (c *funcScope) stackSize() int64 {
 	if c.decl.Recv != nil {
 		numArgs += len(c.decl.Recv.List)
 	}
+	// Closures get the environment as an additional argument.
+	if c.lit != nil {
+		numArgs++
+	}
 	return int64(size + numArgs + len(c.voidCalls))
 }
 
@@ -131,3 +159,13 @@ func (c *funcScope) loadLocal(name string) int {
 	}
 	return i
 }
+
+// getCaptured returns the location of a captured variable with the specified
+// name unless it is shadowed by the local one.
+func (c *funcScope) getCaptured(name string) (capturedVar, bool) {
+	if _, ok := c.locals[name]; ok {
+		return capturedVar{}, false
+	}
+	v, ok := c.captured[name]
+	return v, ok
+}
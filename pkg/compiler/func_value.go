@@ -0,0 +1,275 @@
+package compiler
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+	"strconv"
+
+	"github.com/ixje/neo-go-legacy/pkg/vm/emit"
+	"github.com/ixje/neo-go-legacy/pkg/vm/opcode"
+	"golang.org/x/tools/go/packages"
+)
+
+// envLocal is the name of the local variable holding closure environment.
+// It can't clash with Go identifiers.
+const envLocal = "<env>"
+
+// funcValue represents a function which can be used as a value. NeoVM 2.x
+// has no indirect calls, so every such function gets an identifier and
+// function values are represented by an array of this identifier and an
+// environment, which is an array of locals of the function creating the
+// closure. Calls to function values are dispatched by the identifier among
+// all functions with the same signature.
+type funcValue struct {
+	// id is a unique identifier of the function.
+	id int
+	// sig is the signature of the function.
+	sig *types.Signature
+	// pkg is the package function is declared in.
+	pkg *packages.Package
+	// lit is the function literal. Nil for declared functions.
+	lit *ast.FuncLit
+	// scope is the scope of the function, for literals it is created
+	// by lifting them into separate functions.
+	scope *funcScope
+	// created is true if the code creating the closure was emitted.
+	created bool
+	// called is true if the function literal is called right where it's
+	// created, so the closure can't outlive the variables it captures.
+	called bool
+}
+
+func (c *codegen) newFuncValue(typ types.Type, pkg *packages.Package) *funcValue {
+	fv := &funcValue{
+		id:  len(c.funcValues) + 1,
+		sig: typ.(*types.Signature),
+		pkg: pkg,
+	}
+	c.funcValues = append(c.funcValues, fv)
+	return fv
+}
+
+// analyzeFuncValues assigns identifiers to all function literals of the package
+// and to all functions used as values instead of being called.
+func (c *codegen) analyzeFuncValues(pkg *packages.Package) {
+	count := map[string]int{}
+	for _, f := range pkg.Syntax {
+		for _, decl := range f.Decls {
+			prefix := "glob"
+			if fd, ok := decl.(*ast.FuncDecl); ok {
+				prefix = fd.Name.Name
+			}
+			called := map[*ast.Ident]bool{}
+			calledLits := map[*ast.FuncLit]bool{}
+			ast.Inspect(decl, func(node ast.Node) bool {
+				switch n := node.(type) {
+				case *ast.CallExpr:
+					switch fun := n.Fun.(type) {
+					case *ast.Ident:
+						called[fun] = true
+					case *ast.SelectorExpr:
+						called[fun.Sel] = true
+					case *ast.FuncLit:
+						calledLits[fun] = true
+					}
+				case *ast.FuncLit:
+					count[prefix]++
+					decl := &ast.FuncDecl{
+						Name: ast.NewIdent(prefix + ".func" + strconv.Itoa(count[prefix])),
+						Type: n.Type,
+						Body: n.Body,
+					}
+					fv := c.newFuncValue(pkg.TypesInfo.TypeOf(n), pkg)
+					fv.lit = n
+					fv.called = calledLits[n]
+					fv.scope = newFuncScope(decl, c.newLabel())
+					fv.scope.lit = n
+					c.funcLits[n] = fv
+				case *ast.Ident:
+					fn, ok := pkg.TypesInfo.Uses[n].(*types.Func)
+					if !ok || called[n] || c.funcRefs[fn] != nil {
+						return true
+					}
+					if fn.Type().(*types.Signature).Recv() != nil || fn.Pkg() == nil {
+						return true
+					}
+					if _, ok := getSyscall(fn.Pkg().Name(), fn.Pkg().Path(), fn.Name()); ok {
+						return true
+					}
+					scope, ok := c.funcs[fn.Name()]
+					if !ok {
+						return true
+					}
+					fv := c.newFuncValue(fn.Type(), pkg)
+					fv.scope = scope
+					c.funcRefs[fn] = fv
+				}
+				return true
+			})
+		}
+	}
+}
+
+// convertFuncLitValue emits code creating a closure for the function literal.
+// All variables it uses from enclosing functions are captured by reference.
+// Closures can't capture variables declared in a loop they're created in
+// unless they're called right away, because every loop iteration has its own
+// variable in Go, while the closure environment only has one.
+func (c *codegen) convertFuncLitValue(lit *ast.FuncLit) {
+	fv := c.funcLits[lit]
+	fv.created = true
+	fv.scope.captured = map[string]capturedVar{}
+
+	ast.Inspect(lit.Body, func(node ast.Node) bool {
+		id, ok := node.(*ast.Ident)
+		if !ok {
+			return true
+		}
+		v, ok := c.typeInfo.Uses[id].(*types.Var)
+		if !ok || v.IsField() || v.Pkg() == nil || v.Parent() == v.Pkg().Scope() {
+			return true
+		}
+		if lit.Pos() <= v.Pos() && v.Pos() < lit.End() {
+			return true
+		}
+		name := v.Name()
+		if pv, ok := c.scope.getCaptured(name); ok {
+			pv.depth++
+			fv.scope.captured[name] = pv
+		} else {
+			if !fv.called && c.isDeclaredInLoop(v, lit) {
+				c.prog.Err = fmt.Errorf("closure can't capture variable %s declared in the loop it's created in", name)
+			}
+			fv.scope.captured[name] = capturedVar{depth: 1, pos: c.scope.loadLocal(name)}
+		}
+		return true
+	})
+	// Closures deferred by the function can recover from its panic.
+	if pos, ok := c.scope.locals[panickingLocal]; ok {
+		fv.scope.captured[panicLocal] = capturedVar{depth: 1, pos: c.scope.locals[panicLocal]}
+		fv.scope.captured[panickingLocal] = capturedVar{depth: 1, pos: pos}
+	}
+
+	emit.Opcode(c.prog.BinWriter, opcode.DUPFROMALTSTACK)
+	emit.Int(c.prog.BinWriter, int64(fv.id))
+	emit.Opcode(c.prog.BinWriter, opcode.PUSH2)
+	emit.Opcode(c.prog.BinWriter, opcode.PACK)
+}
+
+// isDeclaredInLoop checks if the variable is declared in a loop of the current
+// function containing the node.
+func (c *codegen) isDeclaredInLoop(v *types.Var, n ast.Node) bool {
+	var found bool
+	ast.Inspect(c.scope.decl.Body, func(node ast.Node) bool {
+		switch node.(type) {
+		case *ast.ForStmt, *ast.RangeStmt:
+			if node.Pos() <= v.Pos() && v.Pos() < node.End() &&
+				node.Pos() <= n.Pos() && n.End() <= node.End() {
+				found = true
+			}
+		}
+		return !found
+	})
+	return found
+}
+
+// emitFuncRef emits a value of the function declared in the program.
+func (c *codegen) emitFuncRef(fn *types.Func) {
+	fv, ok := c.funcRefs[fn]
+	if !ok {
+		c.prog.Err = fmt.Errorf("function %s can't be used as a value", fn.Name())
+		return
+	}
+	// Declared functions have no environment.
+	emit.Opcode(c.prog.BinWriter, opcode.PUSH0)
+	emit.Int(c.prog.BinWriter, int64(fv.id))
+	emit.Opcode(c.prog.BinWriter, opcode.PUSH2)
+	emit.Opcode(c.prog.BinWriter, opcode.PACK)
+}
+
+// emitLoadEnv loads the locals of the function depth levels up from the
+// current closure.
+func (c *codegen) emitLoadEnv(depth int) {
+	c.emitLoadLocal(envLocal)
+	for i := 1; i < depth; i++ {
+		// Environment is always the first local of the closure.
+		c.emitLoadField(0)
+	}
+}
+
+// isFuncValueCall checks if the function being called is not known at
+// compile-time.
+func (c *codegen) isFuncValueCall(n *ast.CallExpr) bool {
+	switch fun := n.Fun.(type) {
+	case *ast.Ident:
+		_, ok := c.typeInfo.ObjectOf(fun).(*types.Var)
+		return ok
+	case *ast.SelectorExpr:
+		if sel := c.typeInfo.Selections[fun]; sel != nil {
+			return sel.Kind() == types.FieldVal
+		}
+		_, ok := c.typeInfo.ObjectOf(fun.Sel).(*types.Var)
+		return ok
+	case *ast.FuncLit, *ast.CallExpr, *ast.IndexExpr:
+		return true
+	}
+	return false
+}
+
+// convertFuncValueCall emits a call of the function value. Closure environment
+// is passed as an additional first argument.
+func (c *codegen) convertFuncValueCall(n *ast.CallExpr) {
+	sig, ok := c.typeInfo.TypeOf(n.Fun).Underlying().(*types.Signature)
+	if !ok {
+		c.prog.Err = fmt.Errorf("%s is not a function", n.Fun)
+		return
+	}
+	if sig.Variadic() {
+		c.prog.Err = fmt.Errorf("variadic function values are not supported")
+		return
+	}
+
+	c.saveSequencePoint(n)
+	c.walkArgs(sig, n.Args)
+	c.emitReverse(len(n.Args))
+
+	ast.Walk(c, n.Fun)
+	emit.Opcode(c.prog.BinWriter, opcode.UNPACK)
+	emit.Opcode(c.prog.BinWriter, opcode.DROP) // item count
+
+	end := c.newLabel()
+	for _, fv := range c.funcValues {
+		if !types.Identical(fv.sig, sig) {
+			continue
+		}
+		next := c.newLabel()
+		emit.Opcode(c.prog.BinWriter, opcode.DUP)
+		emit.Int(c.prog.BinWriter, int64(fv.id))
+		emit.Opcode(c.prog.BinWriter, opcode.NUMEQUAL)
+		emit.Jmp(c.prog.BinWriter, opcode.JMPIFNOT, next)
+		emit.Opcode(c.prog.BinWriter, opcode.DROP) // function id
+		if fv.lit == nil {
+			emit.Opcode(c.prog.BinWriter, opcode.DROP) // environment
+		}
+		emit.Call(c.prog.BinWriter, opcode.CALL, fv.scope.label)
+		emit.Jmp(c.prog.BinWriter, opcode.JMP, end)
+		c.setLabel(next)
+	}
+	// Nil or unknown function.
+	emit.Opcode(c.prog.BinWriter, opcode.THROW)
+	c.setLabel(end)
+}
+
+// convertFuncLit converts the function literal lifted into a separate function.
+func (c *codegen) convertFuncLit(fv *funcValue) {
+	if !fv.created {
+		// Closure is never created, thus this code is unreachable.
+		c.setLabel(fv.scope.label)
+		emit.Opcode(c.prog.BinWriter, opcode.THROW)
+		return
+	}
+	c.typeInfo = fv.pkg.TypesInfo
+	c.funcs[fv.scope.name] = fv.scope
+	c.convertFuncDecl(fv.pkg.Syntax, fv.scope.decl)
+}
@@ -91,3 +91,16 @@ func TestNestedIF(t *testing.T) {
 	`
 	eval(t, src, []byte{})
 }
+
+func TestIfInit(t *testing.T) {
+	src := `
+		package testcase
+		func Main() int {
+			if x := 10; x > 5 {
+				return x - 5
+			}
+			return 0
+		}
+	`
+	eval(t, src, big.NewInt(5))
+}
@@ -0,0 +1,306 @@
+package compiler
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+
+	"github.com/ixje/neo-go-legacy/pkg/vm/emit"
+	"github.com/ixje/neo-go-legacy/pkg/vm/opcode"
+	"golang.org/x/tools/go/packages"
+)
+
+// Values of interfaces with methods are represented by an array of the type
+// tag of the dynamic type and the value itself. Type tags are assigned to all
+// named types having methods, so that interface method calls can be
+// dispatched at runtime. Empty interfaces hold values as is because they are
+// used to pass arbitrary values to and from interop functions.
+
+// analyzeTypes assigns type tags to all named types with methods.
+func (c *codegen) analyzeTypes(pkg *packages.Package) {
+	scope := pkg.Types.Scope()
+	for _, name := range scope.Names() {
+		tn, ok := scope.Lookup(name).(*types.TypeName)
+		if !ok {
+			continue
+		}
+		named, ok := tn.Type().(*types.Named)
+		if !ok || types.IsInterface(named) || named.NumMethods() == 0 {
+			continue
+		}
+		c.taggedTypes = append(c.taggedTypes, named)
+	}
+}
+
+// typeTag returns the type tag of the type or -1 if it has no tag.
+func (c *codegen) typeTag(typ types.Type) int {
+	if ptr, ok := typ.(*types.Pointer); ok {
+		typ = ptr.Elem()
+	}
+	for i := range c.taggedTypes {
+		if types.Identical(c.taggedTypes[i], typ) {
+			return i + 1
+		}
+	}
+	return -1
+}
+
+// isMethodInterface checks if the type is an interface with methods.
+func isMethodInterface(typ types.Type) bool {
+	iface, ok := typ.Underlying().(*types.Interface)
+	return ok && iface.NumMethods() != 0
+}
+
+// methodName returns the name of the function declaration, methods are
+// prefixed by the name of the receiver type.
+func methodName(decl *ast.FuncDecl) string {
+	if decl.Recv == nil || len(decl.Recv.List) == 0 {
+		return decl.Name.Name
+	}
+	typ := decl.Recv.List[0].Type
+	if star, ok := typ.(*ast.StarExpr); ok {
+		typ = star.X
+	}
+	if id, ok := typ.(*ast.Ident); ok {
+		return id.Name + "." + decl.Name.Name
+	}
+	return decl.Name.Name
+}
+
+// methodNameFromFunc returns the name of the method scope.
+func methodNameFromFunc(fn *types.Func) string {
+	recv := fn.Type().(*types.Signature).Recv()
+	if recv == nil {
+		return fn.Name()
+	}
+	typ := recv.Type()
+	if ptr, ok := typ.(*types.Pointer); ok {
+		typ = ptr.Elem()
+	}
+	if named, ok := typ.(*types.Named); ok {
+		return named.Obj().Name() + "." + fn.Name()
+	}
+	return fn.Name()
+}
+
+// walkConverted converts the expression and then converts its value
+// to the specified type.
+func (c *codegen) walkConverted(expr ast.Expr, to types.Type) {
+	ast.Walk(c, expr)
+	if to != nil {
+		c.emitConvert(c.typeInfo.TypeOf(expr), to)
+	}
+}
+
+// emitConvert converts the value on top of the stack between interface and
+// concrete types representation.
+func (c *codegen) emitConvert(from, to types.Type) {
+	if from == nil || to == nil {
+		return
+	}
+	if b, ok := from.(*types.Basic); ok && b.Kind() == types.UntypedNil {
+		return
+	}
+	switch {
+	case isMethodInterface(to) && !types.IsInterface(from):
+		tag := c.typeTag(from)
+		if tag < 0 {
+			c.prog.Err = fmt.Errorf("%s can't be used as %s value", from, to)
+			return
+		}
+		emit.Int(c.prog.BinWriter, int64(tag))
+		emit.Opcode(c.prog.BinWriter, opcode.PUSH2)
+		emit.Opcode(c.prog.BinWriter, opcode.PACK)
+	case isMethodInterface(from) && types.IsInterface(to) && !isMethodInterface(to):
+		c.emitLoadField(1)
+	}
+}
+
+// convertTypeAssert converts type assertion from the interface with methods.
+// Assertion to the concrete type panics if the dynamic type is different.
+func (c *codegen) convertTypeAssert(n *ast.TypeAssertExpr) {
+	ast.Walk(c, n.X)
+
+	from := c.typeInfo.TypeOf(n.X)
+	to := c.typeInfo.TypeOf(n.Type)
+	// Type of the comma-ok assertion is recorded as (T, bool) tuple.
+	if _, ok := c.typeInfo.TypeOf(n).(*types.Tuple); ok {
+		if !isMethodInterface(from) || types.IsInterface(to) {
+			c.prog.Err = fmt.Errorf("comma-ok assertion from %s to %s is not supported", from, to)
+			return
+		}
+		c.convertCommaOkAssert(to)
+		return
+	}
+	switch {
+	case isMethodInterface(from) && !types.IsInterface(to):
+		tag := c.typeTag(to)
+		if tag < 0 {
+			c.prog.Err = fmt.Errorf("impossible type assertion to %s", to)
+			return
+		}
+		emit.Opcode(c.prog.BinWriter, opcode.DUP)
+		c.emitLoadField(0)
+		emit.Int(c.prog.BinWriter, int64(tag))
+		emit.Opcode(c.prog.BinWriter, opcode.NUMEQUAL)
+		emit.Opcode(c.prog.BinWriter, opcode.THROWIFNOT)
+		c.emitLoadField(1)
+	case isMethodInterface(from):
+		c.emitConvert(from, to)
+	case isMethodInterface(to):
+		c.prog.Err = fmt.Errorf("assertion from %s to %s is not supported", from, to)
+	}
+}
+
+// convertCommaOkAssert converts the value of the interface with methods on
+// top of the stack to the value of the concrete type and the flag telling
+// whether the assertion succeeded. The value is left on top of the stack
+// as the assignment expects, the zero value is used if the dynamic type
+// is different or the interface is nil.
+func (c *codegen) convertCommaOkAssert(to types.Type) {
+	tag := c.typeTag(to)
+	if tag < 0 {
+		c.prog.Err = fmt.Errorf("impossible type assertion to %s", to)
+		return
+	}
+	fail := c.newLabel()
+	end := c.newLabel()
+	emit.Opcode(c.prog.BinWriter, opcode.DUP)
+	emit.Opcode(c.prog.BinWriter, opcode.ARRAYSIZE)
+	emit.Jmp(c.prog.BinWriter, opcode.JMPIFNOT, fail)
+	emit.Opcode(c.prog.BinWriter, opcode.DUP)
+	c.emitLoadField(0)
+	emit.Int(c.prog.BinWriter, int64(tag))
+	emit.Opcode(c.prog.BinWriter, opcode.NUMEQUAL)
+	emit.Jmp(c.prog.BinWriter, opcode.JMPIFNOT, fail)
+	c.emitLoadField(1)
+	emit.Bool(c.prog.BinWriter, true)
+	emit.Opcode(c.prog.BinWriter, opcode.SWAP)
+	emit.Jmp(c.prog.BinWriter, opcode.JMP, end)
+
+	c.setLabel(fail)
+	emit.Opcode(c.prog.BinWriter, opcode.DROP)
+	emit.Bool(c.prog.BinWriter, false)
+	c.emitZeroValue(to)
+	c.setLabel(end)
+}
+
+// implementations returns all tagged types implementing the interface
+// together with the corresponding method.
+func (c *codegen) implementations(iface *types.Interface, name string) ([]int, []*types.Func) {
+	var (
+		tags  []int
+		funcs []*types.Func
+	)
+	for i, typ := range c.taggedTypes {
+		if !types.Implements(typ, iface) && !types.Implements(types.NewPointer(typ), iface) {
+			continue
+		}
+		obj, _, _ := types.LookupFieldOrMethod(typ, true, typ.Obj().Pkg(), name)
+		fn, ok := obj.(*types.Func)
+		if !ok {
+			continue
+		}
+		tags = append(tags, i+1)
+		funcs = append(funcs, fn)
+	}
+	return tags, funcs
+}
+
+// convertInterfaceCall emits the call of the interface method. It is
+// dispatched by the type tag of the receiver unless there is the only
+// type implementing the interface.
+func (c *codegen) convertInterfaceCall(n *ast.CallExpr, fun *ast.SelectorExpr) {
+	sig := c.typeInfo.TypeOf(fun).(*types.Signature)
+	iface := c.typeInfo.TypeOf(fun.X).Underlying().(*types.Interface)
+	tags, funcs := c.implementations(iface, fun.Sel.Name)
+
+	c.saveSequencePoint(n)
+	ast.Walk(c, fun.X)
+	c.walkArgs(sig, n.Args)
+	c.emitReverse(len(n.Args) + 1)
+
+	scopes := make([]*funcScope, len(funcs))
+	for i := range funcs {
+		f, ok := c.funcs[methodNameFromFunc(funcs[i])]
+		if !ok {
+			c.prog.Err = fmt.Errorf("could not resolve method %s", methodNameFromFunc(funcs[i]))
+			return
+		}
+		scopes[i] = f
+	}
+
+	if len(scopes) == 1 {
+		c.emitLoadField(1)
+		emit.Call(c.prog.BinWriter, opcode.CALL, scopes[0].label)
+		return
+	}
+
+	end := c.newLabel()
+	emit.Opcode(c.prog.BinWriter, opcode.DUP)
+	c.emitLoadField(0)
+	for i := range scopes {
+		next := c.newLabel()
+		emit.Opcode(c.prog.BinWriter, opcode.DUP)
+		emit.Int(c.prog.BinWriter, int64(tags[i]))
+		emit.Opcode(c.prog.BinWriter, opcode.NUMEQUAL)
+		emit.Jmp(c.prog.BinWriter, opcode.JMPIFNOT, next)
+		emit.Opcode(c.prog.BinWriter, opcode.DROP) // type tag
+		c.emitLoadField(1)
+		emit.Call(c.prog.BinWriter, opcode.CALL, scopes[i].label)
+		emit.Jmp(c.prog.BinWriter, opcode.JMP, end)
+		c.setLabel(next)
+	}
+	// Nil interface value.
+	emit.Opcode(c.prog.BinWriter, opcode.THROW)
+	c.setLabel(end)
+}
+
+// walkArgs converts call arguments to the types of the function parameters.
+func (c *codegen) walkArgs(sig *types.Signature, args []ast.Expr) {
+	for i, arg := range args {
+		c.walkConverted(arg, paramType(sig, i))
+	}
+}
+
+// paramType returns the type of i-th parameter of the function or nil
+// if it is variadic.
+func paramType(sig *types.Signature, i int) types.Type {
+	if sig == nil || i >= sig.Params().Len() || sig.Variadic() && i == sig.Params().Len()-1 {
+		return nil
+	}
+	return sig.Params().At(i).Type()
+}
+
+// resultTypes returns the types of the current function results.
+func (c *codegen) resultTypes() []types.Type {
+	var res []types.Type
+	results := c.scope.decl.Type.Results
+	if results == nil {
+		return nil
+	}
+	for _, field := range results.List {
+		typ := c.typeInfo.TypeOf(field.Type)
+		n := len(field.Names)
+		if n == 0 {
+			n = 1
+		}
+		for i := 0; i < n; i++ {
+			res = append(res, typ)
+		}
+	}
+	return res
+}
+
+// walkResults converts results of the return statement to the types of the
+// function results, the first result is left on top of the stack.
+func (c *codegen) walkResults(results []ast.Expr) {
+	typs := c.resultTypes()
+	for i := len(results) - 1; i >= 0; i-- {
+		var typ types.Type
+		if len(results) == len(typs) {
+			typ = typs[i]
+		}
+		c.walkConverted(results[i], typ)
+	}
+}
@@ -0,0 +1,196 @@
+package compiler_test
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ixje/neo-go-legacy/pkg/compiler"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMethodsOnNamedTypes(t *testing.T) {
+	t.Run("Integer", func(t *testing.T) {
+		src := `package foo
+		type Amount int
+		func (a Amount) Double() Amount { return a * 2 }
+		func Main() int {
+			a := Amount(20)
+			return int(a.Double()) + 2
+		}`
+		eval(t, src, big.NewInt(42))
+	})
+	t.Run("Slice", func(t *testing.T) {
+		src := `package foo
+		type List []int
+		func (l List) Sum() int {
+			s := 0
+			for _, v := range l {
+				s += v
+			}
+			return s
+		}
+		func Main() int {
+			l := List{10, 12, 20}
+			return l.Sum()
+		}`
+		eval(t, src, big.NewInt(42))
+	})
+	t.Run("SameName", func(t *testing.T) {
+		src := `package foo
+		type A int
+		type B int
+		func (a A) Value() int { return int(a) + 1 }
+		func (b B) Value() int { return int(b) * 2 }
+		func Main() int {
+			a := A(1)
+			b := B(20)
+			return a.Value() + b.Value()
+		}`
+		eval(t, src, big.NewInt(42))
+	})
+	t.Run("PointerReceiver", func(t *testing.T) {
+		src := `package foo
+		type counter struct { n int }
+		func (c *counter) Inc() { c.n++ }
+		func Main() int {
+			c := counter{n: 40}
+			c.Inc()
+			return c.n
+		}`
+		_, err := compiler.Compile(strings.NewReader(src))
+		require.Error(t, err)
+	})
+}
+
+func TestInterfaces(t *testing.T) {
+	shapes := `package foo
+		type Shape interface { Area() int }
+		type rect struct { w, h int }
+		func (r rect) Area() int { return r.w * r.h }
+		type square int
+		func (s square) Area() int { return int(s * s) }
+		`
+	t.Run("Dispatch", func(t *testing.T) {
+		src := shapes + `
+		func Main() int {
+			list := []Shape{rect{w: 2, h: 3}, square(6)}
+			sum := 0
+			for _, s := range list {
+				sum += s.Area()
+			}
+			return sum
+		}`
+		eval(t, src, big.NewInt(42))
+	})
+	t.Run("ParameterAndResult", func(t *testing.T) {
+		src := shapes + `
+		func getShape(big bool) Shape {
+			if big {
+				return square(6)
+			}
+			return rect{w: 2, h: 3}
+		}
+		func area(s Shape) int { return s.Area() }
+		func Main() int {
+			var s Shape = getShape(false)
+			return area(s) + area(getShape(true))
+		}`
+		eval(t, src, big.NewInt(42))
+	})
+	t.Run("StructFieldAndMap", func(t *testing.T) {
+		src := shapes + `
+		type holder struct { s Shape }
+		func Main() int {
+			h := holder{s: square(6)}
+			m := map[string]Shape{"r": rect{w: 2, h: 3}}
+			return h.s.Area() + m["r"].Area()
+		}`
+		eval(t, src, big.NewInt(42))
+	})
+	t.Run("SingleImplementation", func(t *testing.T) {
+		src := `package foo
+		type Getter interface { Get() int }
+		type value int
+		func (v value) Get() int { return int(v) + 2 }
+		func Main() int {
+			var g Getter = value(40)
+			return g.Get()
+		}`
+		eval(t, src, big.NewInt(42))
+	})
+	t.Run("TypeAssertion", func(t *testing.T) {
+		src := shapes + `
+		func Main() int {
+			var s Shape = rect{w: 40, h: 1}
+			var x interface{} = s
+			return s.(rect).w + x.(rect).h + 1
+		}`
+		eval(t, src, big.NewInt(42))
+	})
+	t.Run("InvalidTypeAssertion", func(t *testing.T) {
+		src := shapes + `
+		func Main() int {
+			var s Shape = rect{w: 40, h: 1}
+			return int(s.(square))
+		}`
+		v := vmAndCompile(t, src)
+		require.Error(t, v.Run())
+	})
+	t.Run("CommaOkAssertion", func(t *testing.T) {
+		src := shapes + `
+		func area(s Shape) int {
+			if r, ok := s.(rect); ok {
+				return r.w * 10
+			}
+			var r, ok = s.(rect)
+			if ok || r.w != 0 {
+				return -1
+			}
+			return 2
+		}
+		func Main() int {
+			var s Shape
+			return area(rect{w: 4, h: 1}) + area(square(3)) + area(s) - 2
+		}`
+		eval(t, src, big.NewInt(42))
+	})
+	t.Run("CommaOkAssertionToInterface", func(t *testing.T) {
+		src := shapes + `
+		func Main() int {
+			var s Shape = rect{w: 40, h: 1}
+			if _, ok := s.(interface{ Area() int }); ok {
+				return 1
+			}
+			return 0
+		}`
+		_, err := compiler.Compile(strings.NewReader(src))
+		require.Error(t, err)
+	})
+	t.Run("NilInterface", func(t *testing.T) {
+		src := shapes + `
+		func Main() int {
+			var s Shape
+			if s != nil {
+				return 1
+			}
+			return s.Area()
+		}`
+		v := vmAndCompile(t, src)
+		require.Error(t, v.Run())
+	})
+	t.Run("NoMethods", func(t *testing.T) {
+		src := `package foo
+		type Getter interface { Get() int }
+		type value struct { v int }
+		func Main() int {
+			var g Getter
+			_ = g
+			var x interface{} = value{v: 1}
+			g = x.(Getter)
+			return 1
+		}`
+		_, err := compiler.Compile(strings.NewReader(src))
+		require.Error(t, err)
+	})
+}
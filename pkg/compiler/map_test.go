@@ -1,6 +1,7 @@
 package compiler_test
 
 import (
+	"fmt"
 	"math/big"
 	"testing"
 )
@@ -60,3 +61,35 @@ var mapTestCases = []testCase{
 func TestMaps(t *testing.T) {
 	runTestCases(t, mapTestCases)
 }
+
+func TestNilComparison(t *testing.T) {
+	srcTmpl := `package foo
+	func Main() int {
+		%s
+		if %s {
+			return 1
+		}
+		return 2
+	}`
+	testCases := []struct {
+		name   string
+		decl   string
+		cond   string
+		result int64
+	}{
+		{"nil map", "var m map[string]int", "m == nil", 1},
+		{"non-nil map", "m := map[string]int{\"a\": 1}", "m != nil", 1},
+		{"nil byte slice", "var b []byte", "b == nil", 1},
+		{"non-nil byte slice", "b := []byte{1, 2}", "b == nil", 2},
+		{"nil interface", "var x interface{}", "x == nil", 1},
+		{"non-nil interface", "var x interface{} = \"str\"", "nil != x", 1},
+		{"nil func", "var f func() int", "f == nil", 1},
+		{"non-nil func", "f := func() int { return 1 }", "f != nil", 1},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			src := fmt.Sprintf(srcTmpl, tc.decl, tc.cond)
+			eval(t, src, big.NewInt(tc.result))
+		})
+	}
+}
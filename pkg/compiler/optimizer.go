@@ -0,0 +1,495 @@
+package compiler
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+
+	"github.com/ixje/neo-go-legacy/pkg/io"
+	"github.com/ixje/neo-go-legacy/pkg/vm"
+	"github.com/ixje/neo-go-legacy/pkg/vm/emit"
+	"github.com/ixje/neo-go-legacy/pkg/vm/opcode"
+)
+
+// maxFoldSize is the maximum size of byte array constants taking part in
+// constant folding.
+const maxFoldSize = 8
+
+// instruction is a single instruction of the program being optimized.
+type instruction struct {
+	op opcode.Opcode
+	// param is the instruction parameter without the length prefix.
+	param []byte
+	// raw is the instruction encoded, jump offsets are filled in
+	// when the program is encoded.
+	raw []byte
+	// offset is the offset of the instruction in the original program.
+	offset int
+	// target is the index of the instruction jumps and calls refer to,
+	// it's -1 for all other instructions.
+	target int
+	// removed is true if the instruction is removed from the program.
+	removed bool
+}
+
+// optimizer performs optimizations of the generated bytecode. NeoVM 2.x has
+// only 16-bit relative jumps, so there is nothing to relax, instead jumps
+// are threaded and removed if they point to the next instruction.
+type optimizer struct {
+	instrs []instruction
+	// newOffsets are the offsets of instructions in the optimized program,
+	// removed instructions have the offset of the next one.
+	newOffsets []int
+}
+
+// optimize applies all optimizations to the program and updates debug info
+// accordingly.
+func (c *codegen) optimize(b []byte) ([]byte, error) {
+	o, err := newOptimizer(b)
+	if err != nil {
+		return nil, err
+	} else if o == nil {
+		// The program can't be optimized.
+		return b, nil
+	}
+	for changed := true; changed; {
+		changed = o.removeUnreachable()
+		changed = o.threadJumps() || changed
+		changed = o.peephole() || changed
+	}
+	res, err := o.encode()
+	if err != nil {
+		return nil, err
+	}
+	c.remapDebugInfo(o)
+	return res, nil
+}
+
+// newOptimizer decodes the program, nil is returned if the program contains
+// instructions the optimizer can't handle.
+func newOptimizer(b []byte) (*optimizer, error) {
+	var (
+		o       = new(optimizer)
+		indices = make(map[int]int)
+		ctx     = vm.NewContext(b)
+	)
+	for ctx.NextIP() < len(b) {
+		op, param, err := ctx.Next()
+		if err != nil {
+			return nil, err
+		}
+		switch op {
+		case opcode.CALLI, opcode.CALLE, opcode.CALLED, opcode.CALLET, opcode.CALLEDT:
+			return nil, nil
+		}
+		ip, _ := ctx.CurrInstr()
+		indices[ip] = len(o.instrs)
+		o.instrs = append(o.instrs, instruction{
+			op:     op,
+			param:  append([]byte{}, param...),
+			raw:    append([]byte{}, b[ip:ctx.NextIP()]...),
+			offset: ip,
+			target: -1,
+		})
+	}
+	for i := range o.instrs {
+		ins := &o.instrs[i]
+		if !isJumpOrCall(ins.op) {
+			continue
+		}
+		offset := ins.offset + int(int16(binary.LittleEndian.Uint16(ins.param)))
+		if offset == len(b) {
+			ins.target = len(o.instrs)
+			continue
+		}
+		t, ok := indices[offset]
+		if !ok {
+			return nil, fmt.Errorf("invalid jump at the instruction %d", ins.offset)
+		}
+		ins.target = t
+	}
+	return o, nil
+}
+
+func isJumpOrCall(op opcode.Opcode) bool {
+	return isJump(op) || op == opcode.CALL
+}
+
+func isJump(op opcode.Opcode) bool {
+	return op == opcode.JMP || op == opcode.JMPIF || op == opcode.JMPIFNOT
+}
+
+// next returns the index of the first instruction starting from i
+// which is not removed.
+func (o *optimizer) next(i int) int {
+	for i < len(o.instrs) && o.instrs[i].removed {
+		i++
+	}
+	return i
+}
+
+// resolveTargets makes jumps refer to instructions which are not removed.
+// Removed instructions never change the state of the VM, so jumping to
+// them is the same as jumping to the next instruction.
+func (o *optimizer) resolveTargets() {
+	for i := range o.instrs {
+		if o.instrs[i].target >= 0 {
+			o.instrs[i].target = o.next(o.instrs[i].target)
+		}
+	}
+}
+
+// removeUnreachable removes all code which can't be reached from the entry
+// point, including unused functions.
+func (o *optimizer) removeUnreachable() bool {
+	o.resolveTargets()
+
+	reached := make([]bool, len(o.instrs))
+	queue := []int{o.next(0)}
+	for len(queue) != 0 {
+		i := queue[len(queue)-1]
+		queue = queue[:len(queue)-1]
+		if i >= len(o.instrs) || reached[i] {
+			continue
+		}
+		reached[i] = true
+		ins := &o.instrs[i]
+		if ins.target >= 0 {
+			queue = append(queue, ins.target)
+		}
+		switch ins.op {
+		case opcode.JMP, opcode.RET, opcode.THROW:
+		default:
+			queue = append(queue, o.next(i+1))
+		}
+	}
+
+	var changed bool
+	for i := range o.instrs {
+		if !o.instrs[i].removed && !reached[i] {
+			o.instrs[i].removed = true
+			changed = true
+		}
+	}
+	return changed
+}
+
+// threadJumps makes jumps to unconditional jumps refer to their targets and
+// removes jumps to the next instruction.
+func (o *optimizer) threadJumps() bool {
+	o.resolveTargets()
+
+	var changed bool
+	for i := range o.instrs {
+		ins := &o.instrs[i]
+		if ins.removed || !isJump(ins.op) {
+			continue
+		}
+		t := ins.target
+		for n := 0; n < len(o.instrs) && t < len(o.instrs) && o.instrs[t].op == opcode.JMP && o.instrs[t].target != t; n++ {
+			t = o.instrs[t].target
+		}
+		if t != ins.target {
+			ins.target = t
+			changed = true
+		}
+		switch {
+		case ins.target == o.next(i+1):
+			if ins.op == opcode.JMP {
+				ins.removed = true
+			} else {
+				// Condition is still to be removed from the stack.
+				o.replace(i, opcode.DROP)
+			}
+			changed = true
+		case ins.op == opcode.JMP && t < len(o.instrs) &&
+			(o.instrs[t].op == opcode.RET || o.instrs[t].op == opcode.THROW):
+			o.replace(i, o.instrs[t].op)
+			changed = true
+		}
+	}
+	return changed
+}
+
+// peephole replaces short instruction sequences with cheaper ones.
+// Instructions other than the first one in the sequence must not be
+// jump targets. The first instruction is either reused or all of
+// the sequence is a no-op and removed.
+func (o *optimizer) peephole() bool {
+	o.resolveTargets()
+
+	isTarget := make([]bool, len(o.instrs)+1)
+	for i := range o.instrs {
+		if !o.instrs[i].removed && o.instrs[i].target >= 0 {
+			isTarget[o.instrs[i].target] = true
+		}
+	}
+
+	var (
+		changed bool
+		seq     []int
+	)
+	for i := o.next(0); i < len(o.instrs); i = o.next(i + 1) {
+		seq = seq[:0]
+		for j := i; j < len(o.instrs) && len(seq) < 3; j = o.next(j + 1) {
+			if len(seq) != 0 && isTarget[j] {
+				break
+			}
+			seq = append(seq, j)
+		}
+		if o.simplify(seq) {
+			changed = true
+		}
+	}
+	return changed
+}
+
+// simplify tries to simplify the sequence of instructions starting at seq[0].
+func (o *optimizer) simplify(seq []int) bool {
+	if o.instrs[seq[0]].op == opcode.NOP {
+		o.instrs[seq[0]].removed = true
+		return true
+	} else if len(seq) < 2 {
+		return false
+	}
+	a, b := &o.instrs[seq[0]], &o.instrs[seq[1]]
+	if len(seq) == 3 {
+		c := &o.instrs[seq[2]]
+		switch {
+		case a.op == opcode.FROMALTSTACK && b.op == opcode.DUP && c.op == opcode.TOALTSTACK:
+			o.replace(seq[0], opcode.DUPFROMALTSTACK)
+			b.removed, c.removed = true, true
+			return true
+		case isConst(a) && isConst(b):
+			if res, ok := foldBinary(c.op, constValue(a), constValue(b)); ok {
+				o.replaceWithConst(seq[0], res)
+				b.removed, c.removed = true, true
+				return true
+			}
+		}
+	}
+
+	switch {
+	case b.op == opcode.DROP && (isConst(a) || a.op == opcode.DUP ||
+		a.op == opcode.DUPFROMALTSTACK || a.op == opcode.OVER):
+		a.removed, b.removed = true, true
+	case a.op == opcode.FROMALTSTACK && b.op == opcode.TOALTSTACK,
+		a.op == opcode.TOALTSTACK && b.op == opcode.FROMALTSTACK,
+		a.op == opcode.SWAP && b.op == opcode.SWAP:
+		a.removed, b.removed = true, true
+	case a.op == opcode.PUSH1 && b.op == opcode.ADD:
+		o.replace(seq[0], opcode.INC)
+		b.removed = true
+	case a.op == opcode.PUSH1 && b.op == opcode.SUB:
+		o.replace(seq[0], opcode.DEC)
+		b.removed = true
+	case a.op == opcode.PUSH0 && b.op == opcode.NUMNOTEQUAL:
+		o.replace(seq[0], opcode.NZ)
+		b.removed = true
+	case a.op == opcode.NOT && (b.op == opcode.JMPIF || b.op == opcode.JMPIFNOT):
+		op := opcode.JMPIF
+		if b.op == opcode.JMPIF {
+			op = opcode.JMPIFNOT
+		}
+		o.replace(seq[0], op)
+		a.target = b.target
+		b.removed = true
+	case isConst(a) && (b.op == opcode.JMPIF || b.op == opcode.JMPIFNOT):
+		if (constValue(a).Sign() != 0) == (b.op == opcode.JMPIF) {
+			o.replace(seq[0], opcode.JMP)
+			a.target = b.target
+		} else {
+			a.removed = true
+		}
+		b.removed = true
+	case isConst(a):
+		res, ok := foldUnary(b.op, constValue(a))
+		if !ok {
+			return false
+		}
+		o.replaceWithConst(seq[0], res)
+		b.removed = true
+	default:
+		return false
+	}
+	return true
+}
+
+// replace replaces the i-th instruction with the one without parameters
+// (or a jump with target to be set by the caller).
+func (o *optimizer) replace(i int, op opcode.Opcode) {
+	ins := &o.instrs[i]
+	ins.op = op
+	ins.param = nil
+	ins.raw = []byte{byte(op)}
+	if isJumpOrCall(op) {
+		ins.param = make([]byte, 2)
+		ins.raw = append(ins.raw, ins.param...)
+	} else {
+		ins.target = -1
+	}
+}
+
+// replaceWithConst replaces the i-th instruction with the constant push.
+func (o *optimizer) replaceWithConst(i int, val int64) {
+	o.replace(i, opcode.Opcode(int64(opcode.PUSH1)-1+val))
+}
+
+// isConst checks if the instruction pushes an integer constant.
+func isConst(ins *instruction) bool {
+	switch {
+	case ins.op == opcode.PUSH0, ins.op == opcode.PUSHM1,
+		ins.op >= opcode.PUSH1 && ins.op <= opcode.PUSH16:
+		return true
+	case ins.op >= opcode.PUSHBYTES1 && ins.op <= opcode.PUSHBYTES75:
+		return len(ins.param) <= maxFoldSize
+	default:
+		return false
+	}
+}
+
+// constValue returns the value pushed by the constant instruction.
+func constValue(ins *instruction) *big.Int {
+	switch {
+	case ins.op == opcode.PUSH0:
+		return big.NewInt(0)
+	case ins.op == opcode.PUSHM1, ins.op >= opcode.PUSH1 && ins.op <= opcode.PUSH16:
+		return big.NewInt(int64(ins.op) - int64(opcode.PUSH1) + 1)
+	default:
+		return emit.BytesToInt(ins.param)
+	}
+}
+
+// foldUnary computes the result of unary arithmetic operation.
+func foldUnary(op opcode.Opcode, x *big.Int) (int64, bool) {
+	res := new(big.Int)
+	switch op {
+	case opcode.INC:
+		res.Add(x, big.NewInt(1))
+	case opcode.DEC:
+		res.Sub(x, big.NewInt(1))
+	case opcode.NEGATE:
+		res.Neg(x)
+	case opcode.ABS:
+		res.Abs(x)
+	default:
+		return 0, false
+	}
+	return checkFolded(res)
+}
+
+// foldBinary computes the result of binary arithmetic operation.
+func foldBinary(op opcode.Opcode, a, b *big.Int) (int64, bool) {
+	res := new(big.Int)
+	switch op {
+	case opcode.ADD:
+		res.Add(a, b)
+	case opcode.SUB:
+		res.Sub(a, b)
+	case opcode.MUL:
+		res.Mul(a, b)
+	case opcode.DIV, opcode.MOD:
+		if b.Sign() == 0 {
+			return 0, false
+		}
+		if op == opcode.DIV {
+			res.Quo(a, b)
+		} else {
+			res.Rem(a, b)
+		}
+	case opcode.MIN, opcode.MAX:
+		res.Set(a)
+		if (a.Cmp(b) > 0) == (op == opcode.MIN) {
+			res.Set(b)
+		}
+	default:
+		return 0, false
+	}
+	return checkFolded(res)
+}
+
+// checkFolded checks that the result of folding can be pushed as an integer
+// (not a byte array) by a single instruction, so that its type is preserved.
+func checkFolded(res *big.Int) (int64, bool) {
+	if !res.IsInt64() {
+		return 0, false
+	}
+	v := res.Int64()
+	return v, v == -1 || v >= 1 && v <= 16
+}
+
+// encode encodes the optimized program.
+func (o *optimizer) encode() ([]byte, error) {
+	o.resolveTargets()
+
+	o.newOffsets = make([]int, len(o.instrs)+1)
+	offset := 0
+	for i := range o.instrs {
+		o.newOffsets[i] = offset
+		if !o.instrs[i].removed {
+			offset += len(o.instrs[i].raw)
+		}
+	}
+	o.newOffsets[len(o.instrs)] = offset
+
+	w := io.NewBufBinWriter()
+	for i := range o.instrs {
+		ins := &o.instrs[i]
+		if ins.removed {
+			continue
+		}
+		if ins.target >= 0 {
+			offset := o.newOffsets[ins.target] - o.newOffsets[i]
+			if offset < math.MinInt16 || offset > math.MaxInt16 {
+				return nil, fmt.Errorf("label offset is too big at the instruction %d: %d (max %d)",
+					o.newOffsets[i], offset, math.MaxInt16)
+			}
+			binary.LittleEndian.PutUint16(ins.raw[1:], uint16(offset))
+		}
+		w.WriteBytes(ins.raw)
+	}
+	if w.Err != nil {
+		return nil, w.Err
+	}
+	return w.Bytes(), nil
+}
+
+// mapOffset returns the offset in the optimized program corresponding to
+// the offset in the original one.
+func (o *optimizer) mapOffset(offset int) int {
+	i := sort.Search(len(o.instrs), func(i int) bool {
+		return o.instrs[i].offset >= offset
+	})
+	return o.newOffsets[i]
+}
+
+// remapDebugInfo updates method ranges and sequence points after optimization.
+// Methods removed completely are excluded from debug info.
+func (c *codegen) remapDebugInfo(o *optimizer) {
+	for name, f := range c.funcs {
+		if f.rng.Start == f.rng.End {
+			continue
+		}
+		start := o.mapOffset(int(f.rng.Start))
+		end := o.mapOffset(int(f.rng.End) + 1)
+
+		if start >= end {
+			f.rng = DebugRange{}
+			delete(c.sequencePoints, name)
+			continue
+		}
+
+		var points []DebugSeqPoint
+		for _, p := range c.sequencePoints[name] {
+			p.Opcode = o.mapOffset(p.Opcode)
+			if p.Opcode < end {
+				points = append(points, p)
+			}
+		}
+		c.sequencePoints[name] = points
+		f.rng.Start = uint16(start)
+		f.rng.End = uint16(end - 1)
+	}
+}
@@ -0,0 +1,185 @@
+package compiler
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ixje/neo-go-legacy/pkg/vm"
+	"github.com/ixje/neo-go-legacy/pkg/vm/opcode"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOptimizer(t *testing.T) {
+	src := `package foo
+	func Main() int {
+		sum := 0
+		for i := 0; i < 6; i++ {
+			if !isOdd(i) {
+				sum += i
+			}
+		}
+		if true {
+			return sum + 36
+		}
+		return unused()
+	}
+	func isOdd(i int) bool { return i%2 == 1 }
+	func unused() int { return 1 }
+	`
+
+	compile := func(t *testing.T, o *Options) ([]byte, *DebugInfo) {
+		info, err := getBuildInfo("foo.go", src)
+		require.NoError(t, err)
+		b, di, err := codeGen(info, o)
+		require.NoError(t, err)
+
+		v := vm.New()
+		v.LoadScript(b)
+		require.NoError(t, v.Run())
+		require.Equal(t, big.NewInt(42), v.PopResult())
+		return b, di
+	}
+
+	raw, rawInfo := compile(t, &Options{NoOpt: true})
+	buf, di := compile(t, &Options{})
+	require.True(t, len(buf) < len(raw))
+
+	t.Run("unused functions", func(t *testing.T) {
+		names := func(d *DebugInfo) []string {
+			var res []string
+			for i := range d.Methods {
+				res = append(res, d.Methods[i].Name.Name)
+			}
+			return res
+		}
+		require.ElementsMatch(t, []string{"Main", "isOdd", "unused"}, names(rawInfo))
+		require.ElementsMatch(t, []string{"Main", "isOdd"}, names(di))
+	})
+
+	t.Run("debug info", func(t *testing.T) {
+		starts := make(map[int]bool)
+		ctx := vm.NewContext(buf)
+		for ctx.NextIP() < len(buf) {
+			_, _, err := ctx.Next()
+			require.NoError(t, err)
+			ip, _ := ctx.CurrInstr()
+			starts[ip] = true
+		}
+		for _, m := range di.Methods {
+			require.True(t, starts[int(m.Range.Start)])
+			require.True(t, int(m.Range.End) < len(buf))
+			for _, p := range m.SeqPoints {
+				require.True(t, starts[p.Opcode])
+				require.True(t, p.Opcode >= int(m.Range.Start) && p.Opcode <= int(m.Range.End))
+			}
+		}
+	})
+}
+
+func TestOptimizerPasses(t *testing.T) {
+	testCases := []struct {
+		name     string
+		prog     []byte
+		expected []byte
+	}{
+		{
+			name:     "constant folding",
+			prog:     []byte{byte(opcode.PUSH2), byte(opcode.PUSH3), byte(opcode.MUL), byte(opcode.INC), byte(opcode.RET)},
+			expected: []byte{byte(opcode.PUSH7), byte(opcode.RET)},
+		},
+		{
+			name:     "non-integer result",
+			prog:     []byte{byte(opcode.PUSH2), byte(opcode.PUSH2), byte(opcode.SUB), byte(opcode.RET)},
+			expected: []byte{byte(opcode.PUSH2), byte(opcode.PUSH2), byte(opcode.SUB), byte(opcode.RET)},
+		},
+		{
+			name:     "division by zero",
+			prog:     []byte{byte(opcode.PUSH2), byte(opcode.PUSH0), byte(opcode.DIV), byte(opcode.RET)},
+			expected: []byte{byte(opcode.PUSH2), byte(opcode.PUSH0), byte(opcode.DIV), byte(opcode.RET)},
+		},
+		{
+			name: "constant condition",
+			prog: []byte{
+				byte(opcode.PUSHT), byte(opcode.JMPIFNOT), 4, 0,
+				byte(opcode.PUSH1),
+				byte(opcode.PUSH2), byte(opcode.RET),
+			},
+			expected: []byte{byte(opcode.PUSH1), byte(opcode.PUSH2), byte(opcode.RET)},
+		},
+		{
+			name: "inverted condition",
+			prog: []byte{
+				byte(opcode.DUP), byte(opcode.NOT), byte(opcode.JMPIFNOT), 5, 0,
+				byte(opcode.PUSH1), byte(opcode.RET),
+				byte(opcode.PUSH2), byte(opcode.RET),
+			},
+			expected: []byte{
+				byte(opcode.DUP), byte(opcode.JMPIF), 5, 0,
+				byte(opcode.PUSH1), byte(opcode.RET),
+				byte(opcode.PUSH2), byte(opcode.RET),
+			},
+		},
+		{
+			name: "jump threading",
+			prog: []byte{
+				byte(opcode.DUP), byte(opcode.JMPIF), 5, 0,
+				byte(opcode.PUSH1), byte(opcode.RET),
+				byte(opcode.JMP), 4, 0,
+				byte(opcode.NOP),
+				byte(opcode.PUSH2), byte(opcode.RET),
+			},
+			expected: []byte{
+				byte(opcode.DUP), byte(opcode.JMPIF), 5, 0,
+				byte(opcode.PUSH1), byte(opcode.RET),
+				byte(opcode.PUSH2), byte(opcode.RET),
+			},
+		},
+		{
+			name: "jump to return",
+			prog: []byte{
+				byte(opcode.DUP), byte(opcode.JMPIF), 7, 0,
+				byte(opcode.PUSH1), byte(opcode.JMP), 4, 0,
+				byte(opcode.PUSH2), byte(opcode.RET),
+			},
+			expected: []byte{
+				byte(opcode.DUP), byte(opcode.JMPIF), 5, 0,
+				byte(opcode.PUSH1), byte(opcode.RET),
+				byte(opcode.PUSH2), byte(opcode.RET),
+			},
+		},
+		{
+			name: "unused function",
+			prog: []byte{
+				byte(opcode.CALL), 5, 0, byte(opcode.RET),
+				byte(opcode.RET),
+				byte(opcode.PUSH1), byte(opcode.RET),
+			},
+			expected: []byte{
+				byte(opcode.CALL), 4, 0, byte(opcode.RET),
+				byte(opcode.PUSH1), byte(opcode.RET),
+			},
+		},
+		{
+			name: "stack operations",
+			prog: []byte{
+				byte(opcode.FROMALTSTACK), byte(opcode.DUP), byte(opcode.TOALTSTACK),
+				byte(opcode.DUP), byte(opcode.DROP),
+				byte(opcode.SWAP), byte(opcode.SWAP),
+				byte(opcode.PUSH1), byte(opcode.ADD),
+				byte(opcode.PUSH0), byte(opcode.NUMNOTEQUAL),
+				byte(opcode.RET),
+			},
+			expected: []byte{
+				byte(opcode.DUPFROMALTSTACK), byte(opcode.INC), byte(opcode.NZ), byte(opcode.RET),
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := &codegen{funcs: map[string]*funcScope{}}
+			actual, err := c.optimize(tc.prog)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, actual)
+		})
+	}
+}
@@ -3,12 +3,9 @@ package compiler_test
 import (
 	"fmt"
 	"math/big"
-	"strings"
 	"testing"
 
-	"github.com/ixje/neo-go-legacy/pkg/compiler"
 	"github.com/ixje/neo-go-legacy/pkg/vm"
-	"github.com/stretchr/testify/require"
 )
 
 var sliceTestCases = []testCase{
@@ -191,8 +188,11 @@ func TestSliceEmpty(t *testing.T) {
 	}`
 	t.Run("WithNil", func(t *testing.T) {
 		src := fmt.Sprintf(srcTmpl, "", "a == nil")
-		_, err := compiler.Compile(strings.NewReader(src))
-		require.Error(t, err)
+		eval(t, src, big.NewInt(1))
+	})
+	t.Run("NonEmptyWithNil", func(t *testing.T) {
+		src := fmt.Sprintf(srcTmpl, "a = []int{1}", "nil == a")
+		eval(t, src, big.NewInt(2))
 	})
 	t.Run("WithLen", func(t *testing.T) {
 		src := fmt.Sprintf(srcTmpl, "", "len(a) == 0")
@@ -0,0 +1,311 @@
+package compiler
+
+import (
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// standard is a set of operations and notifications a contract must
+// support to conform to some NEO standard.
+type standard struct {
+	// Name is a human-readable standard name.
+	Name    string
+	Methods []Method
+	Events  []Event
+}
+
+// standards is a mapping from standard identifiers to their descriptions.
+var standards = map[string]*standard{
+	"nep5": {
+		Name: "NEP-5",
+		Methods: []Method{
+			{Name: "name", Parameters: []DebugParam{}, ReturnType: "String"},
+			{Name: "symbol", Parameters: []DebugParam{}, ReturnType: "String"},
+			{Name: "decimals", Parameters: []DebugParam{}, ReturnType: "Integer"},
+			{Name: "totalSupply", Parameters: []DebugParam{}, ReturnType: "Integer"},
+			{
+				Name:       "balanceOf",
+				Parameters: []DebugParam{{Name: "account", Type: "ByteArray"}},
+				ReturnType: "Integer",
+			},
+			{
+				Name: "transfer",
+				Parameters: []DebugParam{
+					{Name: "from", Type: "ByteArray"},
+					{Name: "to", Type: "ByteArray"},
+					{Name: "amount", Type: "Integer"},
+				},
+				ReturnType: "Boolean",
+			},
+		},
+		Events: []Event{
+			{
+				Name: "transfer",
+				Parameters: []DebugParam{
+					{Name: "from", Type: "ByteArray"},
+					{Name: "to", Type: "ByteArray"},
+					{Name: "amount", Type: "Integer"},
+				},
+			},
+		},
+	},
+}
+
+// operation describes how the entry point handles some operation.
+type operation struct {
+	// params are types of the arguments used by the operation.
+	params []string
+	// returns are types of the values returned for the operation.
+	returns []string
+}
+
+// checkStandards checks that the contract in the package conforms to all
+// the given standards.
+func (c *codegen) checkStandards(pkg *packages.Package, names []string) error {
+	if len(names) == 0 {
+		return nil
+	}
+	main := resolveEntryPoint(mainIdent, pkg)
+	if main == nil {
+		return fmt.Errorf("no entry point %s found", mainIdent)
+	}
+	c.typeInfo = pkg.TypesInfo
+	ops := c.entryOperations(main)
+	for _, name := range names {
+		std, ok := standards[name]
+		if !ok {
+			return fmt.Errorf("unknown standard %q (known are %s)", name, strings.Join(standardNames(), ", "))
+		}
+		if errs := c.checkStandard(std, ops); len(errs) != 0 {
+			return fmt.Errorf("contract doesn't conform to %s: %s", std.Name, strings.Join(errs, "; "))
+		}
+	}
+	return nil
+}
+
+// checkStandard returns the list of the standard violations.
+func (c *codegen) checkStandard(std *standard, ops map[string]*operation) []string {
+	var errs []string
+	for _, m := range std.Methods {
+		op, ok := ops[m.Name]
+		if !ok {
+			errs = append(errs, fmt.Sprintf("%q operation is not handled", m.Name))
+			continue
+		}
+		if len(op.params) > len(m.Parameters) {
+			errs = append(errs, fmt.Sprintf("%q operation uses %d arguments, %d expected",
+				m.Name, len(op.params), len(m.Parameters)))
+		} else {
+			for i, typ := range op.params {
+				if !sameType(typ, m.Parameters[i].Type) {
+					errs = append(errs, fmt.Sprintf("argument %d of %q operation is %s, %s expected",
+						i+1, m.Name, typ, m.Parameters[i].Type))
+				}
+			}
+		}
+		for _, typ := range op.returns {
+			if !sameType(typ, m.ReturnType) {
+				errs = append(errs, fmt.Sprintf("%q operation returns %s, %s expected", m.Name, typ, m.ReturnType))
+				break
+			}
+		}
+	}
+	for _, e := range std.Events {
+		var found *EventDebugInfo
+		for i := range c.events {
+			if c.events[i].Name == e.Name {
+				found = &c.events[i]
+				break
+			}
+		}
+		if found == nil {
+			errs = append(errs, fmt.Sprintf("%q event is not sent", e.Name))
+			continue
+		}
+		ok := len(found.Parameters) == len(e.Parameters)
+		for i := 0; ok && i < len(e.Parameters); i++ {
+			ok = sameType(found.Parameters[i].Type, e.Parameters[i].Type)
+		}
+		if !ok {
+			errs = append(errs, fmt.Sprintf("%q event has %s parameters, %s expected",
+				e.Name, formatParamTypes(found.Parameters), formatParamTypes(e.Parameters)))
+		}
+	}
+	return errs
+}
+
+// sameType checks if the value of the actual type can be used where the
+// expected type is required. Values of unknown type are always allowed.
+func sameType(actual, expected string) bool {
+	return actual == expected || actual == "Any"
+}
+
+func formatParamTypes(ps []DebugParam) string {
+	ts := make([]string, len(ps))
+	for i := range ps {
+		ts[i] = ps[i].Type
+	}
+	return "(" + strings.Join(ts, ", ") + ")"
+}
+
+// entryOperations returns operations dispatched by the entry point. The
+// entry point is expected to compare its first parameter with constant
+// operation names in if and switch statements and to take operation
+// arguments from its second parameter.
+func (c *codegen) entryOperations(main *ast.FuncDecl) map[string]*operation {
+	ops := make(map[string]*operation)
+	var params []types.Object
+	for _, field := range main.Type.Params.List {
+		for _, name := range field.Names {
+			params = append(params, c.typeInfo.Defs[name])
+		}
+	}
+	if len(params) == 0 {
+		return ops
+	}
+	isOp := func(expr ast.Expr) bool {
+		id, ok := expr.(*ast.Ident)
+		return ok && c.typeInfo.Uses[id] == params[0]
+	}
+	var args types.Object
+	if len(params) > 1 {
+		args = params[1]
+	}
+	add := func(name string, nodes ...ast.Node) {
+		op, ok := ops[name]
+		if !ok {
+			op = &operation{}
+			ops[name] = op
+		}
+		for _, n := range nodes {
+			c.collectOperation(op, args, n)
+		}
+	}
+
+	ast.Inspect(main.Body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.IfStmt:
+			for _, name := range c.comparedNames(n.Cond, isOp) {
+				add(name, n.Cond, n.Body)
+			}
+		case *ast.SwitchStmt:
+			if n.Tag == nil || !isOp(n.Tag) {
+				return true
+			}
+			for _, stmt := range n.Body.List {
+				cc := stmt.(*ast.CaseClause)
+				for _, expr := range cc.List {
+					if name, ok := c.constString(expr); ok {
+						add(name, cc)
+					}
+				}
+			}
+		}
+		return true
+	})
+	for _, op := range ops {
+		for i := range op.params {
+			if op.params[i] == "" {
+				op.params[i] = "Any"
+			}
+		}
+	}
+	return ops
+}
+
+// comparedNames returns constants the operation is compared with in the
+// condition, comparisons can be combined with && and ||.
+func (c *codegen) comparedNames(cond ast.Expr, isOp func(ast.Expr) bool) []string {
+	switch e := cond.(type) {
+	case *ast.ParenExpr:
+		return c.comparedNames(e.X, isOp)
+	case *ast.BinaryExpr:
+		switch e.Op {
+		case token.LAND, token.LOR:
+			return append(c.comparedNames(e.X, isOp), c.comparedNames(e.Y, isOp)...)
+		case token.EQL:
+			if isOp(e.X) {
+				if name, ok := c.constString(e.Y); ok {
+					return []string{name}
+				}
+			} else if isOp(e.Y) {
+				if name, ok := c.constString(e.X); ok {
+					return []string{name}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// collectOperation records types of arguments taken from args with
+// constant indices and types of returned values.
+func (c *codegen) collectOperation(op *operation, args types.Object, node ast.Node) {
+	argIndex := func(expr ast.Expr) int {
+		ie, ok := expr.(*ast.IndexExpr)
+		if !ok || args == nil {
+			return -1
+		}
+		if id, ok := ie.X.(*ast.Ident); !ok || c.typeInfo.Uses[id] != args {
+			return -1
+		}
+		tv := c.typeInfo.Types[ie.Index]
+		if tv.Value == nil {
+			return -1
+		}
+		i, ok := constant.Int64Val(tv.Value)
+		if !ok || i < 0 {
+			return -1
+		}
+		for int(i) >= len(op.params) {
+			op.params = append(op.params, "")
+		}
+		return int(i)
+	}
+
+	ast.Inspect(node, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.TypeAssertExpr:
+			if i := argIndex(n.X); i >= 0 && n.Type != nil {
+				op.params[i] = c.scTypeFromExpr(n.Type)
+			}
+		case *ast.IndexExpr:
+			argIndex(n)
+		case *ast.ReturnStmt:
+			if len(n.Results) == 1 {
+				op.returns = append(op.returns, c.scTypeFromGo(c.typeInfo.TypeOf(n.Results[0])))
+			}
+		}
+		return true
+	})
+}
+
+// constString returns the value of the constant string expression.
+func (c *codegen) constString(expr ast.Expr) (string, bool) {
+	tv := c.typeInfo.Types[expr]
+	if tv.Value == nil || tv.Value.Kind() != constant.String {
+		return "", false
+	}
+	return constant.StringVal(tv.Value), true
+}
+
+// standardNames returns sorted identifiers of all known standards.
+func standardNames() []string {
+	names := make([]string, 0, len(standards))
+	for name := range standards {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
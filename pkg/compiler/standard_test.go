@@ -0,0 +1,140 @@
+package compiler
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ixje/neo-go-legacy/pkg/smartcontract"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStandardNEP5(t *testing.T) {
+	t.Run("example token", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "neogo-standard")
+		require.NoError(t, err)
+		defer os.RemoveAll(dir)
+
+		o := &Options{
+			Outfile:   filepath.Join(dir, "token.avm"),
+			DebugInfo: filepath.Join(dir, "token.debug.json"),
+			ABIInfo:   filepath.Join(dir, "token.abi.json"),
+			ContractDetails: &smartcontract.ContractDetails{
+				ReturnType: smartcontract.ByteArrayType,
+			},
+			Standards: []string{"nep5"},
+		}
+		_, err = CompileAndSave("../../examples/token", o)
+		require.NoError(t, err)
+
+		data, err := ioutil.ReadFile(o.ABIInfo)
+		require.NoError(t, err)
+		var abi ABI
+		require.NoError(t, json.Unmarshal(data, &abi))
+		require.Equal(t, []string{"nep5"}, abi.SupportedStandards)
+		require.Equal(t, 1, len(abi.Functions))
+		require.Equal(t, "Main", abi.Functions[0].Name)
+		require.Equal(t, []Event{{
+			Name: "transfer",
+			Parameters: []DebugParam{
+				{Name: "from", Type: "ByteArray"},
+				{Name: "to", Type: "ByteArray"},
+				{Name: "amount", Type: "Integer"},
+			},
+		}}, abi.Events)
+	})
+
+	check := func(t *testing.T, src string) error {
+		info, err := getBuildInfo("foo.go", src)
+		require.NoError(t, err)
+		_, _, err = codeGen(info, &Options{Standards: []string{"nep5"}})
+		return err
+	}
+	t.Run("conforming", func(t *testing.T) {
+		src := `package foo
+		import "github.com/ixje/neo-go-legacy/pkg/interop/runtime"
+		func Main(op string, args []interface{}) interface{} {
+			switch op {
+			case "name", "symbol":
+				return "TKN"
+			case "decimals":
+				return 8
+			case "totalSupply":
+				return getInt(nil)
+			}
+			if op == "balanceOf" {
+				return getInt(args[0].([]byte))
+			}
+			if op == "transfer" && len(args) == 3 {
+				from := args[0].([]byte)
+				to := args[1].([]byte)
+				amount := args[2].(int)
+				runtime.Notify("transfer", from, to, amount)
+				return true
+			}
+			return false
+		}
+		func getInt(key []byte) interface{} { return 1 }`
+		require.NoError(t, check(t, src))
+	})
+	t.Run("unknown standard", func(t *testing.T) {
+		info, err := getBuildInfo("foo.go", `package foo
+		func Main() int { return 1 }`)
+		require.NoError(t, err)
+		_, _, err = codeGen(info, &Options{Standards: []string{"nep42"}})
+		require.Error(t, err)
+	})
+
+	testCases := []struct {
+		name   string
+		src    string
+		errMsg string
+	}{
+		{
+			name: "missing operations and event",
+			src: `package foo
+			func Main(op string, args []interface{}) interface{} {
+				if op == "name" || op == "symbol" {
+					return "TKN"
+				}
+				return nil
+			}`,
+			errMsg: `"decimals" operation is not handled; "totalSupply" operation is not handled; ` +
+				`"balanceOf" operation is not handled; "transfer" operation is not handled; ` +
+				`"transfer" event is not sent`,
+		},
+		{
+			name: "bad types",
+			src: `package foo
+			import "github.com/ixje/neo-go-legacy/pkg/interop/runtime"
+			func Main(op string, args []interface{}) interface{} {
+				switch op {
+				case "name", "symbol":
+					return []byte("TKN")
+				case "decimals", "totalSupply":
+					return 8
+				case "balanceOf":
+					return args[0].(int) + args[1].(int)
+				case "transfer":
+					runtime.Notify("transfer", args[0].([]byte), args[1].([]byte))
+					return args[2].(string) == ""
+				}
+				return nil
+			}`,
+			errMsg: `"name" operation returns ByteArray, String expected; ` +
+				`"symbol" operation returns ByteArray, String expected; ` +
+				`"balanceOf" operation uses 2 arguments, 1 expected; ` +
+				`argument 3 of "transfer" operation is String, Integer expected; ` +
+				`"transfer" event has (ByteArray, ByteArray) parameters, (ByteArray, ByteArray, Integer) expected`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := check(t, tc.src)
+			require.Error(t, err)
+			require.Equal(t, "contract doesn't conform to NEP-5: "+tc.errMsg, err.Error())
+		})
+	}
+}
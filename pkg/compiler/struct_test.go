@@ -362,6 +362,74 @@ var structTestCases = []testCase{
 		}`,
 		big.NewInt(2),
 	},
+	{
+		"nested selectors",
+		`package foo
+		type inner struct { x int; arr []int }
+		type outer struct { in inner }
+		func Main() int {
+			a := outer{in: inner{x: 10, arr: []int{1, 2}}}
+			a.in.x = 20
+			a.in.x += 19
+			a.in.arr[1] = a.in.x
+			return a.in.arr[0] + a.in.arr[1] + getInner(a).arr[0]
+		}
+		func getInner(o outer) inner { return o.in }`,
+		big.NewInt(41),
+	},
+	{
+		"nested selectors through index",
+		`package foo
+		type token struct { name string; amount int }
+		type holder struct { tokens []token }
+		func Main() int {
+			h := holder{tokens: []token{token{name: "a", amount: 1}, token{name: "b", amount: 2}}}
+			i := 1
+			h.tokens[i].amount = 40
+			return h.tokens[0].amount + h.tokens[i].amount + 1
+		}`,
+		big.NewInt(42),
+	},
+	{
+		"nested struct zero value",
+		`package foo
+		type C struct { c int }
+		type B struct { b C; arr [2]int }
+		type A struct { a B }
+		func Main() int {
+			var a A
+			a.a.b.c = 5
+			a.a.arr[1] = 3
+			return a.a.b.c + a.a.arr[0] + a.a.arr[1]
+		}`,
+		big.NewInt(8),
+	},
+	{
+		"nested struct zero value in literal",
+		`package foo
+		type B struct { b int; s string }
+		type A struct { x int; a B }
+		func Main() string {
+			a := A{x: 1}
+			a.a.b = 2
+			return a.a.s
+		}`,
+		[]byte{},
+	},
+	{
+		"multiple zero value variables",
+		`package foo
+		type A struct { a int }
+		func Main() int {
+			var x, y A
+			var i, j int
+			x.a = 2
+			y.a = 3
+			i = x.a + j
+			return i + y.a
+		}`,
+		big.NewInt(5),
+	},
 }
 
 func TestStructs(t *testing.T) {
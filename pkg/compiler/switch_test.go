@@ -31,6 +31,17 @@ var switchTestCases = []testCase{
 		}`,
 		big.NewInt(1),
 	},
+	{
+		"switch with init",
+		`package main
+		func Main() int {
+			switch a := 5; a {
+			case 5: return 2
+			}
+			return 1
+		}`,
+		big.NewInt(2),
+	},
 	{
 		"multiple cases success",
 		`package main
@@ -1,5 +1,62 @@
 package compiler
 
+import (
+	"fmt"
+	"path"
+	"sync"
+)
+
+var (
+	// customSyscalls maps import paths of stub packages and function names
+	// to custom interop names.
+	customSyscalls     = make(map[string]map[string]string)
+	customSyscallsLock sync.RWMutex
+)
+
+// RegisterSyscall makes the compiler translate calls to the function `name`
+// from the package with the import path `pkgPath` into SYSCALL with the given
+// interop name. It allows to write stub packages similar to pkg/interop ones
+// for custom interops available on private chains. Standard syscalls can't
+// be redefined.
+func RegisterSyscall(pkgPath, name, interop string) error {
+	if _, ok := syscalls[path.Base(pkgPath)][name]; ok {
+		return fmt.Errorf("syscall %s.%s is a standard one", pkgPath, name)
+	}
+	customSyscallsLock.Lock()
+	defer customSyscallsLock.Unlock()
+	if _, ok := customSyscalls[pkgPath][name]; ok {
+		return fmt.Errorf("syscall %s.%s is already registered", pkgPath, name)
+	}
+	if customSyscalls[pkgPath] == nil {
+		customSyscalls[pkgPath] = make(map[string]string)
+	}
+	customSyscalls[pkgPath][name] = interop
+	return nil
+}
+
+// UnregisterSyscall removes custom syscall registered with RegisterSyscall.
+func UnregisterSyscall(pkgPath, name string) {
+	customSyscallsLock.Lock()
+	defer customSyscallsLock.Unlock()
+	delete(customSyscalls[pkgPath], name)
+	if len(customSyscalls[pkgPath]) == 0 {
+		delete(customSyscalls, pkgPath)
+	}
+}
+
+// getSyscall returns interop name for the function from the package with
+// the given name and import path. Standard syscalls are matched by package
+// name and custom ones by import path.
+func getSyscall(pkgName, pkgPath, name string) (string, bool) {
+	if api, ok := syscalls[pkgName][name]; ok {
+		return api, true
+	}
+	customSyscallsLock.RLock()
+	defer customSyscallsLock.RUnlock()
+	api, ok := customSyscalls[pkgPath][name]
+	return api, ok
+}
+
 var syscalls = map[string]map[string]string{
 	"account": {
 		"GetBalance":    "Neo.Account.GetBalance",
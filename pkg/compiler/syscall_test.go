@@ -1,8 +1,10 @@
 package compiler_test
 
 import (
+	"math/big"
 	"testing"
 
+	"github.com/ixje/neo-go-legacy/pkg/compiler"
 	"github.com/ixje/neo-go-legacy/pkg/vm"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -45,3 +47,24 @@ func TestNotify(t *testing.T) {
 	assert.Equal(t, []vm.StackItem{}, s.events[1].Value())
 	assert.Equal(t, []vm.StackItem{vm.NewByteArrayItem([]byte("single"))}, s.events[2].Value())
 }
+
+func TestCustomSyscall(t *testing.T) {
+	const oraclePath = "github.com/ixje/neo-go-legacy/pkg/compiler/testdata/oracle"
+	require.NoError(t, compiler.RegisterSyscall(oraclePath, "Get", "Private.Oracle.Get"))
+	defer compiler.UnregisterSyscall(oraclePath, "Get")
+	require.Error(t, compiler.RegisterSyscall(oraclePath, "Get", "Private.Oracle.Get"))
+	require.Error(t, compiler.RegisterSyscall("github.com/ixje/neo-go-legacy/pkg/interop/runtime", "Log", "Private.Runtime.Log"))
+
+	src := `package foo
+	import "github.com/ixje/neo-go-legacy/pkg/compiler/testdata/oracle"
+	func Main() int {
+		return oracle.Get(21) + 1
+	}`
+	v := vmAndCompile(t, src)
+	v.RegisterInterop("Private.Oracle.Get", func(v *vm.VM) error {
+		v.Estack().PushVal(v.Estack().Pop().BigInt().Int64() * 2)
+		return nil
+	}, 1)
+	require.NoError(t, v.Run())
+	assert.Equal(t, big.NewInt(43), v.PopResult())
+}
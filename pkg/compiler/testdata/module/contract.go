@@ -0,0 +1,8 @@
+package contract
+
+import "example.com/contract/lib"
+
+// Main uses a package from the same module.
+func Main() int {
+	return lib.Double(21)
+}
@@ -0,0 +1,6 @@
+package lib
+
+// Double returns a doubled value.
+func Double(x int) int {
+	return x * 2
+}
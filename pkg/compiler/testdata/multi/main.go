@@ -0,0 +1,7 @@
+package multi
+
+// Main is an entry point defined in one file using functions and
+// variables from another one.
+func Main() int {
+	return add(base, multiplier*2)
+}
@@ -0,0 +1,9 @@
+package multi
+
+const multiplier = 21
+
+var base = 100
+
+func add(a, b int) int {
+	return a + b
+}
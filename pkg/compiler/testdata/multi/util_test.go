@@ -0,0 +1,4 @@
+package multi
+
+// This file is not a part of the contract.
+var unused = missing
@@ -0,0 +1,10 @@
+/*
+Package oracle is a stub for a custom interop used in compiler tests.
+*/
+package oracle
+
+// Get returns oracle value for the given key. It uses `Private.Oracle.Get`
+// syscall registered via compiler.RegisterSyscall.
+func Get(key int) int {
+	return 0
+}
@@ -0,0 +1,82 @@
+package vet
+
+import (
+	"go/types"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// NEP5 reports token contracts not conforming to the NEP-5 standard. The
+// contract is considered to be a token if its entry point handles
+// "balanceOf" or "totalSupply" operations.
+var NEP5 = &analysis.Analyzer{
+	Name:     "nep5",
+	Doc:      "report NEP-5 token standard violations",
+	Requires: []*analysis.Analyzer{Notify},
+	Run:      runNEP5,
+}
+
+// nep5Operations are operations every NEP-5 token must support.
+var nep5Operations = []string{"name", "symbol", "decimals", "totalSupply", "balanceOf", "transfer"}
+
+// nep5Transfer are types of "transfer" notification arguments: from, to
+// and amount.
+var nep5Transfer = []string{"ByteArray", "ByteArray", "Integer"}
+
+func runNEP5(pass *analysis.Pass) (interface{}, error) {
+	main := findMain(pass)
+	if main == nil {
+		return nil, nil
+	}
+	handled := make(map[string]bool)
+	for _, op := range operations(pass.TypesInfo, main) {
+		handled[op.name] = true
+	}
+	if !handled["balanceOf"] && !handled["totalSupply"] {
+		return nil, nil
+	}
+
+	var missing []string
+	for _, name := range nep5Operations {
+		if !handled[name] {
+			missing = append(missing, name)
+		}
+	}
+	sort.Strings(missing)
+	if len(missing) != 0 {
+		pass.Reportf(main.Name.Pos(), "NEP-5 token doesn't handle %s operation(s)", strings.Join(missing, ", "))
+	}
+
+	sig := pass.TypesInfo.Defs[main.Name].Type().(*types.Signature)
+	if sig.Params().Len() != 2 || stackItemType(sig.Params().At(0).Type()) != "ByteArray" ||
+		stackItemType(sig.Params().At(1).Type()) != "Array" {
+		pass.Reportf(main.Name.Pos(), "NEP-5 token entry point should accept operation and array of arguments")
+	}
+
+	events := pass.ResultOf[Notify].(map[string]event)
+	transfer, ok := events["transfer"]
+	if !ok {
+		pass.Reportf(main.Name.Pos(), "NEP-5 token doesn't send \"transfer\" notification")
+		return nil, nil
+	}
+	if !matchArgs(transfer.Args, nep5Transfer) {
+		pass.Reportf(main.Name.Pos(), "\"transfer\" notification at %s has %s arguments, %s expected by NEP-5",
+			transfer.Pos, formatArgs(transfer.Args), formatArgs(nep5Transfer))
+	}
+	return nil, nil
+}
+
+// matchArgs checks if argument types are compatible with the expected ones.
+func matchArgs(args, expected []string) bool {
+	if len(args) != len(expected) {
+		return false
+	}
+	for i := range args {
+		if args[i] != expected[i] && args[i] != "Any" {
+			return false
+		}
+	}
+	return true
+}
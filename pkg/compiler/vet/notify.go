@@ -0,0 +1,141 @@
+package vet
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"reflect"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+)
+
+// Notify reports notifications with the same name having different
+// arguments, such notifications can't be processed by clients reliably.
+// Notifications are named by the first constant string argument. The result
+// of the analyzer is a map of all notifications used by the package and its
+// dependencies.
+var Notify = &analysis.Analyzer{
+	Name:       "notify",
+	Doc:        "report notification argument mismatches",
+	Requires:   []*analysis.Analyzer{inspect.Analyzer},
+	Run:        runNotify,
+	ResultType: reflect.TypeOf(map[string]event(nil)),
+	FactTypes:  []analysis.Fact{new(eventsFact)},
+}
+
+// event is the notification with its argument types.
+type event struct {
+	Name string
+	// Args are NEO VM types of notification arguments.
+	Args []string
+	Pos  token.Position
+}
+
+// eventsFact is a list of notifications sent by the package.
+type eventsFact struct {
+	Events []event
+}
+
+// AFact implements analysis.Fact interface.
+func (*eventsFact) AFact() {}
+
+func (f *eventsFact) String() string {
+	return fmt.Sprintf("events(%d)", len(f.Events))
+}
+
+func runNotify(pass *analysis.Pass) (interface{}, error) {
+	events := make(map[string]event)
+	for _, f := range pass.AllPackageFacts() {
+		if fact, ok := f.Fact.(*eventsFact); ok && f.Package != pass.Pkg {
+			for _, e := range fact.Events {
+				if _, ok := events[e.Name]; !ok {
+					events[e.Name] = e
+				}
+			}
+		}
+	}
+
+	local := new(eventsFact)
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+	inspect.Preorder([]ast.Node{(*ast.CallExpr)(nil)}, func(node ast.Node) {
+		call := node.(*ast.CallExpr)
+		if !isInteropCall(pass.TypesInfo, call, "runtime", "Notify") ||
+			len(call.Args) == 0 || call.Ellipsis.IsValid() {
+			return
+		}
+		name, ok := constString(pass.TypesInfo, call.Args[0])
+		if !ok {
+			return
+		}
+		e := event{
+			Name: name,
+			Args: make([]string, len(call.Args)-1),
+			Pos:  pass.Fset.Position(call.Pos()),
+		}
+		for i, arg := range call.Args[1:] {
+			e.Args[i] = stackItemType(pass.TypesInfo.TypeOf(arg))
+		}
+
+		expected, ok := events[name]
+		if !ok {
+			events[name] = e
+			local.Events = append(local.Events, e)
+			return
+		}
+		if len(e.Args) != len(expected.Args) {
+			pass.Reportf(call.Pos(), "notification %q has %d arguments, %d expected as at %s",
+				name, len(e.Args), len(expected.Args), expected.Pos)
+			return
+		}
+		for i := range e.Args {
+			if e.Args[i] != expected.Args[i] && e.Args[i] != "Any" && expected.Args[i] != "Any" {
+				pass.Reportf(call.Args[i+1].Pos(), "argument %d of notification %q is %s, %s expected as at %s",
+					i+1, name, e.Args[i], expected.Args[i], expected.Pos)
+			}
+		}
+	})
+	if len(local.Events) != 0 {
+		pass.ExportPackageFact(local)
+	}
+	return events, nil
+}
+
+// stackItemType returns the type of NEO VM stack item Go type is represented with.
+func stackItemType(typ types.Type) string {
+	if typ == nil {
+		return "Any"
+	}
+	switch t := typ.Underlying().(type) {
+	case *types.Basic:
+		info := t.Info()
+		switch {
+		case info&types.IsInteger != 0:
+			return "Integer"
+		case info&types.IsBoolean != 0:
+			return "Boolean"
+		case info&types.IsString != 0:
+			return "ByteArray"
+		}
+	case *types.Slice:
+		if b, ok := t.Elem().Underlying().(*types.Basic); ok && b.Kind() == types.Byte {
+			return "ByteArray"
+		}
+		return "Array"
+	case *types.Array:
+		return "Array"
+	case *types.Map:
+		return "Map"
+	case *types.Struct:
+		return "Struct"
+	}
+	return "Any"
+}
+
+// formatArgs returns the list of argument types.
+func formatArgs(args []string) string {
+	return "(" + strings.Join(args, ", ") + ")"
+}
@@ -0,0 +1,150 @@
+package vet
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+)
+
+// StorageKeys reports constant storage keys and key prefixes which can
+// collide with each other, so that different data end up under the same key.
+var StorageKeys = &analysis.Analyzer{
+	Name:      "storagekeys",
+	Doc:       "report colliding storage keys and key prefixes",
+	Requires:  []*analysis.Analyzer{inspect.Analyzer},
+	Run:       runStorageKeys,
+	FactTypes: []analysis.Fact{new(storageKeysFact)},
+}
+
+// storageKey is a constant storage key or a constant prefix of keys.
+type storageKey struct {
+	Value  string
+	Prefix bool
+	Pos    token.Position
+}
+
+// storageKeysFact is a list of storage keys used by the package.
+type storageKeysFact struct {
+	Keys []storageKey
+}
+
+// AFact implements analysis.Fact interface.
+func (*storageKeysFact) AFact() {}
+
+func (f *storageKeysFact) String() string {
+	return fmt.Sprintf("storageKeys(%d)", len(f.Keys))
+}
+
+func (k storageKey) String() string {
+	if k.Prefix {
+		return fmt.Sprintf("keys prefixed by %q", k.Value)
+	}
+	return fmt.Sprintf("storage key %q", k.Value)
+}
+
+func runStorageKeys(pass *analysis.Pass) (interface{}, error) {
+	var known []storageKey
+	for _, f := range pass.AllPackageFacts() {
+		if fact, ok := f.Fact.(*storageKeysFact); ok && f.Package != pass.Pkg {
+			known = append(known, fact.Keys...)
+		}
+	}
+
+	local := new(storageKeysFact)
+	seen := make(map[storageKey]bool)
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+	inspect.Preorder([]ast.Node{(*ast.CallExpr)(nil)}, func(node ast.Node) {
+		call := node.(*ast.CallExpr)
+		fn := calledFunc(pass.TypesInfo, call)
+		if fn == nil || fn.Pkg() == nil || fn.Pkg().Path() != interopPrefix+"/storage" || len(call.Args) < 2 {
+			return
+		}
+		var k storageKey
+		switch fn.Name() {
+		case "Put", "Get", "Delete":
+			var ok bool
+			if k.Value, ok = constKey(pass.TypesInfo, call.Args[1]); !ok {
+				k.Value, k.Prefix = keyPrefix(pass.TypesInfo, call.Args[1])
+			}
+		case "Find":
+			k.Value, k.Prefix = keyPrefix(pass.TypesInfo, call.Args[1])
+			if !k.Prefix {
+				k.Value, k.Prefix = constKey(pass.TypesInfo, call.Args[1])
+			}
+		}
+		if k.Value == "" || seen[k] {
+			return
+		}
+		seen[k] = true
+
+		for _, other := range known {
+			if collide(k, other) {
+				pass.Reportf(call.Args[1].Pos(), "%s may collide with %s at %s", k, other, other.Pos)
+				break
+			}
+		}
+		k.Pos = pass.Fset.Position(call.Args[1].Pos())
+		known = append(known, k)
+		local.Keys = append(local.Keys, k)
+	})
+	if len(local.Keys) != 0 {
+		pass.ExportPackageFact(local)
+	}
+	return nil, nil
+}
+
+// collide checks if keys of one kind can be equal to keys of the other.
+func collide(a, b storageKey) bool {
+	if a.Value == b.Value {
+		return false
+	}
+	return b.Prefix && strings.HasPrefix(a.Value, b.Value) ||
+		a.Prefix && strings.HasPrefix(b.Value, a.Value)
+}
+
+// constKey returns the value of the constant key.
+func constKey(info *types.Info, expr ast.Expr) (string, bool) {
+	if s, ok := constString(info, expr); ok {
+		return s, true
+	}
+	// []byte("key") conversion.
+	if call, ok := expr.(*ast.CallExpr); ok && len(call.Args) == 1 {
+		if tv := info.Types[call.Fun]; tv.IsType() {
+			return constString(info, call.Args[0])
+		}
+	}
+	return "", false
+}
+
+// keyPrefix returns the constant prefix of the key built by concatenation
+// or appending to a constant.
+func keyPrefix(info *types.Info, expr ast.Expr) (string, bool) {
+	switch e := expr.(type) {
+	case *ast.ParenExpr:
+		return keyPrefix(info, e.X)
+	case *ast.BinaryExpr:
+		if e.Op != token.ADD {
+			return "", false
+		}
+		if s, ok := constKey(info, e.X); ok {
+			return s, true
+		}
+		return keyPrefix(info, e.X)
+	case *ast.CallExpr:
+		if id, ok := e.Fun.(*ast.Ident); ok && len(e.Args) != 0 {
+			if b, ok := info.Uses[id].(*types.Builtin); ok && b.Name() == "append" {
+				if s, ok := constKey(info, e.Args[0]); ok {
+					return s, true
+				}
+				return keyPrefix(info, e.Args[0])
+			}
+		}
+	}
+	return "", false
+}
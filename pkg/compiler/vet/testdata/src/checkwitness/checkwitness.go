@@ -0,0 +1,17 @@
+package checkwitness
+
+import (
+	"github.com/ixje/neo-go-legacy/pkg/interop/runtime"
+)
+
+func Main(op string, key []byte) bool {
+	if op == "check" {
+		return runtime.CheckWitness(key)
+	}
+	if op == "ignore" {
+		runtime.CheckWitness(key) // want "result of runtime.CheckWitness is not checked"
+		return true
+	}
+	_ = runtime.CheckWitness(key) // want "result of runtime.CheckWitness is not checked"
+	return false
+}
@@ -0,0 +1,20 @@
+// Package storage is a stub of the interop package used by analyzer tests.
+package storage
+
+// Context stub.
+type Context struct{}
+
+// GetContext stub.
+func GetContext() Context { return Context{} }
+
+// Put stub.
+func Put(ctx Context, key interface{}, value interface{}) {}
+
+// Get stub.
+func Get(ctx Context, key interface{}) interface{} { return 0 }
+
+// Delete stub.
+func Delete(ctx Context, key interface{}) {}
+
+// Find stub.
+func Find(ctx Context, key interface{}) interface{} { return nil }
@@ -0,0 +1,28 @@
+package nep5
+
+import (
+	"github.com/ixje/neo-go-legacy/pkg/interop/runtime"
+	"github.com/ixje/neo-go-legacy/pkg/interop/storage"
+)
+
+func Main(op string, args []interface{}, extra int) interface{} { // want "NEP-5 token doesn't handle decimals, symbol operation\\(s\\)" "NEP-5 token entry point should accept operation and array of arguments" "\"transfer\" notification at .* has \\(ByteArray, ByteArray\\) arguments, \\(ByteArray, ByteArray, Integer\\) expected by NEP-5"
+	switch op {
+	case "name":
+		return "Token"
+	case "totalSupply":
+		return 100
+	case "balanceOf":
+		return storage.Get(storage.GetContext(), args[0].([]byte))
+	case "transfer":
+		if !runtime.CheckWitness(args[0].([]byte)) {
+			return false
+		}
+		from, to := args[0].([]byte), args[1].([]byte)
+		runtime.Notify("transfer", from, to)
+		runtime.Notify("transfer", from, to, 1)
+		runtime.Notify("approve", from, 1)
+		runtime.Notify("approve", from, "1")
+		return true
+	}
+	return false
+}
@@ -0,0 +1,13 @@
+package notify // want package:"events\\(2\\)"
+
+import (
+	"github.com/ixje/neo-go-legacy/pkg/interop/runtime"
+)
+
+func Main(from, to []byte) bool {
+	runtime.Notify("transfer", from, to)
+	runtime.Notify("transfer", from, to, 1) // want "notification \"transfer\" has 3 arguments, 2 expected as at .*"
+	runtime.Notify("approve", from, 1)
+	runtime.Notify("approve", from, "1") // want "argument 2 of notification \"approve\" is ByteArray, Integer expected as at .*"
+	return true
+}
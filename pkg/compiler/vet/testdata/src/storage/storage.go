@@ -0,0 +1,25 @@
+package storage // want package:"storageKeys\\(4\\)"
+
+import (
+	"github.com/ixje/neo-go-legacy/pkg/interop/storage"
+)
+
+const balancePrefix = "b"
+
+func Main(op string, args []interface{}) interface{} {
+	ctx := storage.GetContext()
+	addr := args[0].([]byte)
+	switch op {
+	case "balance":
+		return storage.Get(ctx, append([]byte(balancePrefix), addr...))
+	case "owner":
+		return storage.Get(ctx, "bowner") // want "storage key \"bowner\" may collide with keys prefixed by \"b\""
+	case "total":
+		return storage.Get(ctx, "total")
+	case "list":
+		return storage.Find(ctx, "to") // want "keys prefixed by \"to\" may collide with storage key \"total\""
+	}
+	storage.Put(ctx, balancePrefix+string(addr), 1)
+	storage.Put(ctx, "total", 1)
+	return nil
+}
@@ -0,0 +1,32 @@
+package unsupported
+
+import (
+	"strings" // want "standard library package \"strings\" can't be used in contracts"
+
+	"github.com/ixje/neo-go-legacy/pkg/interop/runtime"
+)
+
+const ratio = 1.5 * 2
+
+var counter int
+
+type point struct {
+	x, y int
+}
+
+func Main(s string) int {
+	go runtime.Log(s)         // want "goroutines are not supported"
+	var ch chan int           // want "channels are not supported"
+	p := &point{1, 2}         // want "pointers are not supported"
+	var f float64 = ratio * 3 // want "floating point and complex numbers are not supported"
+	f = f / 2                 // want "floating point and complex numbers are not supported"
+	counter++                 // want "global variable counter can't be changed in functions"
+	counter = len(s)          // want "global variable counter can't be changed in functions"
+	c := cap([]int{})         // want "builtin function cap is not supported"
+	if strings.HasPrefix(s, "a") {
+		goto end // want "goto statements are not supported"
+	}
+end:
+	_, _ = ch, p
+	return c + int(ratio)
+}
@@ -0,0 +1,19 @@
+package state
+
+import (
+	"github.com/ixje/neo-go-legacy/pkg/interop/runtime"
+	"github.com/ixje/neo-go-legacy/pkg/interop/storage"
+)
+
+// Owner is the contract owner.
+const Owner = "owner"
+
+// Set changes the value without any checks.
+func Set(key, value []byte) {
+	storage.Put(storage.GetContext(), key, value)
+}
+
+// IsOwner checks the owner witness.
+func IsOwner() bool {
+	return runtime.CheckWitness([]byte(Owner))
+}
@@ -0,0 +1,52 @@
+package witness
+
+import (
+	"github.com/ixje/neo-go-legacy/pkg/interop/runtime"
+	"github.com/ixje/neo-go-legacy/pkg/interop/storage"
+	"witness/state"
+)
+
+func Main(op string, args []interface{}) bool { // want Main:"effects\\(writes=true, checks=true\\)"
+	key := args[0].([]byte)
+	if op == "get" {
+		return storage.Get(storage.GetContext(), key) != nil
+	}
+	if op == "set" { // want "operation \"set\" changes storage without checking witness"
+		state.Set(key, args[1].([]byte))
+		return true
+	}
+	if op == "put" || op == "delete" {
+		if !state.IsOwner() {
+			return false
+		}
+		update(op, key)
+		return true
+	}
+	if op == "remove" { // want "operation \"remove\" changes storage without checking witness"
+		runtime.CheckWitness(key)
+		remove(key)
+		return true
+	}
+	if op == "clear" { // want "operation \"clear\" changes storage without checking witness"
+		_ = runtime.CheckWitness(key)
+		return clear(key)
+	}
+	return false
+}
+
+func update(op string, key []byte) { // want update:"effects\\(writes=true, checks=false\\)"
+	if op == "put" {
+		state.Set(key, key)
+	} else {
+		remove(key)
+	}
+}
+
+func remove(key []byte) { // want remove:"effects\\(writes=true, checks=false\\)"
+	storage.Delete(storage.GetContext(), key)
+}
+
+func clear(key []byte) bool { // want clear:"effects\\(writes=true, checks=false\\)"
+	remove(key)
+	return true
+}
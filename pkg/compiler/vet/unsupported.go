@@ -0,0 +1,152 @@
+package vet
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strconv"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+)
+
+// Unsupported reports Go constructs the compiler can't translate
+// into NEO VM code.
+var Unsupported = &analysis.Analyzer{
+	Name:     "unsupported",
+	Doc:      "report Go constructs not supported by the contract compiler",
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      runUnsupported,
+}
+
+// supportedBuiltins are Go builtin functions implemented by the compiler.
+var supportedBuiltins = map[string]bool{
+	"append":  true,
+	"delete":  true,
+	"len":     true,
+	"panic":   true,
+	"recover": true,
+}
+
+func runUnsupported(pass *analysis.Pass) (interface{}, error) {
+	for _, f := range pass.Files {
+		for _, imp := range f.Imports {
+			path, err := strconv.Unquote(imp.Path.Value)
+			if err == nil && isStdlibPath(path) {
+				pass.Reportf(imp.Pos(), "standard library package %q can't be used in contracts", path)
+			}
+		}
+	}
+
+	// Floating point values usually span whole expressions,
+	// so they are reported once per line.
+	floatLines := make(map[token.Position]bool)
+	reportFloat := func(n ast.Node) {
+		pos := pass.Fset.Position(n.Pos())
+		pos.Column, pos.Offset = 0, 0
+		if !floatLines[pos] {
+			floatLines[pos] = true
+			pass.Reportf(n.Pos(), "floating point and complex numbers are not supported")
+		}
+	}
+
+	var funcDepth int
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+	inspect.Nodes(nil, func(node ast.Node, push bool) bool {
+		switch node.(type) {
+		case *ast.FuncDecl, *ast.FuncLit:
+			if push {
+				funcDepth++
+			} else {
+				funcDepth--
+			}
+		}
+		if !push {
+			return false
+		}
+
+		switch n := node.(type) {
+		case *ast.GenDecl:
+			// Constants are evaluated by the compiler.
+			if n.Tok == token.CONST {
+				return false
+			}
+		case *ast.GoStmt:
+			pass.Reportf(n.Pos(), "goroutines are not supported")
+		case *ast.ChanType, *ast.SendStmt, *ast.SelectStmt:
+			pass.Reportf(n.Pos(), "channels are not supported")
+			return false
+		case *ast.StarExpr:
+			pass.Reportf(n.Pos(), "pointers are not supported")
+			return false
+		case *ast.UnaryExpr:
+			switch n.Op {
+			case token.ARROW:
+				pass.Reportf(n.Pos(), "channels are not supported")
+				return false
+			case token.AND:
+				pass.Reportf(n.Pos(), "pointers are not supported")
+				return false
+			}
+		case *ast.BranchStmt:
+			if n.Tok == token.GOTO {
+				pass.Reportf(n.Pos(), "goto statements are not supported")
+			}
+		case *ast.CallExpr:
+			if id, ok := n.Fun.(*ast.Ident); ok {
+				if b, ok := pass.TypesInfo.Uses[id].(*types.Builtin); ok && !supportedBuiltins[b.Name()] {
+					pass.Reportf(n.Pos(), "builtin function %s is not supported", b.Name())
+				}
+			}
+		case *ast.AssignStmt:
+			if funcDepth != 0 && n.Tok != token.DEFINE {
+				for _, lhs := range n.Lhs {
+					checkGlobalAssign(pass, lhs)
+				}
+			}
+		case *ast.IncDecStmt:
+			if funcDepth != 0 {
+				checkGlobalAssign(pass, n.X)
+			}
+		case ast.Expr:
+			if isFloatExpr(pass.TypesInfo, n) {
+				reportFloat(n)
+				return false
+			}
+		}
+		return true
+	})
+	return nil, nil
+}
+
+// checkGlobalAssign reports assignments to package-level variables.
+func checkGlobalAssign(pass *analysis.Pass, lhs ast.Expr) {
+	id, ok := lhs.(*ast.Ident)
+	if !ok {
+		return
+	}
+	v, ok := pass.TypesInfo.Uses[id].(*types.Var)
+	if ok && v.Pkg() != nil && v.Parent() == v.Pkg().Scope() {
+		pass.Reportf(id.Pos(), "global variable %s can't be changed in functions", id.Name)
+	}
+}
+
+// isFloatExpr checks if the expression has floating point or complex
+// type and is not a constant, constants are folded by the compiler.
+func isFloatExpr(info *types.Info, expr ast.Expr) bool {
+	if tv, ok := info.Types[expr]; ok && tv.Value != nil {
+		return false
+	}
+	if id, ok := expr.(*ast.Ident); ok {
+		if _, ok := info.ObjectOf(id).(*types.Const); ok {
+			return false
+		}
+	}
+	typ := info.TypeOf(expr)
+	if typ == nil {
+		return false
+	}
+	b, ok := typ.Underlying().(*types.Basic)
+	return ok && b.Info()&(types.IsFloat|types.IsComplex) != 0 && b.Info()&types.IsUntyped == 0
+}
@@ -0,0 +1,143 @@
+package vet
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// mainIdent is the name of the contract entry point.
+const mainIdent = "Main"
+
+// calledFunc returns the function or the method called statically by the
+// call expression or nil if it's not a static call.
+func calledFunc(info *types.Info, call *ast.CallExpr) *types.Func {
+	var id *ast.Ident
+	switch fun := call.Fun.(type) {
+	case *ast.Ident:
+		id = fun
+	case *ast.SelectorExpr:
+		id = fun.Sel
+	default:
+		return nil
+	}
+	fn, _ := info.Uses[id].(*types.Func)
+	return fn
+}
+
+// isInteropCall checks if the call is a call of the interop function
+// with the specified name from the given interop package.
+func isInteropCall(info *types.Info, call *ast.CallExpr, pkg, name string) bool {
+	fn := calledFunc(info, call)
+	return fn != nil && fn.Pkg() != nil && fn.Name() == name &&
+		fn.Pkg().Path() == interopPrefix+"/"+pkg
+}
+
+// isStdlibPath checks if the import path belongs to the standard library.
+func isStdlibPath(path string) bool {
+	elem := strings.SplitN(path, "/", 2)[0]
+	return !strings.Contains(elem, ".")
+}
+
+// constString returns the value of the constant string expression.
+func constString(info *types.Info, expr ast.Expr) (string, bool) {
+	tv, ok := info.Types[expr]
+	if !ok || tv.Value == nil || tv.Value.Kind() != constant.String {
+		return "", false
+	}
+	return constant.StringVal(tv.Value), true
+}
+
+// findMain returns the declaration of the contract entry point or nil.
+func findMain(pass *analysis.Pass) *ast.FuncDecl {
+	for _, f := range pass.Files {
+		for _, decl := range f.Decls {
+			fd, ok := decl.(*ast.FuncDecl)
+			if ok && fd.Recv == nil && fd.Name.Name == mainIdent {
+				return fd
+			}
+		}
+	}
+	return nil
+}
+
+// operation is a branch of the entry point handling the specific operation.
+type operation struct {
+	name string
+	// node is the node comparing operation parameter with the name.
+	node ast.Node
+	// body is the code executed for the operation.
+	body []ast.Stmt
+}
+
+// operations returns operations the entry point dispatches on its first
+// parameter with if and switch statements comparing it with constants.
+func operations(info *types.Info, main *ast.FuncDecl) []operation {
+	params := main.Type.Params
+	if params.NumFields() == 0 || len(params.List[0].Names) == 0 {
+		return nil
+	}
+	op := info.Defs[params.List[0].Names[0]]
+	if op == nil {
+		return nil
+	}
+	isOp := func(expr ast.Expr) bool {
+		id, ok := expr.(*ast.Ident)
+		return ok && info.Uses[id] == op
+	}
+
+	var res []operation
+	ast.Inspect(main.Body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.IfStmt:
+			for _, name := range comparedNames(info, n.Cond, isOp) {
+				res = append(res, operation{name: name, node: n.Cond, body: n.Body.List})
+			}
+		case *ast.SwitchStmt:
+			if n.Tag == nil || !isOp(n.Tag) {
+				return true
+			}
+			for _, stmt := range n.Body.List {
+				cc := stmt.(*ast.CaseClause)
+				for _, expr := range cc.List {
+					if name, ok := constString(info, expr); ok {
+						res = append(res, operation{name: name, node: expr, body: cc.Body})
+					}
+				}
+			}
+		}
+		return true
+	})
+	return res
+}
+
+// comparedNames returns constants the operation is compared with in the
+// condition, comparisons can be combined with && and ||.
+func comparedNames(info *types.Info, cond ast.Expr, isOp func(ast.Expr) bool) []string {
+	switch e := cond.(type) {
+	case *ast.ParenExpr:
+		return comparedNames(info, e.X, isOp)
+	case *ast.BinaryExpr:
+		switch e.Op {
+		case token.LAND, token.LOR:
+			return append(comparedNames(info, e.X, isOp), comparedNames(info, e.Y, isOp)...)
+		case token.EQL:
+			if isOp(e.X) {
+				if name, ok := constString(info, e.Y); ok {
+					return []string{name}
+				}
+			} else if isOp(e.Y) {
+				if name, ok := constString(info, e.X); ok {
+					return []string{name}
+				}
+			}
+		}
+	}
+	return nil
+}
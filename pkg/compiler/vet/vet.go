@@ -0,0 +1,227 @@
+/*
+Package vet implements static checks for Go smart contracts.
+
+Analyzers of this package are built on golang.org/x/tools/go/analysis and
+report constructs the compiler doesn't support along with common NEO-specific
+mistakes which compile fine, but fail (or are exploitable) at runtime.
+*/
+package vet
+
+import (
+	"fmt"
+	"go/token"
+	"go/types"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/ixje/neo-go-legacy/pkg/compiler"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/packages"
+)
+
+// interopPrefix is the path prefix of interop packages.
+const interopPrefix = "github.com/ixje/neo-go-legacy/pkg/interop"
+
+// Analyzers is the list of all contract analyzers.
+var Analyzers = []*analysis.Analyzer{
+	Unsupported,
+	StorageKeys,
+	CheckWitness,
+	Witness,
+	NEP5,
+	Notify,
+}
+
+// Diagnostic is a problem found by an analyzer.
+type Diagnostic struct {
+	Pos      token.Position
+	Analyzer string
+	Message  string
+}
+
+// String implements fmt.Stringer interface.
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s: %s (%s)", d.Pos, d.Message, d.Analyzer)
+}
+
+// Run loads the contract from the given Go file or package directory and
+// applies analyzers to it and to all packages it imports except for the
+// standard library and interop packages. All analyzers are used if none
+// are specified.
+func Run(path string, analyzers ...*analysis.Analyzer) ([]Diagnostic, error) {
+	if len(analyzers) == 0 {
+		analyzers = Analyzers
+	}
+	if err := analysis.Validate(analyzers); err != nil {
+		return nil, err
+	}
+	pkg, err := compiler.LoadProgram(path)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &runner{
+		fset:         pkg.Fset,
+		requested:    make(map[*analysis.Analyzer]bool),
+		objectFacts:  make(map[objectFactKey]analysis.Fact),
+		packageFacts: make(map[packageFactKey]analysis.Fact),
+	}
+	for _, a := range analyzers {
+		r.requested[a] = true
+	}
+	// Dependencies are analyzed first, so that their facts are available.
+	visited := make(map[*types.Package]bool)
+	var visit func(pkg *packages.Package) error
+	visit = func(pkg *packages.Package) error {
+		if visited[pkg.Types] {
+			return nil
+		}
+		visited[pkg.Types] = true
+		for _, imp := range pkg.Types.Imports() {
+			if dep := pkg.Imports[imp.Path()]; dep != nil && isContractPath(imp.Path()) {
+				if err := visit(dep); err != nil {
+					return err
+				}
+			}
+		}
+		return r.analyze(pkg, analyzers)
+	}
+	if err := visit(pkg); err != nil {
+		return nil, err
+	}
+	sort.Slice(r.diagnostics, func(i, j int) bool {
+		a, b := r.diagnostics[i].Pos, r.diagnostics[j].Pos
+		if a.Filename != b.Filename {
+			return a.Filename < b.Filename
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+	return r.diagnostics, nil
+}
+
+// isContractPath checks if the package is compiled as a part of the
+// contract, that is it's neither a standard library nor an interop package.
+func isContractPath(path string) bool {
+	return !isStdlibPath(path) && path != interopPrefix && !strings.HasPrefix(path, interopPrefix+"/")
+}
+
+type objectFactKey struct {
+	obj types.Object
+	typ reflect.Type
+}
+
+type packageFactKey struct {
+	pkg *types.Package
+	typ reflect.Type
+}
+
+// runner applies analyzers to packages of the program. All packages are
+// loaded together, so facts are shared via objects and packages directly.
+type runner struct {
+	fset *token.FileSet
+	// requested are analyzers to report diagnostics for, others
+	// are only run because requested ones depend on them.
+	requested    map[*analysis.Analyzer]bool
+	objectFacts  map[objectFactKey]analysis.Fact
+	packageFacts map[packageFactKey]analysis.Fact
+	// Keys of facts in the order they were exported in.
+	objectKeys  []objectFactKey
+	packageKeys []packageFactKey
+	diagnostics []Diagnostic
+}
+
+// analyze applies analyzers with all their requirements to the package.
+func (r *runner) analyze(pkg *packages.Package, analyzers []*analysis.Analyzer) error {
+	results := make(map[*analysis.Analyzer]interface{})
+	var exec func(a *analysis.Analyzer) error
+	exec = func(a *analysis.Analyzer) error {
+		if _, ok := results[a]; ok {
+			return nil
+		}
+		inputs := make(map[*analysis.Analyzer]interface{}, len(a.Requires))
+		for _, req := range a.Requires {
+			if err := exec(req); err != nil {
+				return err
+			}
+			inputs[req] = results[req]
+		}
+		pass := &analysis.Pass{
+			Analyzer:   a,
+			Fset:       r.fset,
+			Files:      pkg.Syntax,
+			Pkg:        pkg.Types,
+			TypesInfo:  pkg.TypesInfo,
+			TypesSizes: pkg.TypesSizes,
+			ResultOf:   inputs,
+			Report: func(d analysis.Diagnostic) {
+				if !r.requested[a] {
+					return
+				}
+				r.diagnostics = append(r.diagnostics, Diagnostic{
+					Pos:      r.fset.Position(d.Pos),
+					Analyzer: a.Name,
+					Message:  d.Message,
+				})
+			},
+			ImportObjectFact: func(obj types.Object, fact analysis.Fact) bool {
+				return importFact(r.objectFacts[objectFactKey{obj, reflect.TypeOf(fact)}], fact)
+			},
+			ExportObjectFact: func(obj types.Object, fact analysis.Fact) {
+				k := objectFactKey{obj, reflect.TypeOf(fact)}
+				if _, ok := r.objectFacts[k]; !ok {
+					r.objectKeys = append(r.objectKeys, k)
+				}
+				r.objectFacts[k] = fact
+			},
+			ImportPackageFact: func(p *types.Package, fact analysis.Fact) bool {
+				return importFact(r.packageFacts[packageFactKey{p, reflect.TypeOf(fact)}], fact)
+			},
+			ExportPackageFact: func(fact analysis.Fact) {
+				k := packageFactKey{pkg.Types, reflect.TypeOf(fact)}
+				if _, ok := r.packageFacts[k]; !ok {
+					r.packageKeys = append(r.packageKeys, k)
+				}
+				r.packageFacts[k] = fact
+			},
+			AllObjectFacts: func() []analysis.ObjectFact {
+				res := make([]analysis.ObjectFact, len(r.objectKeys))
+				for i, k := range r.objectKeys {
+					res[i] = analysis.ObjectFact{Object: k.obj, Fact: r.objectFacts[k]}
+				}
+				return res
+			},
+			AllPackageFacts: func() []analysis.PackageFact {
+				res := make([]analysis.PackageFact, len(r.packageKeys))
+				for i, k := range r.packageKeys {
+					res[i] = analysis.PackageFact{Package: k.pkg, Fact: r.packageFacts[k]}
+				}
+				return res
+			},
+		}
+		res, err := a.Run(pass)
+		if err != nil {
+			return fmt.Errorf("%s: %s: %v", pkg.PkgPath, a.Name, err)
+		}
+		results[a] = res
+		return nil
+	}
+	for _, a := range analyzers {
+		if err := exec(a); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// importFact copies the stored fact into the provided one.
+func importFact(stored, fact analysis.Fact) bool {
+	if stored == nil {
+		return false
+	}
+	reflect.ValueOf(fact).Elem().Set(reflect.ValueOf(stored).Elem())
+	return true
+}
@@ -0,0 +1,53 @@
+package vet
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+const examplePath = "../../../examples"
+
+func TestAnalyzers(t *testing.T) {
+	testCases := []struct {
+		pkg      string
+		analyzer *analysis.Analyzer
+	}{
+		{"unsupported", Unsupported},
+		{"storage", StorageKeys},
+		{"checkwitness", CheckWitness},
+		{"witness", Witness},
+		{"nep5", NEP5},
+		{"notify", Notify},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.pkg, func(t *testing.T) {
+			analysistest.Run(t, analysistest.TestData(), tc.analyzer, tc.pkg)
+		})
+	}
+}
+
+func TestExamples(t *testing.T) {
+	infos, err := ioutil.ReadDir(examplePath)
+	require.NoError(t, err)
+	for _, info := range infos {
+		if info.Name() == "storage" {
+			// Storage example is intentionally unprotected.
+			continue
+		}
+		ds, err := Run(filepath.Join(examplePath, info.Name()))
+		require.NoError(t, err)
+		require.Empty(t, ds, info.Name())
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	_, err := Run("testdata/unknown")
+	require.Error(t, err)
+	_, err = Run("vet_test.go_")
+	require.Error(t, err)
+}
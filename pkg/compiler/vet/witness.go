@@ -0,0 +1,221 @@
+package vet
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+)
+
+// CheckWitness reports runtime.CheckWitness calls with results ignored,
+// such calls don't protect anything.
+var CheckWitness = &analysis.Analyzer{
+	Name:     "checkwitness",
+	Doc:      "report unchecked results of runtime.CheckWitness",
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      runCheckWitness,
+}
+
+// Witness reports contract operations changing storage without checking
+// witnesses with runtime.CheckWitness, which means that anyone can invoke them.
+var Witness = &analysis.Analyzer{
+	Name:      "witness",
+	Doc:       "report storage changes without witness checks",
+	Run:       runWitness,
+	FactTypes: []analysis.Fact{new(effectsFact)},
+}
+
+func runCheckWitness(pass *analysis.Pass) (interface{}, error) {
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+	inspect.Preorder([]ast.Node{(*ast.ExprStmt)(nil), (*ast.AssignStmt)(nil)}, func(node ast.Node) {
+		for _, expr := range discarded(node) {
+			if isCheckWitness(pass.TypesInfo, expr) {
+				pass.Reportf(expr.Pos(), "result of runtime.CheckWitness is not checked")
+			}
+		}
+	})
+	return nil, nil
+}
+
+// isCheckWitness checks if the expression is a runtime.CheckWitness call.
+func isCheckWitness(info *types.Info, expr ast.Expr) bool {
+	call, ok := expr.(*ast.CallExpr)
+	return ok && isInteropCall(info, call, "runtime", "CheckWitness")
+}
+
+// discarded returns expressions of the statement whose values are
+// thrown away.
+func discarded(node ast.Node) []ast.Expr {
+	switch n := node.(type) {
+	case *ast.ExprStmt:
+		return []ast.Expr{n.X}
+	case *ast.AssignStmt:
+		if len(n.Lhs) != len(n.Rhs) {
+			return nil
+		}
+		var res []ast.Expr
+		for i := range n.Rhs {
+			if id, ok := n.Lhs[i].(*ast.Ident); ok && id.Name == "_" {
+				res = append(res, n.Rhs[i])
+			}
+		}
+		return res
+	}
+	return nil
+}
+
+// effectsFact describes storage changes and witness checks made
+// by the function or any function it calls.
+type effectsFact struct {
+	Writes bool
+	Checks bool
+}
+
+// AFact implements analysis.Fact interface.
+func (*effectsFact) AFact() {}
+
+func (f *effectsFact) String() string {
+	return fmt.Sprintf("effects(writes=%t, checks=%t)", f.Writes, f.Checks)
+}
+
+func runWitness(pass *analysis.Pass) (interface{}, error) {
+	decls := make(map[*types.Func]*ast.FuncDecl)
+	for _, f := range pass.Files {
+		for _, decl := range f.Decls {
+			if fd, ok := decl.(*ast.FuncDecl); ok && fd.Body != nil {
+				if fn, ok := pass.TypesInfo.Defs[fd.Name].(*types.Func); ok {
+					decls[fn] = fd
+				}
+			}
+		}
+	}
+
+	// Effects of functions depend on each other, so they are
+	// propagated until nothing changes.
+	effects := make(map[*types.Func]effectsFact, len(decls))
+	for changed := true; changed; {
+		changed = false
+		for fn, fd := range decls {
+			e := nodeEffects(pass, effects, fd.Body)
+			if e != effects[fn] {
+				effects[fn] = e
+				changed = true
+			}
+		}
+	}
+	for fn := range decls {
+		e := effects[fn]
+		pass.ExportObjectFact(fn, &e)
+	}
+
+	main := findMain(pass)
+	if main == nil || main.Body == nil {
+		return nil, nil
+	}
+	ops := operations(pass.TypesInfo, main)
+	if len(ops) == 0 {
+		if e := effects[pass.TypesInfo.Defs[main.Name].(*types.Func)]; e.Writes && !e.Checks {
+			pass.Reportf(main.Name.Pos(), "%s changes storage without checking witness", mainIdent)
+		}
+		return nil, nil
+	}
+
+	// Witness can be checked before operations are dispatched.
+	opBodies := make(map[ast.Node]bool)
+	for _, op := range ops {
+		opBodies[op.node] = true
+		for _, stmt := range op.body {
+			opBodies[stmt] = true
+		}
+	}
+	var checked bool
+	ast.Inspect(main.Body, func(n ast.Node) bool {
+		if checked || opBodies[n] {
+			return false
+		}
+		switch n := n.(type) {
+		case ast.Stmt:
+			if !isBlock(n) {
+				checked = nodeEffects(pass, effects, n).Checks
+				return false
+			}
+		case *ast.CallExpr:
+			checked = callEffects(pass, effects, n).Checks
+		}
+		return true
+	})
+	if checked {
+		return nil, nil
+	}
+
+	for _, op := range ops {
+		e := nodeEffects(pass, effects, op.node)
+		for _, stmt := range op.body {
+			e = e.merge(nodeEffects(pass, effects, stmt))
+		}
+		if e.Writes && !e.Checks {
+			pass.Reportf(op.node.Pos(), "operation %q changes storage without checking witness", op.name)
+		}
+	}
+	return nil, nil
+}
+
+// isBlock checks if the statement contains other statements.
+func isBlock(stmt ast.Stmt) bool {
+	switch stmt.(type) {
+	case *ast.BlockStmt, *ast.IfStmt, *ast.SwitchStmt, *ast.TypeSwitchStmt,
+		*ast.CaseClause, *ast.ForStmt, *ast.RangeStmt, *ast.LabeledStmt:
+		return true
+	}
+	return false
+}
+
+func (f effectsFact) merge(other effectsFact) effectsFact {
+	return effectsFact{
+		Writes: f.Writes || other.Writes,
+		Checks: f.Checks || other.Checks,
+	}
+}
+
+// nodeEffects returns effects of all calls made in the node. Witness
+// checks with results thrown away don't count.
+func nodeEffects(pass *analysis.Pass, effects map[*types.Func]effectsFact, node ast.Node) effectsFact {
+	var res effectsFact
+	ignored := make(map[ast.Node]bool)
+	ast.Inspect(node, func(n ast.Node) bool {
+		for _, expr := range discarded(n) {
+			if isCheckWitness(pass.TypesInfo, expr) {
+				ignored[expr] = true
+			}
+		}
+		if call, ok := n.(*ast.CallExpr); ok && !ignored[call] {
+			res = res.merge(callEffects(pass, effects, call))
+		}
+		return true
+	})
+	return res
+}
+
+// callEffects returns effects of the called function.
+func callEffects(pass *analysis.Pass, effects map[*types.Func]effectsFact, call *ast.CallExpr) effectsFact {
+	switch {
+	case isInteropCall(pass.TypesInfo, call, "storage", "Put"),
+		isInteropCall(pass.TypesInfo, call, "storage", "Delete"):
+		return effectsFact{Writes: true}
+	case isInteropCall(pass.TypesInfo, call, "runtime", "CheckWitness"):
+		return effectsFact{Checks: true}
+	}
+	fn := calledFunc(pass.TypesInfo, call)
+	if fn == nil {
+		return effectsFact{}
+	}
+	if fn.Pkg() == pass.Pkg {
+		return effects[fn]
+	}
+	var e effectsFact
+	pass.ImportObjectFact(fn, &e)
+	return e
+}
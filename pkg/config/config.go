@@ -29,7 +29,11 @@ func (c Config) GenerateUserAgent() string {
 // Load attempts to load the config from the given
 // path for the given netMode.
 func Load(path string, netMode NetMode) (Config, error) {
-	configPath := fmt.Sprintf("%s/protocol.%s.yml", path, netMode)
+	return LoadFile(fmt.Sprintf("%s/protocol.%s.yml", path, netMode))
+}
+
+// LoadFile attempts to load the config from the given file.
+func LoadFile(configPath string) (Config, error) {
 	if _, err := os.Stat(configPath); os.IsNotExist(err) {
 		return Config{}, errors.Wrap(err, "Unable to load config")
 	}
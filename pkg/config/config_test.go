@@ -0,0 +1,21 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad(t *testing.T) {
+	cfg, err := Load("../../config", ModeMainNet)
+	require.NoError(t, err)
+	require.Equal(t, ModeMainNet, cfg.ProtocolConfiguration.Magic)
+
+	fileCfg, err := LoadFile("../../config/protocol.mainnet.yml")
+	require.NoError(t, err)
+	require.Equal(t, cfg, fileCfg)
+	require.EqualValues(t, 10000, fileCfg.ProtocolConfiguration.SystemFee.RegisterTransaction)
+
+	_, err = LoadFile("../../config/protocol.unknown.yml")
+	require.Error(t, err)
+}
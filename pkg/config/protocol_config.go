@@ -20,6 +20,11 @@ const (
 type (
 	ProtocolConfiguration struct {
 		AddressVersion byte `yaml:"AddressVersion"`
+		// CustomInterops lists names of additional interop functions that
+		// can be registered with Blockchain.RegisterInterop. All nodes of the
+		// network must have the same list (and the same handlers) to agree on
+		// the state.
+		CustomInterops []string `yaml:"CustomInterops"`
 		// EnableStateRoot specifies if exchange of state roots should be enabled.
 		EnableStateRoot bool `yaml:"EnableStateRoot"`
 		// KeepOnlyLatestState specifies if MPT should only store latest state.
@@ -39,6 +39,9 @@ type Service interface {
 	// Start initializes dBFT and starts event loop for consensus service.
 	// It must be called only when sufficient amount of peers are connected.
 	Start()
+	// Shutdown stops consensus event loop and closes the external signer
+	// connection if there is any.
+	Shutdown()
 
 	// OnPayload is a callback to notify Service about new received payload.
 	OnPayload(p *Payload)
@@ -66,9 +69,17 @@ type service struct {
 	blockEvents  chan *coreb.Block
 	lastProposal []util.Uint256
 	wallet       *wallet.Wallet
+	// signer is an external signer holding validator keys, it's used
+	// instead of wallet keys if configured.
+	signer     *wallet.ExternalSigner
+	signerKeys keys.PublicKeys
 	// started is a flag set with Start method that runs an event handling
 	// goroutine.
 	started *atomic.Bool
+	// quit is closed by Shutdown to stop the event loop and finished is
+	// closed by the event loop when it's done.
+	quit     chan struct{}
+	finished chan struct{}
 }
 
 // Config is a configuration for consensus services.
@@ -110,6 +121,8 @@ func NewService(cfg Config) (Service, error) {
 		transactions: make(chan *transaction.Transaction, 100),
 		blockEvents:  make(chan *coreb.Block, 1),
 		started:      atomic.NewBool(false),
+		quit:         make(chan struct{}),
+		finished:     make(chan struct{}),
 	}
 
 	if cfg.Wallet == nil {
@@ -122,17 +135,27 @@ func NewService(cfg Config) (Service, error) {
 		return nil, err
 	}
 
-	// Check that wallet password is correct for at least one account.
-	var ok bool
-	for _, acc := range srv.wallet.Accounts {
-		err := acc.Decrypt(srv.Config.Wallet.Password)
-		if err == nil {
-			ok = true
-			break
+	if cfg.Wallet.Signer != "" {
+		if srv.signer, err = wallet.DialExternalSigner(cfg.Wallet.Signer); err != nil {
+			return nil, err
+		}
+		if srv.signerKeys, err = srv.signer.PublicKeys(); err != nil {
+			srv.signer.Close()
+			return nil, err
+		}
+	} else {
+		// Check that wallet password is correct for at least one account.
+		var ok bool
+		for _, acc := range srv.wallet.Accounts {
+			err := acc.DecryptWithScrypt(srv.Config.Wallet.Password, srv.wallet.Scrypt)
+			if err == nil {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return nil, errors.New("no account with provided password was found")
 		}
-	}
-	if !ok {
-		return nil, errors.New("no account with provided password was found")
 	}
 
 	defer srv.wallet.Close()
@@ -167,6 +190,9 @@ func NewService(cfg Config) (Service, error) {
 	)
 
 	if srv.dbft == nil {
+		if srv.signer != nil {
+			srv.signer.Close()
+		}
 		return nil, errors.New("can't initialize dBFT")
 	}
 
@@ -186,9 +212,26 @@ func (s *service) Start() {
 	}
 }
 
+// Shutdown implements Service interface.
+func (s *service) Shutdown() {
+	if s.started.Load() {
+		close(s.quit)
+		<-s.finished
+	}
+	if s.signer != nil {
+		if err := s.signer.Close(); err != nil {
+			s.log.Warn("failed to close external signer", zap.Error(err))
+		}
+	}
+}
+
 func (s *service) eventLoop() {
+	defer close(s.finished)
 	for {
 		select {
+		case <-s.quit:
+			s.Chain.UnsubscribeFromBlocks(s.blockEvents)
+			return
 		case <-s.dbft.Timer.C():
 			hv := s.dbft.Timer.HV()
 			s.log.Debug("timer fired",
@@ -265,12 +308,15 @@ func (s *service) newPrepareRequest() payload.PrepareRequest {
 }
 
 func (s *service) getStateRootSig() []byte {
-	var sig []byte
-
 	sr, err := s.Chain.GetStateRoot(s.dbft.BlockIndex - 1)
-	if err == nil {
-		data := sr.GetSignedPart()
-		sig, _ = s.dbft.Priv.Sign(data)
+	if err != nil {
+		return nil
+	}
+	sig, err := s.dbft.Priv.Sign(sr.GetSignedPart())
+	if err != nil {
+		// Prepare request is sent without state root signature then.
+		s.log.Warn("can't sign state root", zap.Uint32("block", s.dbft.BlockIndex-1), zap.Error(err))
+		return nil
 	}
 	return sig
 }
@@ -333,13 +379,24 @@ func (s *service) validatePayload(p *Payload) bool {
 
 func (s *service) getKeyPair(pubs []crypto.PublicKey) (int, crypto.PrivateKey, crypto.PublicKey) {
 	for i := range pubs {
-		sh := pubs[i].(*publicKey).GetScriptHash()
+		pub := pubs[i].(*publicKey)
+		sh := pub.GetScriptHash()
 		acc := s.wallet.GetAccount(sh)
 		if acc == nil {
 			continue
 		}
 
-		key, err := keys.NEP2Decrypt(acc.EncryptedWIF, s.Config.Wallet.Password)
+		if s.signer != nil {
+			if !s.signerKeys.Contains(pub.PublicKey) {
+				continue
+			}
+			return i, &signerKey{Signer: s.signer.Signer(pub.PublicKey)}, pub
+		}
+		if acc.IsWatchOnly() {
+			continue
+		}
+
+		key, err := keys.NEP2DecryptWithParams(acc.EncryptedWIF, s.Config.Wallet.Password, s.wallet.Scrypt)
 		if err != nil {
 			s.log.Fatal("can't unlock account", zap.String("address", address.Uint160ToString(sh)))
 			break
@@ -406,7 +463,7 @@ func (s *service) broadcast(p payload.ConsensusPayload) {
 		pr.minerTx = *s.txx.Get(pr.transactionHashes[0]).(*transaction.Transaction)
 	}
 
-	if err := p.(*Payload).Sign(s.dbft.Priv.(*privateKey)); err != nil {
+	if err := p.(*Payload).Sign(s.dbft.Priv.(wallet.Signer)); err != nil {
 		s.log.Warn("can't sign consensus payload", zap.Error(err))
 	}
 
@@ -5,6 +5,7 @@ import (
 	"errors"
 
 	"github.com/ixje/neo-go-legacy/pkg/crypto/keys"
+	"github.com/ixje/neo-go-legacy/pkg/wallet"
 )
 
 // privateKey is a wrapper around keys.PrivateKey
@@ -29,6 +30,22 @@ func (p *privateKey) Sign(data []byte) ([]byte, error) {
 	return p.PrivateKey.Sign(data), nil
 }
 
+// signerKey is a wrapper around wallet.Signer which implements
+// crypto.PrivateKey interface for keys kept outside of the node.
+type signerKey struct {
+	wallet.Signer
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler interface.
+func (s signerKey) MarshalBinary() ([]byte, error) {
+	return nil, errors.New("external key can't be serialized")
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler interface.
+func (s *signerKey) UnmarshalBinary([]byte) error {
+	return errors.New("external key can't be deserialized")
+}
+
 // publicKey is a wrapper around keys.PublicKey
 // which implements crypto.PublicKey interface.
 type publicKey struct {
@@ -12,6 +12,7 @@ import (
 	"github.com/ixje/neo-go-legacy/pkg/util"
 	"github.com/ixje/neo-go-legacy/pkg/vm"
 	"github.com/ixje/neo-go-legacy/pkg/vm/opcode"
+	"github.com/ixje/neo-go-legacy/pkg/wallet"
 	"github.com/pkg/errors"
 )
 
@@ -189,9 +190,9 @@ func (p *Payload) EncodeBinary(w *io.BinWriter) {
 	p.Witness.EncodeBinary(w)
 }
 
-// Sign signs payload using the private key.
+// Sign signs payload using the signer (local or external key).
 // It also sets corresponding verification and invocation scripts.
-func (p *Payload) Sign(key *privateKey) error {
+func (p *Payload) Sign(key wallet.Signer) error {
 	sig, err := key.Sign(p.MarshalUnsigned())
 	if err != nil {
 		return err
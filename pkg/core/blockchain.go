@@ -58,6 +58,12 @@ var (
 	// ErrInvalidBlockIndex is returned when trying to add block with index
 	// other than expected height of the blockchain.
 	ErrInvalidBlockIndex error = errors.New("invalid block index")
+	// ErrInteropNotAllowed is returned when trying to register custom
+	// interop function that is not listed in CustomInterops configuration.
+	ErrInteropNotAllowed = errors.New("interop is not allowed by configuration")
+	// ErrInteropExists is returned when trying to register custom interop
+	// function with the name that is already used.
+	ErrInteropExists = errors.New("interop with this name already exists")
 )
 var (
 	genAmount         = []int{8, 7, 6, 5, 4, 3, 2, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1}
@@ -128,6 +134,12 @@ type Blockchain struct {
 
 	lastBatch *storage.MemBatch
 
+	// This lock protects customInterops, the slice itself is never
+	// changed after creation, so it can be safely shared with VMs.
+	interopLock sync.RWMutex
+	// Additional interop functions registered by embedding code.
+	customInterops []interopedFunction
+
 	// Notification subsystem.
 	events  chan bcEvent
 	subCh   chan interface{}
@@ -1626,7 +1638,7 @@ func (bc *Blockchain) SystemFee(t *transaction.Transaction) util.Fixed8 {
 			if desc.Type == transaction.Validator && desc.Field == "Registered" {
 				for i := range desc.Value {
 					if desc.Value[i] != 0 {
-						res += util.Fixed8FromInt64(1000)
+						res += transaction.ValidatorRegistrationFee
 						break
 					}
 				}
@@ -2546,5 +2558,55 @@ func (bc *Blockchain) secondsPerBlock() int {
 }
 
 func (bc *Blockchain) newInteropContext(trigger trigger.Type, d dao.DAO, block *block.Block, tx *transaction.Transaction) *interopContext {
-	return newInteropContext(trigger, bc, d, block, tx, bc.log)
+	ic := newInteropContext(trigger, bc, d, block, tx, bc.log)
+	bc.interopLock.RLock()
+	ic.custom = bc.customInterops
+	bc.interopLock.RUnlock()
+	return ic
+}
+
+// RegisterInterop registers an additional interop function with the given
+// name and price that will be available to all VMs spawned by the chain
+// after this call. The name must be listed in CustomInterops section of
+// protocol configuration and must not clash with any of the standard
+// interops. As it affects the state, it should be done before the chain is
+// started and all nodes of the network must use the same set of functions.
+func (bc *Blockchain) RegisterInterop(name string, f vm.InteropFunc, price int) error {
+	var allowed bool
+	for _, n := range bc.config.CustomInterops {
+		if n == name {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return errors.Wrap(ErrInteropNotAllowed, name)
+	}
+	id := vm.InteropNameToID([]byte(name))
+	if isStandardInterop(id) {
+		return errors.Wrap(ErrInteropExists, name)
+	}
+
+	bc.interopLock.Lock()
+	defer bc.interopLock.Unlock()
+	for i := range bc.customInterops {
+		if bc.customInterops[i].ID == id {
+			return errors.Wrap(ErrInteropExists, name)
+		}
+	}
+	iops := make([]interopedFunction, len(bc.customInterops), len(bc.customInterops)+1)
+	copy(iops, bc.customInterops)
+	iops = append(iops, interopedFunction{
+		ID:   id,
+		Name: name,
+		Func: func(_ *interopContext, v *vm.VM) error {
+			return f(v)
+		},
+		Price: price,
+	})
+	sort.Slice(iops, func(i, j int) bool {
+		return iops[i].ID < iops[j].ID
+	})
+	bc.customInterops = iops
+	return nil
 }
@@ -12,9 +12,11 @@ import (
 	"github.com/ixje/neo-go-legacy/pkg/encoding/address"
 	"github.com/ixje/neo-go-legacy/pkg/io"
 	"github.com/ixje/neo-go-legacy/pkg/util"
+	"github.com/ixje/neo-go-legacy/pkg/vm"
 	"github.com/ixje/neo-go-legacy/pkg/vm/emit"
 	"github.com/ixje/neo-go-legacy/pkg/vm/opcode"
 	"github.com/ixje/neo-go-legacy/pkg/wallet"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -243,6 +245,33 @@ func TestGetClaimable(t *testing.T) {
 	})
 }
 
+func TestRegisterInterop(t *testing.T) {
+	bc := newTestChain(t)
+	defer bc.Close()
+	bc.config.CustomInterops = []string{"Private.Oracle.Get", "Neo.Runtime.Log"}
+
+	get := func(v *vm.VM) error {
+		v.Estack().PushVal(v.Estack().Pop().BigInt().Int64() * 2)
+		return nil
+	}
+	require.Equal(t, ErrInteropNotAllowed, errors.Cause(bc.RegisterInterop("Private.Oracle.Put", get, 1)))
+	require.Equal(t, ErrInteropExists, errors.Cause(bc.RegisterInterop("Neo.Runtime.Log", get, 1)))
+	require.NoError(t, bc.RegisterInterop("Private.Oracle.Get", get, 10))
+	require.Equal(t, ErrInteropExists, errors.Cause(bc.RegisterInterop("Private.Oracle.Get", get, 1)))
+
+	buf := io.NewBufBinWriter()
+	emit.Int(buf.BinWriter, 21)
+	emit.Syscall(buf.BinWriter, "Private.Oracle.Get")
+	require.NoError(t, buf.Err)
+
+	v := bc.GetTestVM(nil)
+	v.LoadScript(buf.Bytes())
+	require.NoError(t, v.Run())
+	require.Equal(t, 1, v.Estack().Len())
+	require.EqualValues(t, 42, v.Estack().Pop().BigInt().Int64())
+	require.Equal(t, toFixed8(10), v.GasConsumed())
+}
+
 func TestClose(t *testing.T) {
 	defer func() {
 		r := recover()
@@ -61,6 +61,23 @@ func toFixed8(n int64) util.Fixed8 {
 	return util.Fixed8(n * interopGasRatio)
 }
 
+// EstimateSystemFee returns system fee that should be attached to the
+// invocation transaction consuming given amount of GAS with given free GAS
+// limit (see ProtocolConfiguration.GetFreeGas). Invocation transaction GAS must
+// be a whole number, so the result is rounded up to 1 GAS. Zero free GAS limit
+// means that invocations are not limited at all (the same way it's treated
+// when processing blocks), so no fee is needed in this case.
+func EstimateSystemFee(consumed, free util.Fixed8) util.Fixed8 {
+	fee := consumed - free
+	if free <= 0 || fee <= 0 {
+		return 0
+	}
+	if fee.FractionalValue() != 0 {
+		fee = util.Fixed8FromInt64(fee.IntegralValue() + 1)
+	}
+	return fee
+}
+
 // getSyscallPrice returns cost of executing syscall with provided id.
 // Is SYSCALL is not found, cost is 1.
 func getSyscallPrice(v *vm.VM, id uint32) util.Fixed8 {
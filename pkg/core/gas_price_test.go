@@ -119,3 +119,20 @@ func checkGas(t *testing.T, expected util.Fixed8, v *vm.VM) {
 	require.NoError(t, err)
 	require.Equal(t, expected, getPrice(v, op, par))
 }
+
+func TestEstimateSystemFee(t *testing.T) {
+	testCases := []struct {
+		consumed, free, fee util.Fixed8
+	}{
+		{util.Fixed8FromFloat(0.5), util.Fixed8FromInt64(10), 0},
+		{util.Fixed8FromInt64(10), util.Fixed8FromInt64(10), 0},
+		{util.Fixed8FromFloat(10.001), util.Fixed8FromInt64(10), util.Fixed8FromInt64(1)},
+		{util.Fixed8FromInt64(12), util.Fixed8FromInt64(10), util.Fixed8FromInt64(2)},
+		{util.Fixed8FromFloat(12.5), util.Fixed8FromInt64(10), util.Fixed8FromInt64(3)},
+		{util.Fixed8FromFloat(0.5), 0, 0},
+		{0, 0, 0},
+	}
+	for _, tc := range testCases {
+		require.Equal(t, tc.fee, EstimateSystemFee(tc.consumed, tc.free), "consumed %s, free %s", tc.consumed, tc.free)
+	}
+}
@@ -30,12 +30,13 @@ type interopContext struct {
 	lowerDao      dao.DAO
 	notifications []state.NotificationEvent
 	log           *zap.Logger
+	custom        []interopedFunction
 }
 
 func newInteropContext(trigger trigger.Type, bc Blockchainer, d dao.DAO, block *block.Block, tx *transaction.Transaction, log *zap.Logger) *interopContext {
 	dao := dao.NewCached(d)
 	nes := make([]state.NotificationEvent, 0)
-	return &interopContext{bc, trigger, block, tx, dao, d, nes, log}
+	return &interopContext{bc, trigger, block, tx, dao, d, nes, log, nil}
 }
 
 // SpawnVM returns a VM with script getter and interop functions set
@@ -55,6 +56,9 @@ func (ic *interopContext) SpawnVM() *vm.VM {
 	if ic.bc != nil && ic.bc.GetConfig().EnableStateRoot {
 		vm.RegisterInteropGetter(ic.getNeoxInterop)
 	}
+	if len(ic.custom) != 0 {
+		vm.RegisterInteropGetter(ic.getCustomInterop)
+	}
 	return vm
 }
 
@@ -86,6 +90,27 @@ func (ic *interopContext) getNeoxInterop(id uint32) *vm.InteropFuncPrice {
 	return ic.getInteropFromSlice(id, neoxInterops)
 }
 
+// getCustomInterop returns matching interop function from the set of
+// functions registered via Blockchain.RegisterInterop for a given id in the
+// current context.
+func (ic *interopContext) getCustomInterop(id uint32) *vm.InteropFuncPrice {
+	return ic.getInteropFromSlice(id, ic.custom)
+}
+
+// isStandardInterop checks whether there is a standard interop function with
+// the given id.
+func isStandardInterop(id uint32) bool {
+	for _, slice := range [][]interopedFunction{systemInterops, neoInterops, neoxInterops} {
+		n := sort.Search(len(slice), func(i int) bool {
+			return slice[i].ID >= id
+		})
+		if n < len(slice) && slice[n].ID == id {
+			return true
+		}
+	}
+	return false
+}
+
 // getInteropFromSlice returns matching interop function from the given slice of
 // interop functions in the current context.
 func (ic *interopContext) getInteropFromSlice(id uint32, slice []interopedFunction) *vm.InteropFuncPrice {
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
 
 	"github.com/ixje/neo-go-legacy/pkg/io"
 )
@@ -47,7 +48,8 @@ func (attr *Attribute) DecodeBinary(br *io.BinReader) {
 	case Description, Remark, Remark1, Remark2, Remark3, Remark4,
 		Remark5, Remark6, Remark7, Remark8, Remark9, Remark10, Remark11,
 		Remark12, Remark13, Remark14, Remark15:
-		datasize = br.ReadVarUint()
+		attr.Data = br.ReadVarBytes(math.MaxUint16)
+		return
 	default:
 		br.Err = fmt.Errorf("failed decoding TX attribute usage: 0x%2x", int(attr.Usage))
 		return
@@ -0,0 +1,38 @@
+//go:build go1.18
+// +build go1.18
+
+package transaction
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/ixje/neo-go-legacy/pkg/internal/testserdes"
+	"github.com/stretchr/testify/require"
+)
+
+func FuzzDecodeBinary(f *testing.F) {
+	for _, raw := range []string{rawClaimTX, rawInvocationTX, rawPublishTX} {
+		b, err := hex.DecodeString(raw)
+		require.NoError(f, err)
+		f.Add(b)
+	}
+	for _, tx := range []*Transaction{NewContractTX(), NewInvocationTX([]byte{0x51}, 1)} {
+		f.Add(tx.Bytes())
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		tx := new(Transaction)
+		if testserdes.DecodeBinary(data, tx) != nil {
+			return
+		}
+
+		// Re-encoding must be stable and produce the same hash.
+		data1 := tx.Bytes()
+		require.NotNil(t, data1)
+		tx2 := new(Transaction)
+		require.NoError(t, testserdes.DecodeBinary(data1, tx2))
+		require.Equal(t, data1, tx2.Bytes())
+		require.Equal(t, tx.Hash(), tx2.Hash())
+	})
+}
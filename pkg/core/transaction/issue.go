@@ -8,6 +8,18 @@ import (
 // This TX has not special attributes.
 type IssueTX struct{}
 
+// NewIssueTX returns a new issue transaction with the given outputs.
+func NewIssueTX(outputs ...Output) *Transaction {
+	return &Transaction{
+		Type:       IssueType,
+		Data:       &IssueTX{},
+		Attributes: []Attribute{},
+		Inputs:     []Input{},
+		Outputs:    append([]Output{}, outputs...),
+		Scripts:    []Witness{},
+	}
+}
+
 // DecodeBinary implements Serializable interface.
 func (tx *IssueTX) DecodeBinary(r *io.BinReader) {
 }
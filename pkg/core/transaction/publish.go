@@ -25,10 +25,10 @@ type PublishTX struct {
 func (tx *PublishTX) DecodeBinary(br *io.BinReader) {
 	tx.Script = br.ReadVarBytes()
 
-	lenParams := br.ReadVarUint()
-	tx.ParamList = make([]smartcontract.ParamType, lenParams)
-	for i := 0; i < int(lenParams); i++ {
-		tx.ParamList[i] = smartcontract.ParamType(br.ReadB())
+	params := br.ReadVarBytes()
+	tx.ParamList = make([]smartcontract.ParamType, len(params))
+	for i := range params {
+		tx.ParamList[i] = smartcontract.ParamType(params[i])
 	}
 
 	tx.ReturnType = smartcontract.ParamType(br.ReadB())
@@ -30,6 +30,18 @@ type RegisterTX struct {
 	Admin util.Uint160
 }
 
+// NewRegisterTX returns a new register transaction for the given asset.
+func NewRegisterTX(reg *RegisterTX) *Transaction {
+	return &Transaction{
+		Type:       RegisterType,
+		Data:       reg,
+		Attributes: []Attribute{},
+		Inputs:     []Input{},
+		Outputs:    []Output{},
+		Scripts:    []Witness{},
+	}
+}
+
 // DecodeBinary implements Serializable interface.
 func (tx *RegisterTX) DecodeBinary(br *io.BinReader) {
 	tx.AssetType = AssetType(br.ReadB())
@@ -2,13 +2,32 @@ package transaction
 
 import (
 	"github.com/ixje/neo-go-legacy/pkg/io"
+	"github.com/ixje/neo-go-legacy/pkg/util"
 )
 
+// ValidatorRegistrationFee is the system fee of StateTX registering
+// a validator (1000 GAS).
+const ValidatorRegistrationFee util.Fixed8 = 1000 * 100000000
+
 // StateTX represents a state transaction.
 type StateTX struct {
 	Descriptors []*StateDescriptor
 }
 
+// NewStateTX returns a new state transaction with the given descriptors.
+func NewStateTX(descs ...*StateDescriptor) *Transaction {
+	return &Transaction{
+		Type: StateType,
+		Data: &StateTX{
+			Descriptors: descs,
+		},
+		Attributes: []Attribute{},
+		Inputs:     []Input{},
+		Outputs:    []Output{},
+		Scripts:    []Witness{},
+	}
+}
+
 // DecodeBinary implements Serializable interface.
 func (tx *StateTX) DecodeBinary(r *io.BinReader) {
 	r.ReadArray(&tx.Descriptors)
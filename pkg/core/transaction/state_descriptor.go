@@ -4,7 +4,9 @@ import (
 	"encoding/hex"
 	"encoding/json"
 
+	"github.com/ixje/neo-go-legacy/pkg/crypto/keys"
 	"github.com/ixje/neo-go-legacy/pkg/io"
+	"github.com/ixje/neo-go-legacy/pkg/util"
 )
 
 // DescStateType represents the type of StateDescriptor.
@@ -24,6 +26,32 @@ type StateDescriptor struct {
 	Field string
 }
 
+// NewValidatorStateDescriptor returns a descriptor that registers the
+// validator with the given key or cancels its registration.
+func NewValidatorStateDescriptor(key *keys.PublicKey, registered bool) *StateDescriptor {
+	var value byte
+	if registered {
+		value = 1
+	}
+	return &StateDescriptor{
+		Type:  Validator,
+		Key:   key.Bytes(),
+		Value: []byte{value},
+		Field: "Registered",
+	}
+}
+
+// NewVotesStateDescriptor returns a descriptor that sets votes of the account
+// with the given script hash, empty list of keys removes all votes.
+func NewVotesStateDescriptor(h util.Uint160, votes keys.PublicKeys) *StateDescriptor {
+	return &StateDescriptor{
+		Type:  Account,
+		Key:   h.BytesBE(),
+		Value: votes.Bytes(),
+		Field: "Votes",
+	}
+}
+
 // DecodeBinary implements Serializable interface.
 func (s *StateDescriptor) DecodeBinary(r *io.BinReader) {
 	s.Type = DescStateType(r.ReadB())
@@ -4,8 +4,11 @@ import (
 	"encoding/hex"
 	"testing"
 
+	"github.com/ixje/neo-go-legacy/pkg/crypto/keys"
 	"github.com/ixje/neo-go-legacy/pkg/internal/testserdes"
+	"github.com/ixje/neo-go-legacy/pkg/util"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestEncodeDecodeState(t *testing.T) {
@@ -34,3 +37,27 @@ func TestEncodeDecodeState(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, rawtx, hex.EncodeToString(data))
 }
+
+func TestNewStateDescriptors(t *testing.T) {
+	key, err := keys.NewPublicKeyFromString("03c089d7122b840a4935234e82e26ae5efd0c2acb627239dc9f207311337b6f2c1")
+	require.NoError(t, err)
+
+	desc := NewValidatorStateDescriptor(key, true)
+	require.Equal(t, Validator, desc.Type)
+	require.Equal(t, key.Bytes(), desc.Key)
+	require.Equal(t, "Registered", desc.Field)
+	require.Equal(t, []byte{1}, desc.Value)
+	require.Equal(t, []byte{0}, NewValidatorStateDescriptor(key, false).Value)
+
+	h := util.Uint160{1, 2, 3}
+	desc = NewVotesStateDescriptor(h, keys.PublicKeys{key})
+	require.Equal(t, Account, desc.Type)
+	require.Equal(t, h.BytesBE(), desc.Key)
+	require.Equal(t, "Votes", desc.Field)
+	var votes keys.PublicKeys
+	require.NoError(t, votes.DecodeBytes(desc.Value))
+	require.Equal(t, keys.PublicKeys{key}, votes)
+
+	desc = NewVotesStateDescriptor(h, nil)
+	require.Equal(t, []byte{0}, desc.Value)
+}
@@ -151,6 +151,19 @@ func TestDecodePublishTX(t *testing.T) {
 	assert.Equal(t, rawPublishTX, hex.EncodeToString(data))
 }
 
+func TestDecodeBadLengths(t *testing.T) {
+	t.Run("PublishParams", func(t *testing.T) {
+		// Publish TX with empty script and 2^32-1 parameters.
+		b := []byte{byte(PublishType), 0, 0, 0xfe, 0xff, 0xff, 0xff, 0xff}
+		require.Error(t, testserdes.DecodeBinary(b, new(Transaction)))
+	})
+	t.Run("AttributeRemark", func(t *testing.T) {
+		// Contract TX with a single remark attribute of 2^32-1 bytes.
+		b := []byte{byte(ContractType), 0, 1, byte(Remark), 0xfe, 0xff, 0xff, 0xff, 0xff}
+		require.Error(t, testserdes.DecodeBinary(b, new(Transaction)))
+	})
+}
+
 func TestEncodingTXWithNoData(t *testing.T) {
 	_, err := testserdes.EncodeBinary(new(Transaction))
 	require.Error(t, err)
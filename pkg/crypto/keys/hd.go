@@ -0,0 +1,151 @@
+package keys
+
+import (
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// HardenedKeyStart is the index of the first hardened child key.
+const HardenedKeyStart uint32 = 0x80000000
+
+// masterKeySecret is the HMAC key used to derive master key from the seed
+// for secp256r1 curve as specified in SLIP-0010.
+var masterKeySecret = []byte("Nist256p1 seed")
+
+// ExtendedKey is a private key along with a chain code which allows to
+// derive child keys in a hierarchical deterministic way. Derivation follows
+// BIP-0032 with SLIP-0010 adjustments for secp256r1 curve used by NEO.
+type ExtendedKey struct {
+	PrivateKey *PrivateKey
+	ChainCode  []byte
+}
+
+// NewMasterKey returns the root extended key for the seed (usually
+// generated from BIP-0039 mnemonic).
+func NewMasterKey(seed []byte) *ExtendedKey {
+	n := elliptic.P256().Params().N
+	i := hmacSHA512(masterKeySecret, seed)
+	for {
+		k := new(big.Int).SetBytes(i[:32])
+		if k.Sign() != 0 && k.Cmp(n) < 0 {
+			return &ExtendedKey{
+				PrivateKey: &PrivateKey{b: i[:32]},
+				ChainCode:  i[32:],
+			}
+		}
+		i = hmacSHA512(masterKeySecret, i)
+	}
+}
+
+// Child returns the child key with the given index, indices starting from
+// HardenedKeyStart correspond to hardened keys.
+func (k *ExtendedKey) Child(index uint32) *ExtendedKey {
+	n := elliptic.P256().Params().N
+	var data []byte
+	if index >= HardenedKeyStart {
+		data = append([]byte{0}, padKey(k.PrivateKey.b)...)
+	} else {
+		data = k.PrivateKey.PublicKey().Bytes()
+	}
+	data = appendUint32(data, index)
+
+	parent := new(big.Int).SetBytes(k.PrivateKey.b)
+	for {
+		i := hmacSHA512(k.ChainCode, data)
+		il := new(big.Int).SetBytes(i[:32])
+		if il.Cmp(n) < 0 {
+			child := il.Add(il, parent)
+			child.Mod(child, n)
+			if child.Sign() != 0 {
+				return &ExtendedKey{
+					PrivateKey: &PrivateKey{b: padKey(child.Bytes())},
+					ChainCode:  i[32:],
+				}
+			}
+		}
+		// The key is invalid, so the next one is tried.
+		data = appendUint32(append([]byte{1}, i[32:]...), index)
+	}
+}
+
+// Derive returns the key for the derivation path relative to this one,
+// e.g. "m/44'/888'/0'/0/0" for the root key.
+func (k *ExtendedKey) Derive(path string) (*ExtendedKey, error) {
+	indices, err := ParseDerivationPath(path)
+	if err != nil {
+		return nil, err
+	}
+	for _, i := range indices {
+		k = k.Child(i)
+	}
+	return k, nil
+}
+
+// ParseDerivationPath converts BIP-0032 derivation path to the list of
+// child indices. Hardened indices are marked with ' or h.
+func ParseDerivationPath(path string) ([]uint32, error) {
+	parts := strings.Split(path, "/")
+	if len(parts) == 0 || parts[0] != "m" {
+		return nil, fmt.Errorf("invalid derivation path %q: must start with m", path)
+	}
+	indices := make([]uint32, 0, len(parts)-1)
+	for _, p := range parts[1:] {
+		var offset uint32
+		if strings.HasSuffix(p, "'") || strings.HasSuffix(p, "h") {
+			p = p[:len(p)-1]
+			offset = HardenedKeyStart
+		}
+		i, err := strconv.ParseUint(p, 10, 32)
+		if err != nil || uint32(i) >= HardenedKeyStart {
+			return nil, fmt.Errorf("invalid derivation path %q: bad index %q", path, p)
+		}
+		indices = append(indices, uint32(i)+offset)
+	}
+	return indices, nil
+}
+
+// FormatDerivationPath converts the list of child indices to the derivation
+// path, it's the opposite of ParseDerivationPath.
+func FormatDerivationPath(indices []uint32) string {
+	var b strings.Builder
+	b.WriteString("m")
+	for _, i := range indices {
+		if i >= HardenedKeyStart {
+			fmt.Fprintf(&b, "/%d'", i-HardenedKeyStart)
+		} else {
+			fmt.Fprintf(&b, "/%d", i)
+		}
+	}
+	return b.String()
+}
+
+// padKey returns 32-byte representation of the private key bytes.
+func padKey(b []byte) []byte {
+	if len(b) >= 32 {
+		return b
+	}
+	res := make([]byte, 32)
+	copy(res[32-len(b):], b)
+	return res
+}
+
+func appendUint32(b []byte, i uint32) []byte {
+	var buf [4]byte
+	binary.BigEndian.PutUint32(buf[:], i)
+	return append(b, buf[:]...)
+}
+
+func hmacSHA512(key, data []byte) []byte {
+	h := hmac.New(sha512.New, key)
+	if _, err := h.Write(data); err != nil {
+		panic(errors.New("can't compute HMAC"))
+	}
+	return h.Sum(nil)
+}
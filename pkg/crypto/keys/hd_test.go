@@ -0,0 +1,47 @@
+package keys
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Test vectors for nist256p1 curve from SLIP-0010.
+func TestExtendedKeyDerive(t *testing.T) {
+	seed, err := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	require.NoError(t, err)
+	master := NewMasterKey(seed)
+
+	testCases := []struct {
+		path       string
+		chainCode  string
+		privateKey string
+	}{
+		{"m", "beeb672fe4621673f722f38529c07392fecaa61015c80c34f29ce8b41b3cb6ea", "612091aaa12e22dd2abef664f8a01a82cae99ad7441b7ef8110424915c268bc2"},
+		{"m/0'", "3460cea53e6a6bb5fb391eeef3237ffd8724bf0a40e94943c98b83825342ee11", "6939694369114c67917a182c59ddb8cafc3004e63ca5d3b84403ba8613debc0c"},
+		// These ones need derivation retry.
+		{"m/28578'", "e94c8ebe30c2250a14713212f6449b20f3329105ea15b652ca5bdfc68f6c65c2", "06f0db126f023755d0b8d86d4591718a5210dd8d024e3e14b6159d63f53aa669"},
+		{"m/28578'/33941", "9e87fe95031f14736774cd82f25fd885065cb7c358c1edf813c72af535e83071", "092154eed4af83e078ff9b84322015aefe5769e31270f62c3f66c33888335f3a"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.path, func(t *testing.T) {
+			k, err := master.Derive(tc.path)
+			require.NoError(t, err)
+			require.Equal(t, tc.chainCode, hex.EncodeToString(k.ChainCode))
+			require.Equal(t, tc.privateKey, hex.EncodeToString(k.PrivateKey.Bytes()))
+		})
+	}
+}
+
+func TestDerivationPath(t *testing.T) {
+	indices, err := ParseDerivationPath("m/44'/888h/0'/0/5")
+	require.NoError(t, err)
+	require.Equal(t, []uint32{HardenedKeyStart + 44, HardenedKeyStart + 888, HardenedKeyStart, 0, 5}, indices)
+	require.Equal(t, "m/44'/888'/0'/0/5", FormatDerivationPath(indices))
+
+	for _, p := range []string{"", "44'/0", "m/", "m/a", "m/2147483648", "m/-1"} {
+		_, err := ParseDerivationPath(p)
+		require.Error(t, err, p)
+	}
+}
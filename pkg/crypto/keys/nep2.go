@@ -43,12 +43,18 @@ func NEP2ScryptParams() ScryptParams {
 // NEP2Encrypt encrypts a the PrivateKey using a given passphrase
 // under the NEP-2 standard.
 func NEP2Encrypt(priv *PrivateKey, passphrase string) (s string, err error) {
+	return NEP2EncryptWithParams(priv, passphrase, NEP2ScryptParams())
+}
+
+// NEP2EncryptWithParams encrypts a the PrivateKey using a given passphrase
+// under the NEP-2 standard with the given scrypt parameters.
+func NEP2EncryptWithParams(priv *PrivateKey, passphrase string, params ScryptParams) (s string, err error) {
 	address := priv.Address()
 
 	addrHash := hash.Checksum([]byte(address))
 	// Normalize the passphrase according to the NFC standard.
 	phraseNorm := norm.NFC.Bytes([]byte(passphrase))
-	derivedKey, err := scrypt.Key(phraseNorm, addrHash, n, r, p, keyLen)
+	derivedKey, err := scrypt.Key(phraseNorm, addrHash, params.N, params.R, params.P, keyLen)
 	if err != nil {
 		return s, err
 	}
@@ -78,6 +84,12 @@ func NEP2Encrypt(priv *PrivateKey, passphrase string) (s string, err error) {
 // NEP2Decrypt decrypts an encrypted key using a given passphrase
 // under the NEP-2 standard.
 func NEP2Decrypt(key, passphrase string) (*PrivateKey, error) {
+	return NEP2DecryptWithParams(key, passphrase, NEP2ScryptParams())
+}
+
+// NEP2DecryptWithParams decrypts an encrypted key using a given passphrase
+// under the NEP-2 standard with the given scrypt parameters.
+func NEP2DecryptWithParams(key, passphrase string, params ScryptParams) (*PrivateKey, error) {
 	b, err := base58.CheckDecode(key)
 	if err != nil {
 		return nil, err
@@ -89,7 +101,7 @@ func NEP2Decrypt(key, passphrase string) (*PrivateKey, error) {
 	addrHash := b[3:7]
 	// Normalize the passphrase according to the NFC standard.
 	phraseNorm := norm.NFC.Bytes([]byte(passphrase))
-	derivedKey, err := scrypt.Key(phraseNorm, addrHash, n, r, p, keyLen)
+	derivedKey, err := scrypt.Key(phraseNorm, addrHash, params.N, params.R, params.P, keyLen)
 	if err != nil {
 		return nil, err
 	}
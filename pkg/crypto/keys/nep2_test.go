@@ -44,6 +44,26 @@ func TestNEP2Decrypt(t *testing.T) {
 	}
 }
 
+func TestNEP2CustomScrypt(t *testing.T) {
+	params := ScryptParams{N: 2, R: 1, P: 1}
+	priv, err := NewPrivateKey()
+	assert.NoError(t, err)
+
+	enc, err := NEP2EncryptWithParams(priv, "qwerty", params)
+	assert.NoError(t, err)
+	dec, err := NEP2DecryptWithParams(enc, "qwerty", params)
+	assert.NoError(t, err)
+	assert.Equal(t, priv.Bytes(), dec.Bytes())
+
+	// Other parameters give other key.
+	_, err = NEP2DecryptWithParams(enc, "qwerty", ScryptParams{N: 4, R: 1, P: 1})
+	assert.Error(t, err)
+
+	// Invalid parameters.
+	_, err = NEP2EncryptWithParams(priv, "qwerty", ScryptParams{N: 3, R: 1, P: 1})
+	assert.Error(t, err)
+}
+
 func TestNEP2DecryptErrors(t *testing.T) {
 	p := "qwerty"
 
@@ -99,6 +99,21 @@ func TestDecodeBytes(t *testing.T) {
 	t.Run("uncompressed", func(t *testing.T) { testBytesFunction(t, pubKey.UncompressedBytes) })
 }
 
+func TestPublicKeys_Bytes(t *testing.T) {
+	pubs := make(PublicKeys, 3)
+	for i := range pubs {
+		priv, err := NewPrivateKey()
+		require.NoError(t, err)
+		pubs[i] = priv.PublicKey()
+	}
+	var actual PublicKeys
+	require.NoError(t, actual.DecodeBytes(pubs.Bytes()))
+	require.Equal(t, pubs, actual)
+
+	require.NoError(t, actual.DecodeBytes(PublicKeys{}.Bytes()))
+	require.Equal(t, 0, len(actual))
+}
+
 func TestDecodeBytesBadInfinity(t *testing.T) {
 	decodedPubKey := &PublicKey{}
 	err := decodedPubKey.DecodeBytes([]byte{0, 0, 0})
@@ -0,0 +1,196 @@
+/*
+Package neotest provides helpers for smart contract unit tests.
+
+It compiles contracts with the Go contract compiler, deploys them into an
+in-memory blockchain running with the unit test network configuration and
+allows to invoke them, to generate blocks and to check invocation results,
+contract storage and notifications. Every helper fails the test it's created
+for on any unexpected error, so tests don't need to check errors themselves:
+
+	c := neotest.NewChain(t)
+	defer c.Close()
+	ctr := c.Deploy("./mycontract")
+	res := ctr.Invoke("put", "key", "value")
+	res.AssertHalt()
+	res.AssertStack(true)
+	ctr.AssertStorage("key", "value")
+*/
+package neotest
+
+import (
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/ixje/neo-go-legacy/pkg/config"
+	"github.com/ixje/neo-go-legacy/pkg/core"
+	"github.com/ixje/neo-go-legacy/pkg/core/block"
+	"github.com/ixje/neo-go-legacy/pkg/core/storage"
+	"github.com/ixje/neo-go-legacy/pkg/core/transaction"
+	"github.com/ixje/neo-go-legacy/pkg/crypto/hash"
+	"github.com/ixje/neo-go-legacy/pkg/crypto/keys"
+	"github.com/ixje/neo-go-legacy/pkg/smartcontract"
+	"github.com/ixje/neo-go-legacy/pkg/vm/opcode"
+	"github.com/ixje/neo-go-legacy/pkg/wallet"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+// validatorKeys are WIFs of standby validators of the unit test network,
+// they're used to sign blocks.
+var validatorKeys = []string{
+	"KxyjQ8eUa4FHt3Gvioyt1Wz29cTUrE4eTqX3yFSk1YFCsPL8uNsY",
+	"KzfPUYDC9n2yf4fK5ro4C8KMcdeXtFuEnStycbZgX3GomiUsvX6W",
+	"KzgWE3u3EDp13XPXXuTKZxeJ3Gi8Bsm8f9ijY3ZsCKKRvZUo1Cdn",
+	"L2oEXKRAAMiPEZukwR5ho2S6SMeQLhcK9mF71ZnF7GvT8dU4Kkgz",
+}
+
+// Chain is an in-memory blockchain for contract tests.
+type Chain struct {
+	t          testing.TB
+	bc         *core.Blockchain
+	validators []*keys.PrivateKey
+	// nonce makes transactions with the same script unique.
+	nonce uint32
+}
+
+// ProtocolConfiguration returns the configuration of the unit test network
+// (the same as config/protocol.unit_testnet.yml has) used by the test chain.
+func ProtocolConfiguration() config.ProtocolConfiguration {
+	return config.ProtocolConfiguration{
+		Magic:           56753,
+		AddressVersion:  23,
+		SecondsPerBlock: 15,
+		EnableStateRoot: true,
+		MemPoolSize:     50000,
+		StandbyValidators: []string{
+			"02b3622bf4017bdfe317c58aed5f4c753f206b7db896046fa7d774bbc4bf7f8dc2",
+			"02103a7f7dd016558597f7960d27c516a4394fd968b9e65155eb4b013e4040406e",
+			"03d90c07df63e690ce77912e10ab51acc944b66860237b608c4f8f8309e71ee699",
+			"02a7bc55fe8684e0119768d104ba30795bdcc86619e864add26156723ed185cd62",
+		},
+		SystemFee: config.SystemFee{
+			EnrollmentTransaction: 1000,
+			IssueTransaction:      500,
+			PublishTransaction:    500,
+			RegisterTransaction:   10000,
+		},
+		VerifyBlocks:       true,
+		VerifyTransactions: true,
+	}
+}
+
+// NewChain creates a new blockchain with the genesis block only. Close
+// should be called when the chain is no longer needed.
+func NewChain(t testing.TB) *Chain {
+	bc, err := core.NewBlockchain(storage.NewMemoryStore(), ProtocolConfiguration(), zaptest.NewLogger(t))
+	require.NoError(t, err)
+	go bc.Run()
+
+	c := &Chain{
+		t:          t,
+		bc:         bc,
+		validators: make([]*keys.PrivateKey, len(validatorKeys)),
+	}
+	for i, wif := range validatorKeys {
+		c.validators[i], err = keys.NewPrivateKeyFromWIF(wif)
+		require.NoError(t, err)
+	}
+	return c
+}
+
+// Close stops the chain.
+func (c *Chain) Close() {
+	c.bc.Close()
+}
+
+// Blockchain returns the underlying blockchain.
+func (c *Chain) Blockchain() *core.Blockchain {
+	return c.bc
+}
+
+// Height returns the index of the last block.
+func (c *Chain) Height() uint32 {
+	return c.bc.BlockHeight()
+}
+
+// NewAccount returns a new account with a random key which can be used to
+// sign transactions.
+func (c *Chain) NewAccount() *wallet.Account {
+	acc, err := wallet.NewAccount()
+	require.NoError(c.t, err)
+	return acc
+}
+
+// AddBlock creates the next block with the given transactions, signs it by
+// standby validators and adds it to the chain.
+func (c *Chain) AddBlock(txs ...*transaction.Transaction) *block.Block {
+	b := c.newBlock(txs...)
+	require.NoError(c.t, c.bc.AddBlock(b))
+	return b
+}
+
+// AdvanceBlocks adds n empty blocks to the chain.
+func (c *Chain) AdvanceBlocks(n int) {
+	for i := 0; i < n; i++ {
+		c.AddBlock()
+	}
+}
+
+func (c *Chain) newBlock(txs ...*transaction.Transaction) *block.Block {
+	pubs := make(keys.PublicKeys, len(c.validators))
+	for i := range c.validators {
+		pubs[i] = c.validators[i].PublicKey()
+	}
+	n := len(pubs)
+	script, err := smartcontract.CreateMultiSigRedeemScript(n-(n-1)/3, pubs)
+	require.NoError(c.t, err)
+
+	prev, err := c.bc.GetBlock(c.bc.CurrentBlockHash())
+	require.NoError(c.t, err)
+	index := prev.Index + 1
+	timestamp := uint32(time.Now().UTC().Unix())
+	if timestamp <= prev.Timestamp {
+		timestamp = prev.Timestamp + 1
+	}
+	b := &block.Block{
+		Base: block.Base{
+			Version:       0,
+			PrevHash:      prev.Hash(),
+			Timestamp:     timestamp,
+			Index:         index,
+			ConsensusData: uint64(index),
+			NextConsensus: hash.Hash160(script),
+			Script:        transaction.Witness{VerificationScript: script},
+		},
+		Transactions: append([]*transaction.Transaction{c.newMinerTX()}, txs...),
+	}
+	require.NoError(c.t, b.RebuildMerkleRoot())
+
+	data := b.GetHashableData()
+	for _, priv := range c.validators {
+		b.Script.InvocationScript = append(b.Script.InvocationScript, byte(opcode.PUSHBYTES64))
+		b.Script.InvocationScript = append(b.Script.InvocationScript, priv.Sign(data)...)
+	}
+	return b
+}
+
+func (c *Chain) newMinerTX() *transaction.Transaction {
+	return &transaction.Transaction{
+		Type: transaction.MinerType,
+		Data: &transaction.MinerTX{Nonce: c.nextNonce()},
+	}
+}
+
+// nextNonce returns a number which wasn't used for transactions before.
+func (c *Chain) nextNonce() uint32 {
+	c.nonce++
+	return c.nonce
+}
+
+// nonceAttribute returns the attribute making the transaction unique.
+func (c *Chain) nonceAttribute() transaction.Attribute {
+	data := make([]byte, 4)
+	binary.LittleEndian.PutUint32(data, c.nextNonce())
+	return transaction.Attribute{Usage: transaction.Remark, Data: data}
+}
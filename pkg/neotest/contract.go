@@ -0,0 +1,239 @@
+package neotest
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/ixje/neo-go-legacy/pkg/compiler"
+	"github.com/ixje/neo-go-legacy/pkg/core/state"
+	"github.com/ixje/neo-go-legacy/pkg/core/transaction"
+	"github.com/ixje/neo-go-legacy/pkg/crypto/hash"
+	"github.com/ixje/neo-go-legacy/pkg/io"
+	"github.com/ixje/neo-go-legacy/pkg/rpc/request"
+	"github.com/ixje/neo-go-legacy/pkg/smartcontract"
+	"github.com/ixje/neo-go-legacy/pkg/util"
+	"github.com/ixje/neo-go-legacy/pkg/vm"
+	"github.com/ixje/neo-go-legacy/pkg/vm/emit"
+	"github.com/ixje/neo-go-legacy/pkg/vm/opcode"
+	"github.com/ixje/neo-go-legacy/pkg/wallet"
+	"github.com/stretchr/testify/require"
+)
+
+// Contract is a contract deployed to the test chain.
+type Contract struct {
+	chain *Chain
+	// Hash is the script hash of the contract.
+	Hash util.Uint160
+	// Script is the contract bytecode.
+	Script []byte
+	// DebugInfo is the debug info generated by the compiler, it's nil for
+	// contracts deployed with DeployScript.
+	DebugInfo *compiler.DebugInfo
+	// signers are accounts signing invocations.
+	signers []*wallet.Account
+}
+
+// DefaultContractDetails returns details contracts are deployed with by
+// default: the entry point accepts operation and arguments and the contract
+// can use storage and dynamic invocations.
+func DefaultContractDetails() *smartcontract.ContractDetails {
+	return &smartcontract.ContractDetails{
+		ProjectName:          "test",
+		HasStorage:           true,
+		HasDynamicInvocation: true,
+		ReturnType:           smartcontract.ByteArrayType,
+		Parameters:           []smartcontract.ParamType{smartcontract.StringType, smartcontract.ArrayType},
+	}
+}
+
+// Deploy compiles the contract from the given Go file or package directory
+// and deploys it with default details in a new block.
+func (c *Chain) Deploy(path string) *Contract {
+	return c.DeployWithDetails(path, DefaultContractDetails())
+}
+
+// DeployWithDetails compiles the contract from the given Go file or package
+// directory and deploys it with the given details in a new block.
+func (c *Chain) DeployWithDetails(path string, cd *smartcontract.ContractDetails) *Contract {
+	script, di, err := compiler.CompilePath(path)
+	require.NoError(c.t, err, "failed to compile %s", path)
+	ctr := c.DeployScript(script, cd)
+	ctr.DebugInfo = di
+	return ctr
+}
+
+// DeployScript deploys the contract bytecode with the given details in a
+// new block.
+func (c *Chain) DeployScript(script []byte, cd *smartcontract.ContractDetails) *Contract {
+	deploy, err := request.CreateDeploymentScript(script, cd)
+	require.NoError(c.t, err)
+	tx := transaction.NewInvocationTX(deploy, 0)
+	tx.Attributes = append(tx.Attributes, c.nonceAttribute())
+	c.AddBlock(tx)
+	c.getResult(tx).AssertHalt()
+
+	h := hash.Hash160(script)
+	require.NotNil(c.t, c.bc.GetContractState(h), "contract wasn't deployed")
+	return &Contract{
+		chain:  c,
+		Hash:   h,
+		Script: script,
+	}
+}
+
+// WithSigners returns the contract which invocations are signed by the
+// given accounts, so that they pass runtime.CheckWitness checks.
+func (ctr *Contract) WithSigners(accs ...*wallet.Account) *Contract {
+	res := *ctr
+	res.signers = accs
+	return &res
+}
+
+// Invoke sends the transaction invoking the contract with the given
+// operation and arguments in a new block and returns its result.
+func (ctr *Contract) Invoke(operation string, args ...interface{}) *Result {
+	tx := ctr.newTx(operation, args)
+	ctr.chain.AddBlock(tx)
+	return ctr.chain.getResult(tx)
+}
+
+// Call runs the contract with the given operation and arguments without
+// saving any changes made to the chain (like testinvoke RPC call does).
+// Notifications are not available in the result.
+func (ctr *Contract) Call(operation string, args ...interface{}) *Result {
+	tx := ctr.newTx(operation, args)
+	v := ctr.chain.bc.GetTestVM(tx)
+	v.LoadScript(tx.Data.(*transaction.InvocationTX).Script)
+	_ = v.Run()
+	return &Result{
+		t:           ctr.chain.t,
+		Tx:          tx,
+		VMState:     v.State(),
+		GasConsumed: v.GasConsumed(),
+		Stack:       v.Estack().ToContractParameters(),
+	}
+}
+
+// Storage returns the value stored by the contract under the key which can
+// be a string or []byte, nil is returned if there is no such item.
+func (ctr *Contract) Storage(key interface{}) []byte {
+	si := ctr.chain.bc.GetStorageItem(ctr.Hash, toBytes(ctr.chain.t, key))
+	if si == nil {
+		return nil
+	}
+	return si.Value
+}
+
+// AssertStorage checks that the contract has the value stored under the key.
+// Values are compared the same way VM compares them, so that integers and
+// booleans can be used as expected values. Nil value means that there
+// should be no such item.
+func (ctr *Contract) AssertStorage(key interface{}, value interface{}) {
+	actual := ctr.Storage(key)
+	if value == nil {
+		require.Nil(ctr.chain.t, actual, "storage item %q exists", key)
+		return
+	}
+	require.NotNil(ctr.chain.t, actual, "no storage item %q", key)
+	expected := toParameter(ctr.chain.t, value)
+	require.True(ctr.chain.t, equalParameters(expected, smartcontract.Parameter{
+		Type:  smartcontract.ByteArrayType,
+		Value: actual,
+	}), "storage item %q: expected %v, got %x", key, value, actual)
+}
+
+// newTx creates the transaction invoking the contract.
+func (ctr *Contract) newTx(operation string, args []interface{}) *transaction.Transaction {
+	t := ctr.chain.t
+	w := io.NewBufBinWriter()
+	emitArray(t, w.BinWriter, args)
+	emit.String(w.BinWriter, operation)
+	emit.AppCall(w.BinWriter, ctr.Hash, false)
+	require.NoError(t, w.Err)
+
+	tx := transaction.NewInvocationTX(w.Bytes(), 0)
+	tx.Attributes = append(tx.Attributes, ctr.chain.nonceAttribute())
+	signers := make([]*wallet.Account, len(ctr.signers))
+	copy(signers, ctr.signers)
+	// Witnesses are verified in the order of script hashes.
+	sort.Slice(signers, func(i, j int) bool {
+		return signers[i].Contract.ScriptHash().Less(signers[j].Contract.ScriptHash())
+	})
+	for _, acc := range signers {
+		tx.Attributes = append(tx.Attributes, transaction.Attribute{
+			Usage: transaction.Script,
+			Data:  acc.Contract.ScriptHash().BytesBE(),
+		})
+	}
+	for _, acc := range signers {
+		require.NoError(t, acc.SignTx(tx))
+	}
+	return tx
+}
+
+// getResult returns the result of the transaction from the last block.
+func (c *Chain) getResult(tx *transaction.Transaction) *Result {
+	aer, err := c.bc.GetAppExecResult(tx.Hash())
+	require.NoError(c.t, err)
+	return newResult(c.t, tx, aer)
+}
+
+// emitArray emits arguments packed into an array.
+func emitArray(t require.TestingT, w *io.BinWriter, args []interface{}) {
+	for i := len(args) - 1; i >= 0; i-- {
+		emitArg(t, w, args[i])
+	}
+	emit.Int(w, int64(len(args)))
+	emit.Opcode(w, opcode.PACK)
+}
+
+// emitArg emits the argument of one of supported Go types.
+func emitArg(t require.TestingT, w *io.BinWriter, arg interface{}) {
+	switch a := arg.(type) {
+	case []interface{}:
+		emitArray(t, w, a)
+	case *big.Int:
+		emit.Bytes(w, emit.IntToBytes(a))
+	case int, int64, bool, string, []byte, util.Uint160, util.Uint256:
+		p := toParameter(t, a)
+		switch p.Type {
+		case smartcontract.IntegerType:
+			emit.Int(w, p.Value.(int64))
+		case smartcontract.BoolType:
+			emit.Bool(w, p.Value.(bool))
+		default:
+			emit.Bytes(w, p.Value.([]byte))
+		}
+	default:
+		require.FailNow(t, fmt.Sprintf("unsupported argument type %T", arg))
+	}
+}
+
+// toBytes converts string or []byte key to bytes.
+func toBytes(t require.TestingT, key interface{}) []byte {
+	switch k := key.(type) {
+	case string:
+		return []byte(k)
+	case []byte:
+		return k
+	case util.Uint160:
+		return k.BytesBE()
+	}
+	require.FailNow(t, fmt.Sprintf("unsupported key type %T", key))
+	return nil
+}
+
+// eventName returns the name of the notification (its first item) or an
+// empty string.
+func eventName(ev state.NotificationEvent) string {
+	p := ev.Item.ToContractParameter(make(map[vm.StackItem]bool))
+	if p.Type != smartcontract.ArrayType {
+		return ""
+	}
+	ps := p.Value.([]smartcontract.Parameter)
+	if len(ps) == 0 || ps[0].Type != smartcontract.ByteArrayType {
+		return ""
+	}
+	return string(ps[0].Value.([]byte))
+}
@@ -0,0 +1,66 @@
+package neotest
+
+import (
+	"testing"
+
+	"github.com/ixje/neo-go-legacy/pkg/smartcontract"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContract(t *testing.T) {
+	c := NewChain(t)
+	defer c.Close()
+
+	ctr := c.Deploy("testdata/counter.go")
+	require.NotNil(t, ctr.DebugInfo)
+	require.Equal(t, uint32(1), c.Height())
+
+	owner := c.NewAccount()
+	ownerHash := owner.Contract.ScriptHash()
+	res := ctr.Invoke("init", ownerHash)
+	res.AssertStack(true)
+	ctr.AssertStorage("owner", ownerHash)
+	ctr.AssertStorage("counter", nil)
+
+	t.Run("no witness", func(t *testing.T) {
+		res := ctr.Invoke("inc", 1)
+		res.AssertStack(false)
+		require.Equal(t, 0, res.NotifyCount("inc"))
+	})
+	t.Run("wrong witness", func(t *testing.T) {
+		ctr.WithSigners(c.NewAccount()).Invoke("inc", 1).AssertStack(false)
+	})
+	t.Run("signed", func(t *testing.T) {
+		signed := ctr.WithSigners(c.NewAccount(), owner)
+		res := signed.Invoke("inc", 5)
+		res.AssertStack(5)
+		res.AssertNotify("inc", ownerHash, 5)
+		require.Equal(t, 1, res.NotifyCount("inc"))
+		ctr.AssertStorage("counter", 5)
+
+		res = signed.Call("inc", 2)
+		res.AssertStack(7)
+		ctr.AssertStorage("counter", 5)
+		ctr.Call("get").AssertStack(5)
+	})
+
+	height := c.Height()
+	c.AdvanceBlocks(3)
+	require.Equal(t, height+3, c.Height())
+	// Invocation is processed before its block is stored.
+	ctr.Invoke("height").AssertStack(int64(height + 3))
+
+	ctr.Call("list", "a").AssertStack([]interface{}{"a", []byte("b"), 3})
+	ctr.Call("list", []interface{}{1, true}).AssertStack(smartcontract.Parameter{
+		Type: smartcontract.ArrayType,
+		Value: []smartcontract.Parameter{
+			{Type: smartcontract.ArrayType, Value: []smartcontract.Parameter{
+				{Type: smartcontract.IntegerType, Value: int64(1)},
+				{Type: smartcontract.BoolType, Value: true},
+			}},
+			{Type: smartcontract.ByteArrayType, Value: []byte("b")},
+			{Type: smartcontract.IntegerType, Value: int64(3)},
+		},
+	})
+	ctr.Invoke("unknown").AssertFault()
+}
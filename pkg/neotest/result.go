@@ -0,0 +1,190 @@
+package neotest
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/ixje/neo-go-legacy/pkg/core/state"
+	"github.com/ixje/neo-go-legacy/pkg/core/transaction"
+	"github.com/ixje/neo-go-legacy/pkg/smartcontract"
+	"github.com/ixje/neo-go-legacy/pkg/util"
+	"github.com/ixje/neo-go-legacy/pkg/vm"
+	"github.com/ixje/neo-go-legacy/pkg/vm/emit"
+	"github.com/stretchr/testify/require"
+)
+
+// Result is the result of the contract invocation.
+type Result struct {
+	t testing.TB
+	// Tx is the invocation transaction.
+	Tx *transaction.Transaction
+	// VMState is the state VM finished in, "HALT" or "FAULT".
+	VMState     string
+	GasConsumed util.Fixed8
+	// Stack is the resulting evaluation stack, the top item is the first.
+	Stack []smartcontract.Parameter
+	// Events are notifications sent during the invocation.
+	Events []state.NotificationEvent
+}
+
+func newResult(t testing.TB, tx *transaction.Transaction, aer *state.AppExecResult) *Result {
+	return &Result{
+		t:           t,
+		Tx:          tx,
+		VMState:     aer.VMState,
+		GasConsumed: aer.GasConsumed,
+		Stack:       aer.Stack,
+		Events:      aer.Events,
+	}
+}
+
+// AssertHalt checks that the invocation succeeded.
+func (r *Result) AssertHalt() {
+	require.Equal(r.t, "HALT", r.VMState, "invocation failed")
+}
+
+// AssertFault checks that the invocation failed.
+func (r *Result) AssertFault() {
+	require.Equal(r.t, "FAULT", r.VMState, "invocation succeeded")
+}
+
+// AssertStack checks that the invocation succeeded and the resulting stack
+// contains the expected items starting from the top. Go values of int,
+// int64, *big.Int, bool, string, []byte, util.Uint160, util.Uint256 types
+// and []interface{} arrays of them are compared with stack items the same
+// way VM compares them, smartcontract.Parameter can be used for other items.
+func (r *Result) AssertStack(expected ...interface{}) {
+	r.AssertHalt()
+	require.Equal(r.t, len(expected), len(r.Stack), "unexpected stack length")
+	for i := range expected {
+		p := toParameter(r.t, expected[i])
+		require.True(r.t, equalParameters(p, r.Stack[i]),
+			"stack item %d: expected %v, got %v", i, formatParameter(p), formatParameter(r.Stack[i]))
+	}
+}
+
+// AssertNotify checks that the contract invoked sent the notification with
+// the given name and arguments. Arguments are compared the same way
+// AssertStack compares items.
+func (r *Result) AssertNotify(name string, args ...interface{}) {
+	expected := toParameter(r.t, append([]interface{}{name}, args...))
+	var sent []string
+	for _, ev := range r.Events {
+		p := ev.Item.ToContractParameter(make(map[vm.StackItem]bool))
+		if equalParameters(expected, p) {
+			return
+		}
+		if eventName(ev) == name {
+			sent = append(sent, formatParameter(p))
+		}
+	}
+	require.FailNow(r.t, fmt.Sprintf("notification %s wasn't sent", formatParameter(expected)),
+		"notifications with the same name: %v", sent)
+}
+
+// NotifyCount returns the number of notifications with the given name.
+func (r *Result) NotifyCount(name string) int {
+	var n int
+	for _, ev := range r.Events {
+		if eventName(ev) == name {
+			n++
+		}
+	}
+	return n
+}
+
+// toParameter converts Go value to the parameter.
+func toParameter(t require.TestingT, v interface{}) smartcontract.Parameter {
+	switch v := v.(type) {
+	case smartcontract.Parameter:
+		return v
+	case int:
+		return smartcontract.Parameter{Type: smartcontract.IntegerType, Value: int64(v)}
+	case int64:
+		return smartcontract.Parameter{Type: smartcontract.IntegerType, Value: v}
+	case *big.Int:
+		return smartcontract.Parameter{Type: smartcontract.ByteArrayType, Value: emit.IntToBytes(v)}
+	case bool:
+		return smartcontract.Parameter{Type: smartcontract.BoolType, Value: v}
+	case string:
+		return smartcontract.Parameter{Type: smartcontract.ByteArrayType, Value: []byte(v)}
+	case []byte:
+		return smartcontract.Parameter{Type: smartcontract.ByteArrayType, Value: v}
+	case util.Uint160:
+		return smartcontract.Parameter{Type: smartcontract.ByteArrayType, Value: v.BytesBE()}
+	case util.Uint256:
+		return smartcontract.Parameter{Type: smartcontract.ByteArrayType, Value: v.BytesBE()}
+	case []interface{}:
+		ps := make([]smartcontract.Parameter, len(v))
+		for i := range v {
+			ps[i] = toParameter(t, v[i])
+		}
+		return smartcontract.Parameter{Type: smartcontract.ArrayType, Value: ps}
+	}
+	require.FailNow(t, fmt.Sprintf("unsupported value type %T", v))
+	return smartcontract.Parameter{}
+}
+
+// equalParameters compares parameters the same way VM compares stack items:
+// arrays are compared element by element and other items as byte arrays.
+func equalParameters(a, b smartcontract.Parameter) bool {
+	if a.Type == smartcontract.ArrayType || b.Type == smartcontract.ArrayType {
+		if a.Type != b.Type {
+			return false
+		}
+		as, bs := a.Value.([]smartcontract.Parameter), b.Value.([]smartcontract.Parameter)
+		if len(as) != len(bs) {
+			return false
+		}
+		for i := range as {
+			if !equalParameters(as[i], bs[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	ab, aok := parameterBytes(a)
+	bb, bok := parameterBytes(b)
+	if !aok || !bok {
+		return reflect.DeepEqual(a, b)
+	}
+	return bytes.Equal(ab, bb)
+}
+
+// parameterBytes returns the byte representation of the parameter VM uses
+// for the comparison.
+func parameterBytes(p smartcontract.Parameter) ([]byte, bool) {
+	switch p.Type {
+	case smartcontract.IntegerType:
+		return emit.IntToBytes(big.NewInt(p.Value.(int64))), true
+	case smartcontract.BoolType:
+		if p.Value.(bool) {
+			return []byte{1}, true
+		}
+		return []byte{}, true
+	case smartcontract.ByteArrayType:
+		return p.Value.([]byte), true
+	}
+	return nil, false
+}
+
+func formatParameter(p smartcontract.Parameter) string {
+	switch p.Type {
+	case smartcontract.ArrayType:
+		ps := p.Value.([]smartcontract.Parameter)
+		s := "["
+		for i := range ps {
+			if i != 0 {
+				s += ", "
+			}
+			s += formatParameter(ps[i])
+		}
+		return s + "]"
+	case smartcontract.ByteArrayType:
+		return fmt.Sprintf("%x", p.Value)
+	}
+	return fmt.Sprintf("%v", p.Value)
+}
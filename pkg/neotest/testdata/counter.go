@@ -0,0 +1,37 @@
+package counter
+
+import (
+	"github.com/ixje/neo-go-legacy/pkg/interop/blockchain"
+	"github.com/ixje/neo-go-legacy/pkg/interop/runtime"
+	"github.com/ixje/neo-go-legacy/pkg/interop/storage"
+)
+
+// Main is a counter which can be increased by its owner.
+func Main(op string, args []interface{}) interface{} {
+	ctx := storage.GetContext()
+	switch op {
+	case "init":
+		owner := args[0].([]byte)
+		if storage.Get(ctx, "owner") != nil {
+			return false
+		}
+		storage.Put(ctx, "owner", owner)
+		return true
+	case "inc":
+		owner := storage.Get(ctx, "owner").([]byte)
+		if !runtime.CheckWitness(owner) {
+			return false
+		}
+		n := storage.Get(ctx, "counter").(int) + args[0].(int)
+		storage.Put(ctx, "counter", n)
+		runtime.Notify("inc", owner, n)
+		return n
+	case "get":
+		return storage.Get(ctx, "counter").(int)
+	case "height":
+		return blockchain.GetHeight()
+	case "list":
+		return []interface{}{args[0], []byte("b"), 3}
+	}
+	panic("unknown operation")
+}
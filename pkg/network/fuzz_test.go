@@ -0,0 +1,41 @@
+//go:build go1.18
+// +build go1.18
+
+package network
+
+import (
+	"testing"
+
+	"github.com/ixje/neo-go-legacy/pkg/config"
+	"github.com/ixje/neo-go-legacy/pkg/core/transaction"
+	"github.com/ixje/neo-go-legacy/pkg/io"
+	"github.com/ixje/neo-go-legacy/pkg/network/payload"
+	"github.com/ixje/neo-go-legacy/pkg/util"
+	"github.com/stretchr/testify/require"
+)
+
+func FuzzMessageDecode(f *testing.F) {
+	for _, m := range []*Message{
+		NewMessage(config.ModeUnitTestNet, CMDVerack, nil),
+		NewMessage(config.ModeUnitTestNet, CMDPing, payload.NewPing(10, 42)),
+		NewMessage(config.ModeUnitTestNet, CMDInv, payload.NewInventory(payload.TXType, []util.Uint256{{1, 2, 3}})),
+		NewMessage(config.ModeUnitTestNet, CMDGetBlocks, payload.NewGetBlocks([]util.Uint256{{4, 5, 6}}, util.Uint256{})),
+		NewMessage(config.ModeUnitTestNet, CMDTX, transaction.NewContractTX()),
+	} {
+		data, err := m.Bytes()
+		require.NoError(f, err)
+		f.Add(data)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		m := new(Message)
+		r := io.NewBinReaderFromBuf(data)
+		if err := m.Decode(r); err != nil || m.Payload == nil {
+			return
+		}
+
+		// Decoded message can always be encoded back.
+		_, err := m.Bytes()
+		require.NoError(t, err)
+	})
+}
@@ -19,10 +19,14 @@ const (
 	// The minimum size of a valid message.
 	minMessageSize = 24
 	cmdSize        = 12
+
+	// PayloadMaxSize is the maximum payload size in bytes.
+	PayloadMaxSize = 0x02000000
 )
 
 var (
 	errChecksumMismatch = errors.New("checksum mismatch")
+	errPayloadTooBig    = errors.New("payload is too big")
 )
 
 // Message is the complete message send between nodes.
@@ -170,6 +174,9 @@ func (m *Message) Decode(br *io.BinReader) error {
 	if m.Length == 0 {
 		return nil
 	}
+	if m.Length > PayloadMaxSize {
+		return errPayloadTooBig
+	}
 	return m.decodePayload(br)
 }
 
@@ -1 +1,23 @@
 package network
+
+import (
+	"testing"
+
+	"github.com/ixje/neo-go-legacy/pkg/config"
+	"github.com/ixje/neo-go-legacy/pkg/io"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessageDecodeBigPayload(t *testing.T) {
+	w := io.NewBufBinWriter()
+	w.WriteU32LE(uint32(config.ModeUnitTestNet))
+	cmd := cmdToByteArray(CMDTX)
+	w.WriteBytes(cmd[:])
+	w.WriteU32LE(PayloadMaxSize + 1)
+	w.WriteU32LE(0)
+	require.NoError(t, w.Err)
+
+	m := new(Message)
+	err := m.Decode(io.NewBinReaderFromBuf(w.Bytes()))
+	require.Equal(t, errPayloadTooBig, err)
+}
@@ -195,6 +195,7 @@ func (s *Server) Shutdown() {
 		p.Disconnect(errServerShutdown)
 	}
 	s.bQueue.discard()
+	s.consensus.Shutdown()
 	close(s.quit)
 }
 
@@ -49,6 +49,16 @@ type Options struct {
 	// you can override it here to use NeoScanServer for example.
 	Balancer request.BalanceGetter
 
+	// CoinSelector chooses unspents to spend in the default Balancer
+	// implementation, request.SmallestFirst is used if it's not set.
+	CoinSelector request.CoinSelector
+
+	// FreeGas is the amount of GAS every invocation can consume for free on
+	// the network. It's only used to estimate system fee with nodes that
+	// don't support `estimategas` RPC call (like C# node), estimation fails
+	// with them if it's not set.
+	FreeGas util.Fixed8
+
 	// Cert is a client-side certificate, it doesn't work at the moment along
 	// with the other two options below.
 	Cert           string
@@ -142,7 +152,7 @@ func (c *Client) CalculateInputs(address string, asset util.Uint256, cost util.F
 			break
 		}
 	}
-	return unspentsToInputs(utxos, cost)
+	return unspentsToInputs(utxos, cost, c.opts.CoinSelector)
 
 }
 
@@ -4,10 +4,10 @@ import (
 	"encoding/json"
 	"errors"
 	"net/http"
-	"sort"
 
 	"github.com/ixje/neo-go-legacy/pkg/core/state"
 	"github.com/ixje/neo-go-legacy/pkg/core/transaction"
+	"github.com/ixje/neo-go-legacy/pkg/rpc/request"
 	"github.com/ixje/neo-go-legacy/pkg/rpc/response/result"
 	"github.com/ixje/neo-go-legacy/pkg/util"
 	errs "github.com/pkg/errors"
@@ -24,6 +24,9 @@ type (
 	NeoScanServer struct {
 		URL  string // "protocol://host:port/"
 		Path string // path to API endpoint without wallet address
+		// CoinSelector chooses unspents to spend, request.SmallestFirst
+		// is used if it's not set.
+		CoinSelector request.CoinSelector
 	}
 
 	// Unspent stores Unspents per asset
@@ -90,36 +93,32 @@ func (s NeoScanServer) CalculateInputs(address string, assetIDUint util.Uint256,
 		return nil, util.Fixed8(0), errs.Wrapf(err, "Cannot get balance for address %v", address)
 	}
 	filterSpecificAsset(assetID, us, &assetUnspent)
-	return unspentsToInputs(assetUnspent.Unspent, cost)
+	return unspentsToInputs(assetUnspent.Unspent, cost, s.CoinSelector)
 }
 
 // unspentsToInputs uses UnspentBalances to create a slice of inputs for a new
-// transcation containing the required amount of asset.
-func unspentsToInputs(utxos state.UnspentBalances, required util.Fixed8) ([]transaction.Input, util.Fixed8, error) {
-	var (
-		num, i   uint16
-		selected = util.Fixed8(0)
-	)
-	sort.Sort(utxos)
-
-	for _, us := range utxos {
-		if selected >= required {
-			break
-		}
-		selected += us.Value
-		num++
+// transcation containing the required amount of asset. Unspents are chosen
+// by the selector given, request.SmallestFirst is used if it's nil.
+func unspentsToInputs(utxos state.UnspentBalances, required util.Fixed8, selector request.CoinSelector) ([]transaction.Input, util.Fixed8, error) {
+	if selector == nil {
+		selector = request.SmallestFirst
 	}
-	if selected < required {
+	selected, err := selector(utxos, required)
+	if err == request.ErrInsufficientFunds {
 		return nil, util.Fixed8(0), errors.New("cannot compose inputs for transaction; check sender balance")
+	} else if err != nil {
+		return nil, util.Fixed8(0), err
 	}
 
-	inputs := make([]transaction.Input, 0, num)
-	for i = 0; i < num; i++ {
+	var total util.Fixed8
+	inputs := make([]transaction.Input, 0, len(selected))
+	for _, us := range selected {
+		total += us.Value
 		inputs = append(inputs, transaction.Input{
-			PrevHash:  utxos[i].Tx,
-			PrevIndex: utxos[i].Index,
+			PrevHash:  us.Tx,
+			PrevIndex: us.Index,
 		})
 	}
 
-	return inputs, selected, nil
+	return inputs, total, nil
 }
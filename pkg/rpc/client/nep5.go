@@ -93,6 +93,42 @@ func (c *Client) NEP5TokenInfo(tokenHash util.Uint160) (*wallet.Token, error) {
 	return wallet.NewToken(tokenHash, name, symbol, decimals), nil
 }
 
+// CreateNEP5TransferTx creates an unsigned invocation transaction that
+// invokes 'transfer' method on a given token to move specified amount of NEP5
+// assets (in FixedN format using contract's number of decimals) from one
+// account to another. gas is the system fee attached to the transaction, use
+// EstimateNEP5TransferFee to get the fee needed for the transfer.
+func (c *Client) CreateNEP5TransferTx(from util.Uint160, to util.Uint160, token *wallet.Token, amount int64, gas util.Fixed8) (*transaction.Transaction, error) {
+	script := nep5TransferScript(from, to, token, amount)
+	tx := transaction.NewInvocationTX(script, gas)
+	tx.Attributes = append(tx.Attributes, transaction.Attribute{
+		Usage: transaction.Script,
+		Data:  from.BytesBE(),
+	})
+
+	if err := request.AddInputsAndUnspentsToTx(tx, address.Uint160ToString(from), core.UtilityTokenID(), gas, c); err != nil {
+		return nil, fmt.Errorf("can't add GAS to transaction: %v", err)
+	}
+	return tx, nil
+}
+
+// EstimateNEP5TransferFee returns system fee that should be attached to the
+// transaction transferring specified amount of NEP5 assets from one account
+// to another.
+func (c *Client) EstimateNEP5TransferFee(from util.Uint160, to util.Uint160, token *wallet.Token, amount int64) (util.Fixed8, error) {
+	return c.EstimateSystemFee(nep5TransferScript(from, to, token, amount), []util.Uint160{from})
+}
+
+// nep5TransferScript returns a script invoking 'transfer' method of the token.
+func nep5TransferScript(from util.Uint160, to util.Uint160, token *wallet.Token, amount int64) []byte {
+	// Note: we don't use invoke function here because it requires
+	// 2 round trips instead of one.
+	w := io.NewBufBinWriter()
+	emit.AppCallWithOperationAndArgs(w.BinWriter, token.Hash, "transfer", from, to, amount)
+	emit.Opcode(w.BinWriter, opcode.THROWIFNOT)
+	return w.Bytes()
+}
+
 // TransferNEP5 creates an invocation transaction that invokes 'transfer' method
 // on a given token to move specified amount of NEP5 assets (in FixedN format
 // using contract's number of decimals) to given account.
@@ -101,20 +137,9 @@ func (c *Client) TransferNEP5(acc *wallet.Account, to util.Uint160, token *walle
 	if err != nil {
 		return util.Uint256{}, fmt.Errorf("bad account address: %v", err)
 	}
-	// Note: we don't use invoke function here because it requires
-	// 2 round trips instead of one.
-	w := io.NewBufBinWriter()
-	emit.AppCallWithOperationAndArgs(w.BinWriter, token.Hash, "transfer", from, to, amount)
-	emit.Opcode(w.BinWriter, opcode.THROWIFNOT)
-
-	tx := transaction.NewInvocationTX(w.Bytes(), gas)
-	tx.Attributes = append(tx.Attributes, transaction.Attribute{
-		Usage: transaction.Script,
-		Data:  from.BytesBE(),
-	})
-
-	if err := request.AddInputsAndUnspentsToTx(tx, acc.Address, core.UtilityTokenID(), gas, c); err != nil {
-		return util.Uint256{}, fmt.Errorf("can't add GAS to transaction: %v", err)
+	tx, err := c.CreateNEP5TransferTx(from, to, token, amount, gas)
+	if err != nil {
+		return util.Uint256{}, err
 	}
 
 	if err := acc.SignTx(tx); err != nil {
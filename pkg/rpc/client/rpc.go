@@ -9,6 +9,7 @@ import (
 	"github.com/ixje/neo-go-legacy/pkg/encoding/address"
 	"github.com/ixje/neo-go-legacy/pkg/io"
 	"github.com/ixje/neo-go-legacy/pkg/rpc/request"
+	"github.com/ixje/neo-go-legacy/pkg/rpc/response"
 	"github.com/ixje/neo-go-legacy/pkg/rpc/response/result"
 	"github.com/ixje/neo-go-legacy/pkg/smartcontract"
 	"github.com/ixje/neo-go-legacy/pkg/util"
@@ -484,6 +485,62 @@ func (c *Client) GetVersion() (*result.Version, error) {
 	return resp, nil
 }
 
+// EstimateGas runs given script in the test VM and returns the amount of GAS
+// consumed along with system fee required to execute it in the next block.
+// Hashes for verifying are used to pass witness checks performed by the
+// script.
+// NOTE: This is a test invoke and will not affect the blockchain.
+func (c *Client) EstimateGas(script []byte, hashesForVerifying []util.Uint160) (*result.GasEstimate, error) {
+	var (
+		params = request.NewRawParams(hex.EncodeToString(script))
+		resp   = new(result.GasEstimate)
+	)
+	if hashesForVerifying != nil {
+		params.Values = append(params.Values, hashesForVerifying)
+	}
+	if err := c.performRequest("estimategas", params, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// EstimateSystemFee returns system fee that should be attached to the
+// invocation transaction with given script. It fails if the script can't be
+// executed successfully. If the node doesn't support estimategas method (like
+// C# node), the fee is calculated from the GAS consumed by invokescript with
+// the free GAS limit specified in client options.
+func (c *Client) EstimateSystemFee(script []byte, hashesForVerifying []util.Uint160) (util.Fixed8, error) {
+	res, err := c.EstimateGas(script, hashesForVerifying)
+	if err != nil {
+		if rpcErr, ok := err.(*response.Error); !ok || rpcErr.Code != response.ErrMethodNotFound.Code {
+			return 0, err
+		}
+		return c.estimateSystemFeeByInvoke(script, hashesForVerifying)
+	}
+	if res.State != "HALT" {
+		return 0, errors.Errorf("test invocation failed with %s state", res.State)
+	}
+	return res.SystemFee, nil
+}
+
+func (c *Client) estimateSystemFeeByInvoke(script []byte, hashesForVerifying []util.Uint160) (util.Fixed8, error) {
+	if c.opts.FreeGas == 0 {
+		return 0, errors.New("node doesn't support estimategas and free GAS limit is not set")
+	}
+	res, err := c.InvokeScript(hex.EncodeToString(script), hashesForVerifying)
+	if err != nil {
+		return 0, err
+	}
+	if res.State != "HALT" {
+		return 0, errors.Errorf("test invocation failed with %s state", res.State)
+	}
+	consumed, err := util.Fixed8FromString(res.GasConsumed)
+	if err != nil {
+		return 0, errors.Wrap(err, "bad gas_consumed value")
+	}
+	return core.EstimateSystemFee(consumed, c.opts.FreeGas), nil
+}
+
 // InvokeScript returns the result of the given script after running it true the VM.
 // NOTE: This is a test invoke and will not affect the blockchain.
 func (c *Client) InvokeScript(script string, hashesForVerifying []util.Uint160) (*result.Invoke, error) {
@@ -585,26 +642,77 @@ func (c *Client) TransferAsset(asset util.Uint256, address string, amount util.F
 	return rawTx.Hash(), nil
 }
 
+// CreateInvocationTx creates an unsigned invocation transaction with the
+// given script spending the amount of gas specified from the account with
+// the given script hash.
+func (c *Client) CreateInvocationTx(script []byte, from util.Uint160, sysfee util.Fixed8, netfee util.Fixed8) (*transaction.Transaction, error) {
+	tx := transaction.NewInvocationTX(script, sysfee)
+	if err := c.addFee(tx, from, sysfee+netfee); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// CreateStateTx creates an unsigned state transaction with the given
+// descriptors paying system and network fees from the account with the
+// given script hash.
+func (c *Client) CreateStateTx(descs []*transaction.StateDescriptor, from util.Uint160, sysfee util.Fixed8, netfee util.Fixed8) (*transaction.Transaction, error) {
+	tx := transaction.NewStateTX(descs...)
+	if err := c.addFee(tx, from, sysfee+netfee); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// CreateRegisterTx creates an unsigned transaction registering the asset
+// paying system and network fees from the account with the given script
+// hash.
+func (c *Client) CreateRegisterTx(reg *transaction.RegisterTX, from util.Uint160, sysfee util.Fixed8, netfee util.Fixed8) (*transaction.Transaction, error) {
+	tx := transaction.NewRegisterTX(reg)
+	if err := c.addFee(tx, from, sysfee+netfee); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// CreateIssueTx creates an unsigned transaction issuing the asset to the
+// given outputs paying system and network fees from the account with the
+// given script hash.
+func (c *Client) CreateIssueTx(outputs []transaction.Output, from util.Uint160, sysfee util.Fixed8, netfee util.Fixed8) (*transaction.Transaction, error) {
+	tx := transaction.NewIssueTX(outputs...)
+	if err := c.addFee(tx, from, sysfee+netfee); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// addFee adds GAS inputs (with change) of the account to the transaction to
+// pay the fee, the account is added to the verification list if there is no
+// fee to pay.
+func (c *Client) addFee(tx *transaction.Transaction, from util.Uint160, gas util.Fixed8) error {
+	if gas > 0 {
+		if err := request.AddInputsAndUnspentsToTx(tx, address.Uint160ToString(from), core.UtilityTokenID(), gas, c); err != nil {
+			return errors.Wrap(err, "failed to add inputs and unspents to transaction")
+		}
+	} else {
+		tx.AddVerificationHash(from)
+	}
+	return nil
+}
+
 // SignAndPushInvocationTx signs and pushes given script as an invocation
 // transaction  using given wif to sign it and spending the amount of gas
 // specified. It returns a hash of the invocation transaction and an error.
 func (c *Client) SignAndPushInvocationTx(script []byte, acc *wallet.Account, sysfee util.Fixed8, netfee util.Fixed8) (util.Uint256, error) {
 	var txHash util.Uint256
-	var err error
-
-	tx := transaction.NewInvocationTX(script, sysfee)
-	gas := sysfee + netfee
 
-	if gas > 0 {
-		if err = request.AddInputsAndUnspentsToTx(tx, acc.Address, core.UtilityTokenID(), gas, c); err != nil {
-			return txHash, errors.Wrap(err, "failed to add inputs and unspents to transaction")
-		}
-	} else {
-		addr, err := address.StringToUint160(acc.Address)
-		if err != nil {
-			return txHash, errors.Wrap(err, "failed to get address")
-		}
-		tx.AddVerificationHash(addr)
+	addr, err := address.StringToUint160(acc.Address)
+	if err != nil {
+		return txHash, errors.Wrap(err, "failed to get address")
+	}
+	tx, err := c.CreateInvocationTx(script, addr, sysfee, netfee)
+	if err != nil {
+		return txHash, err
 	}
 
 	if err = acc.SignTx(tx); err != nil {
@@ -3,6 +3,7 @@ package client
 import (
 	"context"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -113,6 +114,33 @@ func getResultBlock1() *result.Block {
 // published in official C# JSON-RPC API v2.10.3 reference
 // (see https://docs.neo.org/docs/en-us/reference/rpc/latest-version/api.html)
 var rpcClientTestCases = map[string][]rpcClientTestCase{
+	"estimategas": {
+		{
+			name: "positive",
+			invoke: func(c *Client) (interface{}, error) {
+				return c.EstimateGas([]byte{0x51}, nil)
+			},
+			serverResponse: `{"jsonrpc":"2.0","id":1,"result":{"state":"HALT","gas_consumed":"12.5","free_gas":"10","sysfee":"3"}}`,
+			result: func(c *Client) interface{} {
+				return &result.GasEstimate{
+					State:       "HALT",
+					GasConsumed: util.Fixed8FromFloat(12.5),
+					FreeGas:     util.Fixed8FromInt64(10),
+					SystemFee:   util.Fixed8FromInt64(3),
+				}
+			},
+		},
+		{
+			name: "system fee",
+			invoke: func(c *Client) (interface{}, error) {
+				return c.EstimateSystemFee([]byte{0x51}, []util.Uint160{{1, 2, 3}})
+			},
+			serverResponse: `{"jsonrpc":"2.0","id":1,"result":{"state":"HALT","gas_consumed":"12.5","free_gas":"10","sysfee":"3"}}`,
+			result: func(c *Client) interface{} {
+				return util.Fixed8FromInt64(3)
+			},
+		},
+	},
 	"getaccountstate": {
 		{
 			name: "positive",
@@ -1005,6 +1033,14 @@ var rpcClientErrorCases = map[string][]rpcClientErrorCase{
 			},
 		},
 	},
+	`{"jsonrpc":"2.0","id":1,"result":{"state":"FAULT","gas_consumed":"0.1","free_gas":"10","sysfee":"0"}}`: {
+		{
+			name: "estimatesystemfee_fault",
+			invoke: func(c *Client) (interface{}, error) {
+				return c.EstimateSystemFee([]byte{0xf0}, nil)
+			},
+		},
+	},
 	`{"jsonrpc":"2.0","id":1,"result":false}`: {
 		{
 			name: "sendrawtransaction_bad_server_answer",
@@ -1189,6 +1225,12 @@ var rpcClientErrorCases = map[string][]rpcClientErrorCase{
 				return c.InvokeFunction("", "", []smartcontract.Parameter{}, nil)
 			},
 		},
+		{
+			name: "estimategas_invalid_params_error",
+			invoke: func(c *Client) (interface{}, error) {
+				return c.EstimateGas(nil, nil)
+			},
+		},
 		{
 			name: "invokescript_invalid_params_error",
 			invoke: func(c *Client) (interface{}, error) {
@@ -1395,6 +1437,12 @@ var rpcClientErrorCases = map[string][]rpcClientErrorCase{
 				return c.InvokeFunction("", "", []smartcontract.Parameter{}, nil)
 			},
 		},
+		{
+			name: "estimategas_unmarshalling_error",
+			invoke: func(c *Client) (interface{}, error) {
+				return c.EstimateGas(nil, nil)
+			},
+		},
 		{
 			name: "invokescript_unmarshalling_error",
 			invoke: func(c *Client) (interface{}, error) {
@@ -1537,3 +1585,44 @@ func requestHandler(t *testing.T, w http.ResponseWriter, resp string) {
 		t.Fatalf("Error writing response: %s", err.Error())
 	}
 }
+
+func TestEstimateSystemFeeFallback(t *testing.T) {
+	var methods []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var r struct {
+			Method string `json:"method"`
+		}
+		require.NoError(t, json.NewDecoder(req.Body).Decode(&r))
+		methods = append(methods, r.Method)
+		switch r.Method {
+		case "estimategas":
+			requestHandler(t, w, `{"jsonrpc":"2.0","id":1,"error":{"code":-32601,"message":"Method not found"}}`)
+		case "invokescript":
+			requestHandler(t, w, `{"jsonrpc":"2.0","id":1,"result":{"script":"51","state":"HALT","gas_consumed":"11.2","stack":[]}}`)
+		default:
+			requestHandler(t, w, `{"jsonrpc":"2.0","id":1,"error":{"code":-32603,"message":"Internal error"}}`)
+		}
+	}))
+	defer srv.Close()
+
+	t.Run("no free GAS", func(t *testing.T) {
+		methods = nil
+		c, err := New(context.TODO(), srv.URL, Options{})
+		require.NoError(t, err)
+
+		_, err = c.EstimateSystemFee([]byte{0x51}, nil)
+		require.Error(t, err)
+		require.Equal(t, []string{"estimategas"}, methods)
+	})
+
+	t.Run("with free GAS", func(t *testing.T) {
+		methods = nil
+		c, err := New(context.TODO(), srv.URL, Options{FreeGas: util.Fixed8FromInt64(10)})
+		require.NoError(t, err)
+
+		fee, err := c.EstimateSystemFee([]byte{0x51}, nil)
+		require.NoError(t, err)
+		require.Equal(t, util.Fixed8FromInt64(2), fee)
+		require.Equal(t, []string{"estimategas", "invokescript"}, methods)
+	})
+}
@@ -0,0 +1,159 @@
+package request
+
+import (
+	"fmt"
+
+	"github.com/ixje/neo-go-legacy/pkg/core/state"
+	"github.com/ixje/neo-go-legacy/pkg/core/transaction"
+	"github.com/ixje/neo-go-legacy/pkg/io"
+	"github.com/ixje/neo-go-legacy/pkg/util"
+	"github.com/pkg/errors"
+)
+
+type (
+	// Transfer is a single payment of a batch.
+	Transfer struct {
+		AssetID util.Uint256
+		Address util.Uint160
+		Amount  util.Fixed8
+	}
+
+	// BatchTxParams contains parameters for transactions paying several
+	// recipients from one address.
+	BatchTxParams struct {
+		// From is the script hash of the sender, change is sent back to it.
+		From      util.Uint160
+		Transfers []Transfer
+		// Unspents are sender's UTXOs available for spending by asset.
+		Unspents map[util.Uint256][]state.UnspentBalance
+		// Attributes are added to every transaction.
+		Attributes []transaction.Attribute
+		// MaxSize is the size limit for every signed transaction,
+		// transaction.MaxTransactionSize is used if it's not set.
+		// Network fee is not added to transactions, so it should be
+		// set to the free transaction size limit of the network if
+		// there is one.
+		MaxSize int
+		// WitnessSize is the size of the witness added to every
+		// transaction when it's signed.
+		WitnessSize int
+		// Selector chooses unspents to spend, SmallestFirst is used if
+		// it's not set.
+		Selector CoinSelector
+	}
+)
+
+// CreateBatchContractTransactions returns unsigned contract transactions
+// making all the transfers given. Transfers are packed in as few
+// transactions as possible keeping their order, every transaction spends
+// inputs of its own and has one change output for every asset it transfers.
+func CreateBatchContractTransactions(params BatchTxParams) ([]*transaction.Transaction, error) {
+	if len(params.Transfers) == 0 {
+		return nil, errors.New("no transfers given")
+	}
+	maxSize := params.MaxSize
+	if maxSize <= 0 {
+		maxSize = transaction.MaxTransactionSize
+	}
+	for i, t := range params.Transfers {
+		if t.Amount <= 0 {
+			return nil, fmt.Errorf("transfer %d: amount must be positive", i+1)
+		}
+	}
+	if params.Selector == nil {
+		params.Selector = SmallestFirst
+	}
+	pool := make(map[util.Uint256][]state.UnspentBalance, len(params.Unspents))
+	for asset, us := range params.Unspents {
+		pool[asset] = append([]state.UnspentBalance(nil), us...)
+	}
+
+	var (
+		txs   []*transaction.Transaction
+		start = 0
+		last  *transaction.Transaction
+		used  map[util.Uint256][]state.UnspentBalance
+	)
+	for i := range params.Transfers {
+		tx, n, err := buildBatchTx(params, params.Transfers[start:i+1], pool)
+		if err == nil && io.GetVarSize(tx)+params.WitnessSize <= maxSize {
+			last, used = tx, n
+			continue
+		}
+		if last == nil {
+			if err != nil {
+				return nil, errors.Wrapf(err, "transfer %d", i+1)
+			}
+			return nil, fmt.Errorf("transfer %d: transaction doesn't fit in %d bytes", i+1, maxSize)
+		}
+		txs = append(txs, last)
+		removeSpent(pool, used)
+		start = i
+		if tx, n, err = buildBatchTx(params, params.Transfers[i:i+1], pool); err != nil {
+			return nil, errors.Wrapf(err, "transfer %d", i+1)
+		} else if io.GetVarSize(tx)+params.WitnessSize > maxSize {
+			return nil, fmt.Errorf("transfer %d: transaction doesn't fit in %d bytes", i+1, maxSize)
+		}
+		last, used = tx, n
+	}
+	return append(txs, last), nil
+}
+
+// buildBatchTx creates a transaction for the transfers given spending
+// unspents from the pool. It also returns unspents spent for every asset.
+func buildBatchTx(params BatchTxParams, transfers []Transfer, pool map[util.Uint256][]state.UnspentBalance) (*transaction.Transaction, map[util.Uint256][]state.UnspentBalance, error) {
+	var (
+		assets []util.Uint256
+		needed = make(map[util.Uint256]util.Fixed8)
+		used   = make(map[util.Uint256][]state.UnspentBalance)
+		tx     = transaction.NewContractTX()
+	)
+	tx.Attributes = append(tx.Attributes, params.Attributes...)
+	for _, t := range transfers {
+		if _, ok := needed[t.AssetID]; !ok {
+			assets = append(assets, t.AssetID)
+		}
+		needed[t.AssetID] += t.Amount
+		tx.AddOutput(transaction.NewOutput(t.AssetID, t.Amount, t.Address))
+	}
+	for _, asset := range assets {
+		utxos, err := params.Selector(pool[asset], needed[asset])
+		if err == ErrInsufficientFunds {
+			return nil, nil, fmt.Errorf("insufficient funds for asset %s: %s needed, %s available",
+				asset.StringLE(), needed[asset], unspentsValue(pool[asset]))
+		} else if err != nil {
+			return nil, nil, err
+		}
+		for _, us := range utxos {
+			tx.AddInput(&transaction.Input{PrevHash: us.Tx, PrevIndex: us.Index})
+		}
+		used[asset] = utxos
+		selected := unspentsValue(utxos)
+		if change := selected - needed[asset]; change > 0 {
+			tx.AddOutput(transaction.NewOutput(asset, change, params.From))
+		}
+	}
+	return tx, used, nil
+}
+
+// removeSpent removes unspents spent by the transaction from the pool.
+func removeSpent(pool map[util.Uint256][]state.UnspentBalance, used map[util.Uint256][]state.UnspentBalance) {
+	for asset, spent := range used {
+		rest := pool[asset][:0]
+		for _, us := range pool[asset] {
+			if !containsUnspent(spent, us) {
+				rest = append(rest, us)
+			}
+		}
+		pool[asset] = rest
+	}
+}
+
+func containsUnspent(unspents []state.UnspentBalance, us state.UnspentBalance) bool {
+	for i := range unspents {
+		if unspents[i].Tx == us.Tx && unspents[i].Index == us.Index {
+			return true
+		}
+	}
+	return false
+}
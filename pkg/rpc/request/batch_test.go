@@ -0,0 +1,135 @@
+package request
+
+import (
+	"testing"
+
+	"github.com/ixje/neo-go-legacy/pkg/core/state"
+	"github.com/ixje/neo-go-legacy/pkg/core/transaction"
+	"github.com/ixje/neo-go-legacy/pkg/io"
+	"github.com/ixje/neo-go-legacy/pkg/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateBatchContractTransactions(t *testing.T) {
+	var (
+		neo    = util.Uint256{1}
+		gas    = util.Uint256{2}
+		from   = util.Uint160{1, 2, 3}
+		rcpt1  = util.Uint160{4}
+		rcpt2  = util.Uint160{5}
+		unspts = map[util.Uint256][]state.UnspentBalance{
+			neo: {
+				{Tx: util.Uint256{10}, Index: 0, Value: util.Fixed8FromInt64(10)},
+				{Tx: util.Uint256{11}, Index: 1, Value: util.Fixed8FromInt64(5)},
+			},
+			gas: {
+				{Tx: util.Uint256{12}, Index: 0, Value: util.Fixed8FromInt64(1)},
+				{Tx: util.Uint256{13}, Index: 2, Value: util.Fixed8FromInt64(3)},
+				{Tx: util.Uint256{14}, Index: 1, Value: util.Fixed8FromInt64(2)},
+			},
+		}
+	)
+
+	t.Run("single transaction", func(t *testing.T) {
+		txs, err := CreateBatchContractTransactions(BatchTxParams{
+			From: from,
+			Transfers: []Transfer{
+				{AssetID: gas, Address: rcpt1, Amount: util.Fixed8FromInt64(2)},
+				{AssetID: neo, Address: rcpt2, Amount: util.Fixed8FromInt64(7)},
+				{AssetID: gas, Address: rcpt2, Amount: util.Fixed8FromFloat(1.5)},
+			},
+			Unspents: unspts,
+		})
+		require.NoError(t, err)
+		require.Equal(t, 1, len(txs))
+		tx := txs[0]
+		require.Equal(t, transaction.ContractType, tx.Type)
+		require.Equal(t, []transaction.Input{
+			{PrevHash: util.Uint256{12}, PrevIndex: 0},
+			{PrevHash: util.Uint256{14}, PrevIndex: 1},
+			{PrevHash: util.Uint256{13}, PrevIndex: 2},
+			{PrevHash: util.Uint256{11}, PrevIndex: 1},
+			{PrevHash: util.Uint256{10}, PrevIndex: 0},
+		}, tx.Inputs)
+		require.Equal(t, []transaction.Output{
+			*transaction.NewOutput(gas, util.Fixed8FromInt64(2), rcpt1),
+			*transaction.NewOutput(neo, util.Fixed8FromInt64(7), rcpt2),
+			*transaction.NewOutput(gas, util.Fixed8FromFloat(1.5), rcpt2),
+			*transaction.NewOutput(gas, util.Fixed8FromFloat(2.5), from),
+			*transaction.NewOutput(neo, util.Fixed8FromInt64(8), from),
+		}, tx.Outputs)
+	})
+	t.Run("split by size", func(t *testing.T) {
+		transfers := []Transfer{
+			{AssetID: gas, Address: rcpt1, Amount: util.Fixed8FromInt64(1)},
+			{AssetID: gas, Address: rcpt2, Amount: util.Fixed8FromInt64(1)},
+			{AssetID: gas, Address: rcpt1, Amount: util.Fixed8FromInt64(2)},
+		}
+		single, err := CreateBatchContractTransactions(BatchTxParams{
+			From:      from,
+			Transfers: transfers[:2],
+			Unspents:  unspts,
+		})
+		require.NoError(t, err)
+		require.Equal(t, 1, len(single))
+
+		// Two transfers fit, but the third one doesn't.
+		txs, err := CreateBatchContractTransactions(BatchTxParams{
+			From:        from,
+			Transfers:   transfers,
+			Unspents:    unspts,
+			MaxSize:     io.GetVarSize(single[0]) + 100,
+			WitnessSize: 100,
+		})
+		require.NoError(t, err)
+		require.Equal(t, 2, len(txs))
+		require.Equal(t, single[0], txs[0])
+		require.Equal(t, []transaction.Input{{PrevHash: util.Uint256{13}, PrevIndex: 2}}, txs[1].Inputs)
+		require.Equal(t, []transaction.Output{
+			*transaction.NewOutput(gas, util.Fixed8FromInt64(2), rcpt1),
+			*transaction.NewOutput(gas, util.Fixed8FromInt64(1), from),
+		}, txs[1].Outputs)
+	})
+	t.Run("attributes", func(t *testing.T) {
+		attr := transaction.Attribute{Usage: transaction.Remark, Data: []byte("salary")}
+		txs, err := CreateBatchContractTransactions(BatchTxParams{
+			From:       from,
+			Transfers:  []Transfer{{AssetID: neo, Address: rcpt1, Amount: util.Fixed8FromInt64(1)}},
+			Unspents:   unspts,
+			Attributes: []transaction.Attribute{attr},
+		})
+		require.NoError(t, err)
+		require.Equal(t, []transaction.Attribute{attr}, txs[0].Attributes)
+	})
+	t.Run("errors", func(t *testing.T) {
+		testCases := map[string]BatchTxParams{
+			"no transfers": {From: from, Unspents: unspts},
+			"zero amount": {
+				Transfers: []Transfer{{AssetID: neo, Address: rcpt1}},
+				Unspents:  unspts,
+			},
+			"insufficient funds": {
+				Transfers: []Transfer{
+					{AssetID: neo, Address: rcpt1, Amount: util.Fixed8FromInt64(10)},
+					{AssetID: neo, Address: rcpt2, Amount: util.Fixed8FromInt64(6)},
+				},
+				Unspents: unspts,
+			},
+			"unknown asset": {
+				Transfers: []Transfer{{AssetID: util.Uint256{3}, Address: rcpt1, Amount: 1}},
+				Unspents:  unspts,
+			},
+			"too big": {
+				Transfers: []Transfer{{AssetID: neo, Address: rcpt1, Amount: 1}},
+				Unspents:  unspts,
+				MaxSize:   50,
+			},
+		}
+		for name, params := range testCases {
+			t.Run(name, func(t *testing.T) {
+				_, err := CreateBatchContractTransactions(params)
+				require.Error(t, err)
+			})
+		}
+	})
+}
@@ -0,0 +1,144 @@
+package request
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"sort"
+
+	"github.com/ixje/neo-go-legacy/pkg/core/state"
+	"github.com/ixje/neo-go-legacy/pkg/util"
+)
+
+// CoinSelector chooses unspents of some asset to spend to get at least the
+// amount given. It must not modify the slice of unspents passed and returns
+// ErrInsufficientFunds if they're not enough.
+type CoinSelector func(unspents []state.UnspentBalance, amount util.Fixed8) ([]state.UnspentBalance, error)
+
+// ErrInsufficientFunds is returned by coin selectors when the total value of
+// unspents is less than the amount requested.
+var ErrInsufficientFunds = errors.New("insufficient funds")
+
+// maxBnBTries is the maximum number of subsets BranchAndBound checks.
+const maxBnBTries = 100000
+
+// SmallestFirst spends the smallest unspents first. It cleans up dust, but
+// produces transactions with many inputs.
+func SmallestFirst(unspents []state.UnspentBalance, amount util.Fixed8) ([]state.UnspentBalance, error) {
+	return accumulate(sortedUnspents(unspents, false), amount)
+}
+
+// LargestFirst spends the largest unspents first, so that transactions
+// have as few inputs as possible.
+func LargestFirst(unspents []state.UnspentBalance, amount util.Fixed8) ([]state.UnspentBalance, error) {
+	return accumulate(sortedUnspents(unspents, true), amount)
+}
+
+// BranchAndBound searches for a set of unspents with total value equal to
+// the amount, so that the transaction has no change output. If there is no
+// such set (or it can't be found in a reasonable time), it falls back to
+// LargestFirst.
+func BranchAndBound(unspents []state.UnspentBalance, amount util.Fixed8) ([]state.UnspentBalance, error) {
+	utxos := sortedUnspents(unspents, true)
+	// rest[i] is the total value of utxos[i:].
+	rest := make([]util.Fixed8, len(utxos)+1)
+	for i := len(utxos) - 1; i >= 0; i-- {
+		rest[i] = rest[i+1] + utxos[i].Value
+	}
+	if rest[0] < amount {
+		return nil, ErrInsufficientFunds
+	}
+
+	var (
+		tries    int
+		selected []int
+		search   func(i int, sum util.Fixed8) bool
+	)
+	search = func(i int, sum util.Fixed8) bool {
+		if sum == amount {
+			return true
+		}
+		tries++
+		if i == len(utxos) || sum+rest[i] < amount || tries > maxBnBTries {
+			return false
+		}
+		if sum+utxos[i].Value <= amount {
+			selected = append(selected, i)
+			if search(i+1, sum+utxos[i].Value) {
+				return true
+			}
+			selected = selected[:len(selected)-1]
+		}
+		return search(i+1, sum)
+	}
+	if !search(0, 0) {
+		return accumulate(utxos, amount)
+	}
+	res := make([]state.UnspentBalance, len(selected))
+	for i, j := range selected {
+		res[i] = utxos[j]
+	}
+	return res, nil
+}
+
+// PrivacyPreserving avoids linking unspents together where possible: it
+// spends the smallest single unspent covering the amount if there is one,
+// otherwise unspents are spent in random order, so that the choice doesn't
+// reveal anything about the wallet.
+func PrivacyPreserving(unspents []state.UnspentBalance, amount util.Fixed8) ([]state.UnspentBalance, error) {
+	utxos := sortedUnspents(unspents, false)
+	for _, us := range utxos {
+		if us.Value >= amount {
+			return []state.UnspentBalance{us}, nil
+		}
+	}
+	for i := len(utxos) - 1; i > 0; i-- {
+		j := randIntn(i + 1)
+		utxos[i], utxos[j] = utxos[j], utxos[i]
+	}
+	return accumulate(utxos, amount)
+}
+
+// sortedUnspents returns the copy of unspents sorted by value.
+func sortedUnspents(unspents []state.UnspentBalance, desc bool) state.UnspentBalances {
+	utxos := append(state.UnspentBalances(nil), unspents...)
+	if desc {
+		sort.Stable(sort.Reverse(utxos))
+	} else {
+		sort.Stable(utxos)
+	}
+	return utxos
+}
+
+// accumulate takes unspents in order until their value reaches the amount.
+func accumulate(utxos []state.UnspentBalance, amount util.Fixed8) ([]state.UnspentBalance, error) {
+	var selected util.Fixed8
+	for i := range utxos {
+		if selected >= amount {
+			return utxos[:i], nil
+		}
+		selected += utxos[i].Value
+	}
+	if selected < amount {
+		return nil, ErrInsufficientFunds
+	}
+	return utxos, nil
+}
+
+// randIntn returns a uniformly distributed random number in [0, n).
+func randIntn(n int) int {
+	var buf [8]byte
+	if _, err := rand.Read(buf[:]); err != nil {
+		panic(err)
+	}
+	return int(binary.LittleEndian.Uint64(buf[:]) % uint64(n))
+}
+
+// unspentsValue returns the total value of unspents.
+func unspentsValue(unspents []state.UnspentBalance) util.Fixed8 {
+	var sum util.Fixed8
+	for _, us := range unspents {
+		sum += us.Value
+	}
+	return sum
+}
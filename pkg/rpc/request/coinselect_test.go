@@ -0,0 +1,72 @@
+package request
+
+import (
+	"testing"
+
+	"github.com/ixje/neo-go-legacy/pkg/core/state"
+	"github.com/ixje/neo-go-legacy/pkg/util"
+	"github.com/stretchr/testify/require"
+)
+
+func unspentsOf(values ...int64) []state.UnspentBalance {
+	res := make([]state.UnspentBalance, len(values))
+	for i, v := range values {
+		res[i] = state.UnspentBalance{Tx: util.Uint256{byte(i)}, Value: util.Fixed8(v)}
+	}
+	return res
+}
+
+func TestCoinSelectors(t *testing.T) {
+	utxos := unspentsOf(5, 1, 8, 3, 2)
+	orig := unspentsOf(5, 1, 8, 3, 2)
+	values := func(us []state.UnspentBalance) []util.Fixed8 {
+		res := make([]util.Fixed8, len(us))
+		for i := range us {
+			res[i] = us[i].Value
+		}
+		return res
+	}
+
+	testCases := []struct {
+		name     string
+		selector CoinSelector
+		amount   int64
+		expected []util.Fixed8
+	}{
+		{"smallest first", SmallestFirst, 5, []util.Fixed8{1, 2, 3}},
+		{"largest first", LargestFirst, 10, []util.Fixed8{8, 5}},
+		{"exact match", BranchAndBound, 10, []util.Fixed8{8, 2}},
+		{"exact match of all", BranchAndBound, 19, []util.Fixed8{8, 5, 3, 2, 1}},
+		{"no exact match", BranchAndBound, 20, nil},
+		{"single unspent", PrivacyPreserving, 4, []util.Fixed8{5}},
+		{"zero amount", SmallestFirst, 0, []util.Fixed8{}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := tc.selector(utxos, util.Fixed8(tc.amount))
+			if tc.expected == nil {
+				require.Equal(t, ErrInsufficientFunds, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, values(res))
+		})
+	}
+	t.Run("fallback to largest first", func(t *testing.T) {
+		res, err := BranchAndBound(unspentsOf(4, 4, 4), 6)
+		require.NoError(t, err)
+		require.Equal(t, []util.Fixed8{4, 4}, values(res))
+	})
+	t.Run("random order", func(t *testing.T) {
+		res, err := PrivacyPreserving(utxos, 12)
+		require.NoError(t, err)
+		require.True(t, unspentsValue(res) >= 12)
+		// No unspent is needed to reach the amount without the last one.
+		require.True(t, unspentsValue(res[:len(res)-1]) < 12)
+	})
+	for _, sel := range []CoinSelector{SmallestFirst, LargestFirst, BranchAndBound, PrivacyPreserving} {
+		_, err := sel(utxos, 20)
+		require.Equal(t, ErrInsufficientFunds, err)
+	}
+	require.Equal(t, orig, utxos)
+}
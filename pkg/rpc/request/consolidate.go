@@ -0,0 +1,101 @@
+package request
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/ixje/neo-go-legacy/pkg/core/state"
+	"github.com/ixje/neo-go-legacy/pkg/core/transaction"
+	"github.com/ixje/neo-go-legacy/pkg/io"
+	"github.com/ixje/neo-go-legacy/pkg/util"
+)
+
+// ConsolidateTxParams contains parameters for a transaction merging unspents
+// of some asset into a few outputs.
+type ConsolidateTxParams struct {
+	// Address is the script hash owning unspents, outputs are sent to it.
+	Address  util.Uint160
+	AssetID  util.Uint256
+	Unspents []state.UnspentBalance
+	// Outputs is the number of outputs to create, 1 if not set.
+	Outputs int
+	// MaxValue excludes unspents of this or bigger value if set.
+	MaxValue util.Fixed8
+	// Unit is the smallest amount of asset outputs can hold (1 NEO for
+	// NEO), util.Fixed8(1) is used if it's not set.
+	Unit       util.Fixed8
+	Attributes []transaction.Attribute
+	// MaxSize is the size limit for the signed transaction,
+	// transaction.MaxTransactionSize is used if it's not set.
+	MaxSize int
+	// WitnessSize is the size of the witness added when it's signed.
+	WitnessSize int
+}
+
+// CreateConsolidationTransaction returns an unsigned contract transaction
+// spending the smallest unspents of the asset and splitting their value
+// evenly between outputs. It takes as many unspents as fit in the size limit,
+// so it can be repeated to merge the rest of them.
+func CreateConsolidationTransaction(params ConsolidateTxParams) (*transaction.Transaction, error) {
+	if params.Outputs <= 0 {
+		params.Outputs = 1
+	}
+	if params.Unit <= 0 {
+		params.Unit = 1
+	}
+	maxSize := params.MaxSize
+	if maxSize <= 0 {
+		maxSize = transaction.MaxTransactionSize
+	}
+	var utxos state.UnspentBalances
+	for _, us := range params.Unspents {
+		if params.MaxValue <= 0 || us.Value < params.MaxValue {
+			utxos = append(utxos, us)
+		}
+	}
+	sort.Stable(utxos)
+	if len(utxos) <= params.Outputs {
+		return nil, fmt.Errorf("nothing to consolidate: %d unspents of asset %s can't be merged into %d outputs",
+			len(utxos), params.AssetID.StringLE(), params.Outputs)
+	}
+
+	// The size grows with every input, so the number of inputs fitting in
+	// the limit is found with a binary search.
+	fits := func(n int) bool {
+		tx := buildConsolidationTx(params, utxos[:n])
+		return io.GetVarSize(tx)+params.WitnessSize <= maxSize
+	}
+	n := sort.Search(len(utxos)-params.Outputs, func(i int) bool {
+		return !fits(params.Outputs + 1 + i)
+	}) + params.Outputs
+	if n <= params.Outputs {
+		return nil, fmt.Errorf("transaction merging %d unspents doesn't fit in %d bytes", params.Outputs+1, maxSize)
+	}
+	if int64(unspentsValue(utxos[:n])/params.Unit) < int64(params.Outputs) {
+		return nil, errors.New("unspents value is too low to be split between outputs")
+	}
+	return buildConsolidationTx(params, utxos[:n]), nil
+}
+
+// buildConsolidationTx creates a transaction spending all the unspents given.
+func buildConsolidationTx(params ConsolidateTxParams, utxos []state.UnspentBalance) *transaction.Transaction {
+	total := unspentsValue(utxos)
+	units := int64(total / params.Unit)
+	tx := transaction.NewContractTX()
+	tx.Attributes = append(tx.Attributes, params.Attributes...)
+	for _, us := range utxos {
+		tx.AddInput(&transaction.Input{PrevHash: us.Tx, PrevIndex: us.Index})
+	}
+	// Every output gets the same number of units, the remainder is added
+	// to the first one.
+	part := util.Fixed8(units/int64(params.Outputs)) * params.Unit
+	for i := 0; i < params.Outputs; i++ {
+		amount := part
+		if i == 0 {
+			amount = total - part*util.Fixed8(params.Outputs-1)
+		}
+		tx.AddOutput(transaction.NewOutput(params.AssetID, amount, params.Address))
+	}
+	return tx
+}
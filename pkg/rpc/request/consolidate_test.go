@@ -0,0 +1,78 @@
+package request
+
+import (
+	"testing"
+
+	"github.com/ixje/neo-go-legacy/pkg/core/transaction"
+	"github.com/ixje/neo-go-legacy/pkg/io"
+	"github.com/ixje/neo-go-legacy/pkg/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateConsolidationTransaction(t *testing.T) {
+	var (
+		asset = util.Uint256{1}
+		addr  = util.Uint160{2}
+	)
+	params := ConsolidateTxParams{
+		Address:  addr,
+		AssetID:  asset,
+		Unspents: unspentsOf(500, 100, 300, 200, 1000),
+	}
+
+	t.Run("all", func(t *testing.T) {
+		tx, err := CreateConsolidationTransaction(params)
+		require.NoError(t, err)
+		require.Equal(t, transaction.ContractType, tx.Type)
+		require.Equal(t, 5, len(tx.Inputs))
+		require.Equal(t, []transaction.Output{*transaction.NewOutput(asset, 2100, addr)}, tx.Outputs)
+	})
+	t.Run("small ones", func(t *testing.T) {
+		p := params
+		p.MaxValue = 500
+		p.Outputs = 2
+		p.Unit = 200
+		tx, err := CreateConsolidationTransaction(p)
+		require.NoError(t, err)
+		require.Equal(t, []transaction.Input{
+			{PrevHash: util.Uint256{1}},
+			{PrevHash: util.Uint256{3}},
+			{PrevHash: util.Uint256{2}},
+		}, tx.Inputs)
+		require.Equal(t, []transaction.Output{
+			*transaction.NewOutput(asset, 400, addr),
+			*transaction.NewOutput(asset, 200, addr),
+		}, tx.Outputs)
+	})
+	t.Run("size limit", func(t *testing.T) {
+		p := params
+		p.Unspents = p.Unspents[:3]
+		full, err := CreateConsolidationTransaction(p)
+		require.NoError(t, err)
+
+		p.Unspents = params.Unspents
+		p.MaxSize = io.GetVarSize(full) + 10
+		p.WitnessSize = 10
+		tx, err := CreateConsolidationTransaction(p)
+		require.NoError(t, err)
+		require.Equal(t, 3, len(tx.Inputs))
+		require.Equal(t, []transaction.Output{*transaction.NewOutput(asset, 600, addr)}, tx.Outputs)
+	})
+	t.Run("errors", func(t *testing.T) {
+		p := params
+		p.MaxValue = 200
+		_, err := CreateConsolidationTransaction(p)
+		require.Error(t, err)
+
+		p = params
+		p.MaxSize = 100
+		_, err = CreateConsolidationTransaction(p)
+		require.Error(t, err)
+
+		p = params
+		p.Outputs = 3
+		p.Unit = 1000
+		_, err = CreateConsolidationTransaction(p)
+		require.Error(t, err)
+	})
+}
@@ -20,6 +20,8 @@ type (
 var (
 	// ErrInvalidParams represents a generic 'invalid parameters' error.
 	ErrInvalidParams = NewInvalidParamsError("", nil)
+	// ErrMethodNotFound represents a generic 'method not found' error.
+	ErrMethodNotFound = NewMethodNotFoundError("", nil)
 	// ErrAlreadyExists represents SubmitError with code -501
 	ErrAlreadyExists = NewSubmitError(-501, "Block or transaction already exists and cannot be sent repeatedly.")
 	// ErrOutOfMemory represents SubmitError with code -502
@@ -0,0 +1,19 @@
+package result
+
+import (
+	"github.com/ixje/neo-go-legacy/pkg/util"
+)
+
+// GasEstimate represents the result of the `estimategas` RPC call.
+type GasEstimate struct {
+	// State is the resulting VM state of the test invocation.
+	State string `json:"state"`
+	// GasConsumed is the amount of GAS consumed by the test invocation.
+	GasConsumed util.Fixed8 `json:"gas_consumed"`
+	// FreeGas is the amount of GAS that can be spent for free by the
+	// invocation included in the next block.
+	FreeGas util.Fixed8 `json:"free_gas"`
+	// SystemFee is the amount of GAS that should be attached to the
+	// invocation transaction, it's rounded up to the whole GAS.
+	SystemFee util.Fixed8 `json:"sysfee"`
+}
@@ -1,7 +1,10 @@
 package result
 
 import (
+	"encoding/json"
+
 	"github.com/ixje/neo-go-legacy/pkg/smartcontract"
+	"github.com/ixje/neo-go-legacy/pkg/vm"
 )
 
 // Invoke represents code invocation result and is used by several RPC calls
@@ -11,4 +14,21 @@ type Invoke struct {
 	GasConsumed string                    `json:"gas_consumed"`
 	Script      string                    `json:"script"`
 	Stack       []smartcontract.Parameter `json:"stack"`
+	// TypedStack is a lossless representation of the resulting stack
+	// (see vm.StackItemToJSON). It's omitted if the stack can't be
+	// represented this way (like when it contains recursive structures).
+	TypedStack []json.RawMessage `json:"typedstack,omitempty"`
+}
+
+// StackItems decodes resulting stack items from the TypedStack.
+func (r *Invoke) StackItems() ([]vm.StackItem, error) {
+	items := make([]vm.StackItem, len(r.TypedStack))
+	for i := range r.TypedStack {
+		item, err := vm.StackItemFromJSON(r.TypedStack[i])
+		if err != nil {
+			return nil, err
+		}
+		items[i] = item
+	}
+	return items, nil
 }
@@ -0,0 +1,30 @@
+package result
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ixje/neo-go-legacy/pkg/vm"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInvokeStackItems(t *testing.T) {
+	item := vm.NewArrayItem([]vm.StackItem{vm.NewBigIntegerItem(1), vm.NewBoolItem(true)})
+	data, err := vm.StackItemToJSON(item)
+	require.NoError(t, err)
+
+	r := &Invoke{TypedStack: []json.RawMessage{data}}
+	items, err := r.StackItems()
+	require.NoError(t, err)
+	require.Equal(t, []vm.StackItem{item}, items)
+
+	raw, err := json.Marshal(r)
+	require.NoError(t, err)
+	r2 := new(Invoke)
+	require.NoError(t, json.Unmarshal(raw, r2))
+	require.Equal(t, r, r2)
+
+	r.TypedStack = append(r.TypedStack, json.RawMessage(`{"type":"Unknown"}`))
+	_, err = r.StackItems()
+	require.Error(t, err)
+}
@@ -29,6 +29,7 @@ import (
 	"github.com/ixje/neo-go-legacy/pkg/rpc/response"
 	"github.com/ixje/neo-go-legacy/pkg/rpc/response/result"
 	"github.com/ixje/neo-go-legacy/pkg/util"
+	"github.com/ixje/neo-go-legacy/pkg/vm"
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
 )
@@ -81,6 +82,7 @@ const (
 )
 
 var rpcHandlers = map[string]func(*Server, request.Params) (interface{}, *response.Error){
+	"estimategas":          (*Server).estimateGas,
 	"getaccountstate":      (*Server).getAccountState,
 	"getalltransfertx":     (*Server).getAllTransferTx,
 	"getapplicationlog":    (*Server).getApplicationLog,
@@ -1474,44 +1476,82 @@ func (s *Server) invokeFunction(reqParams request.Params) (interface{}, *respons
 
 // invokescript implements the `invokescript` RPC call.
 func (s *Server) invokescript(reqParams request.Params) (interface{}, *response.Error) {
+	script, hashesForVerifying, respErr := getScriptParams(reqParams)
+	if respErr != nil {
+		return nil, respErr
+	}
+	return s.runScriptInVM(script, hashesForVerifying), nil
+}
+
+// estimateGas implements the `estimategas` RPC call.
+func (s *Server) estimateGas(reqParams request.Params) (interface{}, *response.Error) {
+	script, hashesForVerifying, respErr := getScriptParams(reqParams)
+	if respErr != nil {
+		return nil, respErr
+	}
+	v := s.testInvoke(script, hashesForVerifying)
+	// BlockHeight() is already persisted, so the invocation will be
+	// included in the next one.
+	cfg := s.chain.GetConfig()
+	free := cfg.GetFreeGas(s.chain.BlockHeight() + 1)
+	return &result.GasEstimate{
+		State:       v.State(),
+		GasConsumed: v.GasConsumed(),
+		FreeGas:     free,
+		SystemFee:   core.EstimateSystemFee(v.GasConsumed(), free),
+	}, nil
+}
+
+// getScriptParams parses script and (optional) hashes for verifying
+// parameters used by `invokescript` and `estimategas` RPC calls.
+func getScriptParams(reqParams request.Params) ([]byte, []util.Uint160, *response.Error) {
 	if len(reqParams) < 1 {
-		return nil, response.ErrInvalidParams
+		return nil, nil, response.ErrInvalidParams
 	}
 
 	script, err := reqParams[0].GetBytesHex()
 	if err != nil {
-		return nil, response.ErrInvalidParams
+		return nil, nil, response.ErrInvalidParams
 	}
 
 	hashesForVerifying, err := reqParams.ValueWithType(1, request.ArrayT).GetArrayUint160FromHex()
 	if err != nil {
-		return nil, response.ErrInvalidParams
+		return nil, nil, response.ErrInvalidParams
 	}
-
-	return s.runScriptInVM(script, hashesForVerifying), nil
+	return script, hashesForVerifying, nil
 }
 
-// runScriptInVM runs given script in a new test VM and returns the invocation
-// result.
-func (s *Server) runScriptInVM(script []byte, scriptHashesForVerifying []util.Uint160) *result.Invoke {
+// testInvoke runs given script in a new test VM and returns this VM after
+// execution.
+func (s *Server) testInvoke(script []byte, scriptHashesForVerifying []util.Uint160) *vm.VM {
 	var tx *transaction.Transaction
 	if count := len(scriptHashesForVerifying); count != 0 {
-		tx := new(transaction.Transaction)
+		tx = new(transaction.Transaction)
 		tx.Attributes = make([]transaction.Attribute, count)
-		for i, a := range tx.Attributes {
-			a.Data = scriptHashesForVerifying[i].BytesBE()
-			a.Usage = transaction.Script
+		for i := range tx.Attributes {
+			tx.Attributes[i].Data = scriptHashesForVerifying[i].BytesBE()
+			tx.Attributes[i].Usage = transaction.Script
 		}
 	}
-	vm := s.chain.GetTestVM(tx)
-	vm.SetGasLimit(s.config.MaxGasInvoke)
-	vm.LoadScript(script)
-	_ = vm.Run()
+	v := s.chain.GetTestVM(tx)
+	v.SetGasLimit(s.config.MaxGasInvoke)
+	v.LoadScript(script)
+	_ = v.Run()
+	return v
+}
+
+// runScriptInVM runs given script in a new test VM and returns the invocation
+// result.
+func (s *Server) runScriptInVM(script []byte, scriptHashesForVerifying []util.Uint160) *result.Invoke {
+	v := s.testInvoke(script, scriptHashesForVerifying)
 	result := &result.Invoke{
-		State:       vm.State(),
-		GasConsumed: vm.GasConsumed().String(),
+		State:       v.State(),
+		GasConsumed: v.GasConsumed().String(),
 		Script:      hex.EncodeToString(script),
-		Stack:       vm.Estack().ToContractParameters(),
+		Stack:       v.Estack().ToContractParameters(),
+	}
+	if stack, err := v.Estack().ToJSON(); err == nil {
+		result.TypedStack = stack
 	}
 	return result
 }
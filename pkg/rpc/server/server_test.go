@@ -25,6 +25,7 @@ import (
 	"github.com/ixje/neo-go-legacy/pkg/io"
 	"github.com/ixje/neo-go-legacy/pkg/rpc/response"
 	"github.com/ixje/neo-go-legacy/pkg/rpc/response/result"
+	"github.com/ixje/neo-go-legacy/pkg/smartcontract"
 	"github.com/ixje/neo-go-legacy/pkg/util"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -53,6 +54,31 @@ const testContractHashOld = "b0769b0fe5b510572650d2fc2025ca3f1f0361f6"
 const testContractHash = "80f4f684f9f26a1241abf787331f9c8efeb517bb"
 
 var rpcTestCases = map[string][]rpcTestCase{
+	"estimategas": {
+		{
+			name:   "positive",
+			params: `["51c56b0d48656c6c6f2c20776f726c6421680f4e656f2e52756e74696d652e4c6f67616c7566"]`,
+			result: func(e *executor) interface{} { return &result.GasEstimate{} },
+			check: func(t *testing.T, e *executor, est interface{}) {
+				res, ok := est.(*result.GasEstimate)
+				require.True(t, ok)
+				assert.Equal(t, "HALT", res.State)
+				assert.NotEqual(t, util.Fixed8(0), res.GasConsumed)
+				assert.Equal(t, util.Fixed8(0), res.FreeGas)
+				assert.Equal(t, util.Fixed8(0), res.SystemFee)
+			},
+		},
+		{
+			name:   "no params",
+			params: `[]`,
+			fail:   true,
+		},
+		{
+			name:   "bad string",
+			params: `["qwerty"]`,
+			fail:   true,
+		},
+	},
 	"getapplicationlog": {
 		{
 			name:   "positive",
@@ -837,6 +863,29 @@ var rpcTestCases = map[string][]rpcTestCase{
 				assert.NotEqual(t, 0, res.GasConsumed)
 			},
 		},
+		{
+			name:   "positive, with hashes for verifying",
+			params: `["140102030405060708090a0b0c0d0e0f101112131468184e656f2e52756e74696d652e436865636b5769746e657373", ["14131211100f0e0d0c0b0a090807060504030201"]]`,
+			result: func(e *executor) interface{} { return &result.Invoke{} },
+			check: func(t *testing.T, e *executor, inv interface{}) {
+				res, ok := inv.(*result.Invoke)
+				require.True(t, ok)
+				assert.Equal(t, "HALT", res.State)
+				require.Equal(t, 1, len(res.Stack))
+				assert.Equal(t, smartcontract.BoolType, res.Stack[0].Type)
+				assert.Equal(t, true, res.Stack[0].Value)
+			},
+		},
+		{
+			name:   "no hashes for verifying",
+			params: `["140102030405060708090a0b0c0d0e0f101112131468184e656f2e52756e74696d652e436865636b5769746e657373"]`,
+			result: func(e *executor) interface{} { return &result.Invoke{} },
+			check: func(t *testing.T, e *executor, inv interface{}) {
+				res, ok := inv.(*result.Invoke)
+				require.True(t, ok)
+				assert.Equal(t, "FAULT", res.State)
+			},
+		},
 		{
 			name:   "no params",
 			params: `[]`,
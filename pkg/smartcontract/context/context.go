@@ -19,6 +19,10 @@ import (
 	"github.com/ixje/neo-go-legacy/pkg/wallet"
 )
 
+// txTypePrefix is the prefix of context types for transactions, it's followed
+// by the transaction type name like "Neo.Core.ContractTransaction".
+const txTypePrefix = "Neo.Core."
+
 // ParameterContext represents smartcontract parameter's context.
 type ParameterContext struct {
 	// Type is a type of a verifiable item.
@@ -35,6 +39,11 @@ type paramContext struct {
 	Items map[string]json.RawMessage `json:"items"`
 }
 
+// signable is a verifiable item which has the part to be signed.
+type signable interface {
+	GetSignedPart() []byte
+}
+
 type sigWithIndex struct {
 	index int
 	sig   []byte
@@ -49,6 +58,11 @@ func NewParameterContext(typ string, verif io.Serializable) *ParameterContext {
 	}
 }
 
+// NewTransactionContext returns ParameterContext for the transaction.
+func NewTransactionContext(tx *transaction.Transaction) *ParameterContext {
+	return NewParameterContext(txTypePrefix+tx.Type.String(), tx)
+}
+
 // GetWitness returns invocation and verification scripts for the specified contract.
 func (c *ParameterContext) GetWitness(ctr *wallet.Contract) (*transaction.Witness, error) {
 	item := c.getItemForContract(ctr)
@@ -124,6 +138,21 @@ func (c *ParameterContext) AddSignature(ctr *wallet.Contract, pub *keys.PublicKe
 	return nil
 }
 
+// Sign signs the verifiable item with the signer and adds the signature for
+// the specified contract. The item must have the signed part (like
+// transactions do).
+func (c *ParameterContext) Sign(ctr *wallet.Contract, s wallet.Signer) error {
+	verif, ok := c.Verifiable.(signable)
+	if !ok {
+		return errors.New("verifiable item can't be signed")
+	}
+	sig, err := s.Sign(verif.GetSignedPart())
+	if err != nil {
+		return err
+	}
+	return c.AddSignature(ctr, s.PublicKey(), sig)
+}
+
 func (c *ParameterContext) getItemForContract(ctr *wallet.Contract) *Item {
 	h := ctr.ScriptHash()
 	if item, ok := c.Items[h]; ok {
@@ -176,13 +205,12 @@ func (c *ParameterContext) UnmarshalJSON(data []byte) error {
 		return err
 	}
 
-	var verif io.Serializable
-	switch pc.Type {
-	case "Neo.Core.ContractTransaction":
-		verif = new(transaction.Transaction)
-	default:
-		return fmt.Errorf("unsupported type: %s", c.Type)
+	if !strings.HasPrefix(pc.Type, txTypePrefix) {
+		return fmt.Errorf("unsupported type: %s", pc.Type)
+	} else if _, err := transaction.TXTypeFromString(strings.TrimPrefix(pc.Type, txTypePrefix)); err != nil {
+		return fmt.Errorf("unsupported type: %s", pc.Type)
 	}
+	var verif io.Serializable = new(transaction.Transaction)
 	br := io.NewBinReaderFromBuf(data)
 	verif.DecodeBinary(br)
 	if br.Err != nil {
@@ -2,6 +2,7 @@ package context
 
 import (
 	"encoding/hex"
+	"encoding/json"
 	"testing"
 
 	"github.com/ixje/neo-go-legacy/pkg/core/transaction"
@@ -112,6 +113,25 @@ func TestParameterContext_AddSignatureMultisig(t *testing.T) {
 	})
 }
 
+func TestParameterContext_Sign(t *testing.T) {
+	tx := getContractTx()
+	priv, err := keys.NewPrivateKey()
+	require.NoError(t, err)
+	ctr := &wallet.Contract{
+		Script:     priv.PublicKey().GetVerificationScript(),
+		Parameters: []wallet.ContractParam{newParam(smartcontract.SignatureType, "parameter0")},
+	}
+
+	c := NewTransactionContext(tx)
+	require.NoError(t, c.Sign(ctr, wallet.NewLocalSigner(priv)))
+	require.Equal(t, priv.Sign(tx.GetSignedPart()), c.Items[ctr.ScriptHash()].Parameters[0].Value)
+
+	t.Run("not signable", func(t *testing.T) {
+		c := NewParameterContext("Neo.Core.Witness", new(transaction.Witness))
+		require.Error(t, c.Sign(ctr, wallet.NewLocalSigner(priv)))
+	})
+}
+
 func TestParameterContext_MarshalJSON(t *testing.T) {
 	priv, err := keys.NewPrivateKey()
 	require.NoError(t, err)
@@ -138,6 +158,25 @@ func TestParameterContext_MarshalJSON(t *testing.T) {
 	}
 
 	testserdes.MarshalUnmarshalJSON(t, expected, new(ParameterContext))
+
+	t.Run("invocation", func(t *testing.T) {
+		tx := transaction.NewInvocationTX([]byte{1, 2, 3}, util.Fixed8FromInt64(1))
+		tx.Attributes = make([]transaction.Attribute, 0)
+		tx.Inputs = make([]transaction.Input, 0)
+		tx.Outputs = make([]transaction.Output, 0)
+		tx.Scripts = make([]transaction.Witness, 0)
+		tx.Hash()
+		c := NewTransactionContext(tx)
+		require.Equal(t, "Neo.Core.InvocationTransaction", c.Type)
+		testserdes.MarshalUnmarshalJSON(t, c, new(ParameterContext))
+	})
+	t.Run("unsupported type", func(t *testing.T) {
+		for _, typ := range []string{"Neo.Core.Block", "Neo.Core.FooTransaction", "ContractTransaction"} {
+			data, err := json.Marshal(&ParameterContext{Type: typ, Verifiable: tx})
+			require.NoError(t, err)
+			require.Error(t, json.Unmarshal(data, new(ParameterContext)))
+		}
+	})
 }
 
 func getPrivateKeys(t *testing.T, n int) ([]*keys.PrivateKey, []*keys.PublicKey) {
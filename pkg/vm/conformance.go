@@ -0,0 +1,620 @@
+package vm
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/ixje/neo-go-legacy/pkg/crypto/hash"
+	"github.com/ixje/neo-go-legacy/pkg/util"
+	"github.com/ixje/neo-go-legacy/pkg/vm/emit"
+	"github.com/ixje/neo-go-legacy/pkg/vm/opcode"
+)
+
+// ConformanceResult is the result of running a single test case from the
+// neo-vm JSON test suite.
+type ConformanceResult struct {
+	File     string `json:"file"`
+	Category string `json:"category"`
+	// Name is the name of the test set, for opcode tests it's the name of
+	// the opcode being tested.
+	Name string `json:"name"`
+	Test string `json:"test"`
+	// Passed is true if all the steps of the test case produced the results
+	// recorded in the test file.
+	Passed bool `json:"passed"`
+	// Step is the number of the first failed step.
+	Step int `json:"step,omitempty"`
+	// Diff contains human-readable descriptions of all differences between
+	// expected and actual VM state for the failed step.
+	Diff []string `json:"diff,omitempty"`
+}
+
+// ConformanceSummary is a number of passed and failed test cases for a single
+// test set (opcode).
+type ConformanceSummary struct {
+	Name   string `json:"name"`
+	Passed int    `json:"passed"`
+	Failed int    `json:"failed"`
+}
+
+// ConformanceReport contains results of the neo-vm JSON test suite run.
+type ConformanceReport struct {
+	Results []ConformanceResult `json:"results"`
+}
+
+type (
+	vmUT struct {
+		Category string      `json:"category"`
+		Name     string      `json:"name"`
+		Tests    []vmUTEntry `json:"tests"`
+	}
+
+	vmUTActionType string
+
+	vmUTEntry struct {
+		Name   string
+		Script vmUTScript
+		Steps  []vmUTStep
+		// FIXME remove when NEO 3.0 https://github.com/ixje/neo-go-legacy/issues/477
+		ScriptTable []map[string]vmUTScript
+	}
+
+	vmUTExecutionContextState struct {
+		Instruction        string          `json:"nextInstruction"`
+		InstructionPointer int             `json:"instructionPointer"`
+		AStack             []vmUTStackItem `json:"altStack"`
+		EStack             []vmUTStackItem `json:"evaluationStack"`
+	}
+
+	vmUTExecutionEngineState struct {
+		State           vmUTState                   `json:"state"`
+		ResultStack     []vmUTStackItem             `json:"resultStack"`
+		InvocationStack []vmUTExecutionContextState `json:"invocationStack"`
+	}
+
+	vmUTScript []byte
+
+	vmUTStackItem struct {
+		Type  vmUTStackItemType
+		Value interface{}
+	}
+
+	vmUTStep struct {
+		Actions []vmUTActionType         `json:"actions"`
+		Result  vmUTExecutionEngineState `json:"result"`
+	}
+
+	vmUTState State
+
+	vmUTStackItemType string
+)
+
+// stackItemAUX is used as an intermediate structure
+// to conditionally unmarshal vmUTStackItem based
+// on the value of Type field.
+type stackItemAUX struct {
+	Type  vmUTStackItemType `json:"type"`
+	Value json.RawMessage   `json:"value"`
+}
+
+const (
+	vmExecute  vmUTActionType = "Execute"
+	vmStepInto vmUTActionType = "StepInto"
+	vmStepOut  vmUTActionType = "StepOut"
+	vmStepOver vmUTActionType = "StepOver"
+
+	typeArray     vmUTStackItemType = "Array"
+	typeBoolean   vmUTStackItemType = "Boolean"
+	typeByteArray vmUTStackItemType = "ByteArray"
+	typeInteger   vmUTStackItemType = "Integer"
+	typeInterop   vmUTStackItemType = "Interop"
+	typeMap       vmUTStackItemType = "Map"
+	typeString    vmUTStackItemType = "String"
+	typeStruct    vmUTStackItemType = "Struct"
+
+	// maxDescribeDepth limits nesting level of items printed in diffs, it
+	// also protects from infinite recursion on cyclic structures.
+	maxDescribeDepth = 16
+)
+
+// RunConformanceDir runs all neo-vm JSON test files (*.json) found in the
+// given directory and its subdirectories.
+func RunConformanceDir(dir string) (*ConformanceReport, error) {
+	report := new(ConformanceReport)
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || !strings.HasSuffix(path, ".json") {
+			return nil
+		}
+		res, err := RunConformanceFile(path)
+		if err != nil {
+			return fmt.Errorf("%s: %v", path, err)
+		}
+		report.Results = append(report.Results, res...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(report.Results) == 0 {
+		return nil, fmt.Errorf("no test cases found in %s", dir)
+	}
+	return report, nil
+}
+
+// RunConformanceFile runs all test cases from the given neo-vm JSON test file.
+func RunConformanceFile(filename string) ([]ConformanceResult, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	// FIXME remove when NEO 3.0 https://github.com/ixje/neo-go-legacy/issues/477
+	if len(data) > 2 && data[0] == 0xef && data[1] == 0xbb && data[2] == 0xbf {
+		data = data[3:]
+	}
+
+	ut := new(vmUT)
+	if err := json.Unmarshal(data, ut); err != nil {
+		return nil, err
+	}
+
+	results := make([]ConformanceResult, 0, len(ut.Tests))
+	for i := range ut.Tests {
+		res := ConformanceResult{
+			File:     filename,
+			Category: ut.Category,
+			Name:     ut.Name,
+			Test:     ut.Tests[i].Name,
+		}
+		res.Step, res.Diff = runConformanceTest(&ut.Tests[i])
+		res.Passed = len(res.Diff) == 0
+		if res.Passed {
+			res.Step = 0
+		}
+		results = append(results, res)
+	}
+	return results, nil
+}
+
+// Passed returns true if all test cases in the report have passed.
+func (r *ConformanceReport) Passed() bool {
+	for i := range r.Results {
+		if !r.Results[i].Passed {
+			return false
+		}
+	}
+	return true
+}
+
+// Summary returns the number of passed and failed test cases grouped by test
+// set name (opcode), sorted by name.
+func (r *ConformanceReport) Summary() []ConformanceSummary {
+	var (
+		idx = make(map[string]int)
+		res []ConformanceSummary
+	)
+	for i := range r.Results {
+		n, ok := idx[r.Results[i].Name]
+		if !ok {
+			n = len(res)
+			idx[r.Results[i].Name] = n
+			res = append(res, ConformanceSummary{Name: r.Results[i].Name})
+		}
+		if r.Results[i].Passed {
+			res[n].Passed++
+		} else {
+			res[n].Failed++
+		}
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Name < res[j].Name })
+	return res
+}
+
+// runConformanceTest executes the test case and returns the index of the
+// failed step along with the list of differences found (which is empty if
+// the test has passed).
+func runConformanceTest(test *vmUTEntry) (step int, diff []string) {
+	defer func() {
+		if r := recover(); r != nil {
+			diff = append(diff, fmt.Sprintf("panic: %v", r))
+		}
+	}()
+
+	v := New()
+	v.LoadScript([]byte(test.Script))
+	v.state = breakState
+
+	// FIXME remove when NEO 3.0 https://github.com/ixje/neo-go-legacy/issues/477
+	v.getScript = getConformanceScript(test.ScriptTable)
+	v.RegisterInteropGetter(getConformanceInterop)
+
+	for step = range test.Steps {
+		if err := execConformanceStep(v, test.Steps[step]); err != nil {
+			return step, []string{err.Error()}
+		}
+		diff = compareEngineState(v, &test.Steps[step].Result)
+		if len(diff) != 0 {
+			return step, diff
+		}
+	}
+	return 0, nil
+}
+
+func getConformanceInterop(id uint32) *InteropFuncPrice {
+	// FIXME in NEO 3.0 it is []byte{0x77, 0x77, 0x77, 0x77} https://github.com/ixje/neo-go-legacy/issues/477
+	if id == InteropNameToID([]byte("Test.ExecutionEngine.GetScriptContainer")) ||
+		id == InteropNameToID([]byte("System.ExecutionEngine.GetScriptContainer")) {
+		return &InteropFuncPrice{InteropFunc(func(v *VM) error {
+			v.estack.Push(&Element{value: (*InteropItem)(nil)})
+			return nil
+		}), 0}
+	}
+	return nil
+}
+
+func getConformanceScript(scripts []map[string]vmUTScript) func(util.Uint160) ([]byte, bool) {
+	store := make(map[util.Uint160][]byte)
+	for i := range scripts {
+		for _, v := range scripts[i] {
+			store[hash.Hash160(v)] = []byte(v)
+		}
+	}
+
+	return func(a util.Uint160) ([]byte, bool) { return store[a], true }
+}
+
+func execConformanceStep(v *VM, step vmUTStep) error {
+	for i, a := range step.Actions {
+		var err error
+		switch a {
+		case vmExecute:
+			err = v.Run()
+		case vmStepInto:
+			err = v.StepInto()
+		case vmStepOut:
+			err = v.StepOut()
+		case vmStepOver:
+			err = v.StepOver()
+		default:
+			return fmt.Errorf("invalid action: %s", a)
+		}
+
+		// only the last action is allowed to fail
+		if i+1 < len(step.Actions) && err != nil {
+			return fmt.Errorf("action %d (%s) failed: %v", i, a, err)
+		}
+	}
+	return nil
+}
+
+// compareEngineState compares VM state with the expected one.
+func compareEngineState(v *VM, result *vmUTExecutionEngineState) []string {
+	var diff []string
+
+	if State(result.State) != v.state {
+		diff = append(diff, fmt.Sprintf("state: expected %s, got %s", State(result.State), v.state))
+	}
+	if result.State == vmUTState(faultState) { // do not compare stacks on fault
+		return diff
+	}
+
+	for i, s := range result.InvocationStack {
+		e := v.istack.Peek(i)
+		if e == nil {
+			diff = append(diff, fmt.Sprintf("invocationStack[%d]: expected context, got none", i))
+			continue
+		}
+		ctx := e.Value().(*Context)
+		prefix := fmt.Sprintf("invocationStack[%d].", i)
+		if ctx.nextip < len(ctx.prog) {
+			if s.InstructionPointer != ctx.nextip {
+				diff = append(diff, fmt.Sprintf("%sinstructionPointer: expected %d, got %d",
+					prefix, s.InstructionPointer, ctx.nextip))
+			}
+			if op := opcode.Opcode(ctx.prog[ctx.nextip]).String(); s.Instruction != op {
+				diff = append(diff, fmt.Sprintf("%snextInstruction: expected %s, got %s",
+					prefix, s.Instruction, op))
+			}
+		}
+		diff = append(diff, compareStacks(prefix+"evaluationStack", s.EStack, v.estack)...)
+		diff = append(diff, compareStacks(prefix+"altStack", s.AStack, v.astack)...)
+	}
+
+	if len(result.ResultStack) != 0 {
+		diff = append(diff, compareStacks("resultStack", result.ResultStack, v.estack)...)
+	}
+	return diff
+}
+
+func compareStacks(name string, expected []vmUTStackItem, actual *Stack) []string {
+	if expected == nil {
+		return nil
+	}
+
+	if len(expected) != actual.Len() {
+		return []string{fmt.Sprintf("%s: expected %d items %s, got %d items %s", name,
+			len(expected), describeExpected(expected), actual.Len(), describeStack(actual))}
+	}
+
+	var diff []string
+	for i, item := range expected {
+		e := actual.Peek(i)
+		if e == nil {
+			diff = append(diff, fmt.Sprintf("%s[%d]: expected %s, got nothing", name, i, item.describe()))
+			continue
+		}
+
+		if item.Type == typeInterop {
+			if _, ok := e.value.(*InteropItem); !ok {
+				diff = append(diff, fmt.Sprintf("%s[%d]: expected Interop, got %s", name, i, describeItem(e.value, 0)))
+			}
+			continue
+		}
+		exp, err := item.toStackItem()
+		if err != nil {
+			diff = append(diff, fmt.Sprintf("%s[%d]: %v", name, i, err))
+			continue
+		}
+		if !compareItems(exp, e.value) {
+			diff = append(diff, fmt.Sprintf("%s[%d]: expected %s, got %s", name, i,
+				describeItem(exp, 0), describeItem(e.value, 0)))
+		}
+	}
+	return diff
+}
+
+func compareItems(a, b StackItem) bool {
+	switch si := a.(type) {
+	case *BigIntegerItem:
+		val := si.value
+		switch ac := b.(type) {
+		case *BigIntegerItem:
+			return val.Cmp(ac.value) == 0
+		case *ByteArrayItem:
+			return val.Cmp(emit.BytesToInt(ac.value)) == 0
+		case *BoolItem:
+			if ac.value {
+				return val.Cmp(big.NewInt(1)) == 0
+			}
+			return val.Sign() == 0
+		default:
+			return false
+		}
+	default:
+		return reflect.DeepEqual(a, b)
+	}
+}
+
+func describeExpected(items []vmUTStackItem) string {
+	descs := make([]string, len(items))
+	for i := range items {
+		descs[i] = items[i].describe()
+	}
+	return "[" + strings.Join(descs, ", ") + "]"
+}
+
+func describeStack(s *Stack) string {
+	descs := make([]string, 0, s.Len())
+	s.Iter(func(e *Element) {
+		descs = append(descs, describeItem(e.value, 0))
+	})
+	return "[" + strings.Join(descs, ", ") + "]"
+}
+
+func (v *vmUTStackItem) describe() string {
+	if v.Type == typeInterop {
+		return "Interop"
+	}
+	item, err := v.toStackItem()
+	if err != nil {
+		return fmt.Sprintf("%s(?)", v.Type)
+	}
+	return describeItem(item, 0)
+}
+
+// describeItem returns short human-readable description of the stack item.
+func describeItem(item StackItem, depth int) string {
+	if depth > maxDescribeDepth {
+		return "..."
+	}
+	describeSlice := func(items []StackItem) string {
+		descs := make([]string, len(items))
+		for i := range items {
+			descs[i] = describeItem(items[i], depth+1)
+		}
+		return "[" + strings.Join(descs, ", ") + "]"
+	}
+	switch t := item.(type) {
+	case *BigIntegerItem:
+		return fmt.Sprintf("Integer(%s)", t.value)
+	case *ByteArrayItem:
+		return fmt.Sprintf("ByteArray(0x%s)", hex.EncodeToString(t.value))
+	case *BoolItem:
+		return fmt.Sprintf("Boolean(%t)", t.value)
+	case *ArrayItem:
+		return "Array" + describeSlice(t.value)
+	case *StructItem:
+		return "Struct" + describeSlice(t.value)
+	case *MapItem:
+		descs := make([]string, len(t.value))
+		for i := range t.value {
+			descs[i] = describeItem(t.value[i].Key, depth+1) + ": " + describeItem(t.value[i].Value, depth+1)
+		}
+		return "Map{" + strings.Join(descs, ", ") + "}"
+	case *InteropItem:
+		return "Interop"
+	case nil:
+		return "nil"
+	default:
+		return fmt.Sprintf("%T", item)
+	}
+}
+
+func (v *vmUTStackItem) toStackItem() (StackItem, error) {
+	switch v.Type {
+	case typeArray, typeStruct:
+		items := v.Value.([]vmUTStackItem)
+		result := make([]StackItem, len(items))
+		for i := range items {
+			item, err := items[i].toStackItem()
+			if err != nil {
+				return nil, err
+			}
+			result[i] = item
+		}
+		if v.Type == typeStruct {
+			return &StructItem{value: result}, nil
+		}
+		return &ArrayItem{value: result}, nil
+	case typeMap:
+		items := v.Value.(map[string]vmUTStackItem)
+		result := NewMapItem()
+		for k, v := range items {
+			key, err := decodeBytes([]byte(`"` + k + `"`))
+			if err != nil {
+				return nil, err
+			}
+			val, err := v.toStackItem()
+			if err != nil {
+				return nil, err
+			}
+			result.Add(NewByteArrayItem(key), val)
+		}
+		return result, nil
+	case typeByteArray:
+		return &ByteArrayItem{
+			v.Value.([]byte),
+		}, nil
+	case typeBoolean:
+		return &BoolItem{
+			v.Value.(bool),
+		}, nil
+	case typeInteger:
+		return &BigIntegerItem{
+			value: v.Value.(*big.Int),
+		}, nil
+	default:
+		return nil, fmt.Errorf("can't convert %s item", v.Type)
+	}
+}
+
+func (v *vmUTState) UnmarshalJSON(data []byte) error {
+	switch s := string(data); s {
+	case `"Break"`:
+		*v = vmUTState(breakState)
+	case `"Fault"`:
+		*v = vmUTState(faultState)
+	case `"Halt"`:
+		*v = vmUTState(haltState)
+	default:
+		return fmt.Errorf("invalid state: %s", s)
+	}
+	return nil
+}
+
+func (v *vmUTScript) UnmarshalJSON(data []byte) error {
+	b, err := decodeBytes(data)
+	if err != nil {
+		return err
+	}
+
+	*v = vmUTScript(b)
+	return nil
+}
+
+func (v *vmUTActionType) UnmarshalJSON(data []byte) error {
+	return json.Unmarshal(data, (*string)(v))
+}
+
+func (v *vmUTStackItem) UnmarshalJSON(data []byte) error {
+	var si stackItemAUX
+	if err := json.Unmarshal(data, &si); err != nil {
+		return err
+	}
+
+	v.Type = si.Type
+
+	switch si.Type {
+	case typeArray, typeStruct:
+		var a []vmUTStackItem
+		if err := json.Unmarshal(si.Value, &a); err != nil {
+			return err
+		}
+		v.Value = a
+	case typeInteger:
+		num := new(big.Int)
+		var a int64
+		var s string
+		if err := json.Unmarshal(si.Value, &a); err == nil {
+			num.SetInt64(a)
+		} else if err := json.Unmarshal(si.Value, &s); err == nil {
+			if _, ok := num.SetString(s, 10); !ok {
+				return fmt.Errorf("invalid integer: %s", s)
+			}
+		} else {
+			return fmt.Errorf("invalid integer: %s", si.Value)
+		}
+		v.Value = num
+	case typeBoolean:
+		var b bool
+		if err := json.Unmarshal(si.Value, &b); err != nil {
+			return err
+		}
+		v.Value = b
+	case typeByteArray:
+		b, err := decodeBytes(si.Value)
+		if err != nil {
+			return err
+		}
+		v.Value = b
+	case typeInterop:
+		v.Value = nil
+	case typeMap:
+		var m map[string]vmUTStackItem
+		if err := json.Unmarshal(si.Value, &m); err != nil {
+			return err
+		}
+		v.Value = m
+	case typeString:
+		return errors.New("string items are not supported")
+	default:
+		return fmt.Errorf("unknown type: %s", si.Type)
+	}
+	return nil
+}
+
+// decodeBytes tries to decode bytes from string.
+// It tries hex and base64 encodings.
+func decodeBytes(data []byte) ([]byte, error) {
+	if len(data) < 2 {
+		return nil, errors.New("invalid string")
+	}
+	if len(data) == 2 {
+		return []byte{}, nil
+	}
+
+	if len(data) > 3 {
+		hdata := data[3 : len(data)-1]
+		if b, err := hex.DecodeString(string(hdata)); err == nil {
+			return b, nil
+		}
+	}
+
+	data = data[1 : len(data)-1]
+	r := base64.NewDecoder(base64.StdEncoding, bytes.NewReader(data))
+	return ioutil.ReadAll(r)
+}
@@ -0,0 +1,32 @@
+package vm
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ixje/neo-go-legacy/pkg/vm/emit"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompareItems(t *testing.T) {
+	big1 := new(big.Int).Lsh(big.NewInt(1), 64)
+	big2 := new(big.Int).Lsh(big.NewInt(1), 65)
+	testCases := []struct {
+		a, b  StackItem
+		equal bool
+	}{
+		{&BigIntegerItem{value: big1}, &BigIntegerItem{value: big1}, true},
+		{&BigIntegerItem{value: big1}, &BigIntegerItem{value: big2}, false},
+		{&BigIntegerItem{value: big1}, &BigIntegerItem{value: big.NewInt(0)}, false},
+		{&BigIntegerItem{value: big1}, NewByteArrayItem(emit.IntToBytes(big1)), true},
+		{&BigIntegerItem{value: big1}, NewByteArrayItem(emit.IntToBytes(big2)), false},
+		{&BigIntegerItem{value: big.NewInt(1)}, NewBoolItem(true), true},
+		{&BigIntegerItem{value: big.NewInt(0)}, NewBoolItem(false), true},
+		{&BigIntegerItem{value: big1}, NewBoolItem(false), false},
+		{&BigIntegerItem{value: big1}, NewArrayItem(nil), false},
+	}
+	for _, tc := range testCases {
+		require.Equal(t, tc.equal, compareItems(tc.a, tc.b), "%s vs %s",
+			describeItem(tc.a, 0), describeItem(tc.b, 0))
+	}
+}
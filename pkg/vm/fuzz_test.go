@@ -0,0 +1,190 @@
+//go:build go1.18
+// +build go1.18
+
+package vm
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ixje/neo-go-legacy/pkg/util"
+	"github.com/ixje/neo-go-legacy/pkg/vm/opcode"
+	"github.com/stretchr/testify/require"
+)
+
+// fuzzMaxSteps limits the number of instructions executed for a single fuzzed
+// script, so that infinite loops don't hang the fuzzer.
+const fuzzMaxSteps = 10000
+
+// addScriptSeeds adds all scripts from JSON test files found in dir to the
+// seed corpus.
+func addScriptSeeds(f *testing.F, dir string) {
+	_ = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || !strings.HasSuffix(path, ".json") {
+			return nil
+		}
+		ut := new(vmUT)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil
+		}
+		data = bytes.TrimPrefix(data, []byte{0xef, 0xbb, 0xbf})
+		if json.Unmarshal(data, ut) != nil {
+			return nil
+		}
+		for i := range ut.Tests {
+			f.Add([]byte(ut.Tests[i].Script))
+		}
+		return nil
+	})
+}
+
+// runFuzzScript executes the script with a step limit returning resulting VM
+// and an error (if any).
+func runFuzzScript(script []byte) (*VM, error) {
+	v := New()
+	// Avoid polluting the output.
+	nop := func(v *VM) error {
+		v.Estack().Pop()
+		return nil
+	}
+	v.RegisterInterop("Neo.Runtime.Log", nop, 1)
+	v.RegisterInterop("Neo.Runtime.Notify", nop, 1)
+	v.SetPriceGetter(func(*VM, opcode.Opcode, []byte) util.Fixed8 { return 1 })
+	v.SetGasLimit(fuzzMaxSteps)
+	v.LoadScript(script)
+	return v, v.Run()
+}
+
+// maxExecIntBits is the maximum size of integers produced by the script
+// execution. Arithmetic results are limited to MaxBigIntegerSizeBits, but like
+// in neo-vm 2.x NEGATE, ABS, MIN and MAX don't check their operands, so an
+// integer can be as big as a byte array it was converted from.
+const maxExecIntBits = MaxItemSize * 8
+
+// checkItemLimits checks that the item and all of its descendants respect VM
+// limits, integers must be at most intBits long.
+func checkItemLimits(item StackItem, intBits int, seen map[StackItem]bool) error {
+	if seen[item] {
+		return nil
+	}
+	seen[item] = true
+	switch t := item.(type) {
+	case *ByteArrayItem:
+		if len(t.value) > MaxItemSize {
+			return fmt.Errorf("byte array is too big: %d", len(t.value))
+		}
+	case *BigIntegerItem:
+		if t.value.BitLen() > intBits {
+			return fmt.Errorf("integer is too big: %d bits", t.value.BitLen())
+		}
+	case *ArrayItem, *StructItem:
+		arr := t.Value().([]StackItem)
+		if len(arr) > MaxArraySize {
+			return fmt.Errorf("array is too big: %d", len(arr))
+		}
+		for i := range arr {
+			if err := checkItemLimits(arr[i], intBits, seen); err != nil {
+				return err
+			}
+		}
+	case *MapItem:
+		if len(t.value) > MaxArraySize {
+			return fmt.Errorf("map is too big: %d", len(t.value))
+		}
+		for i := range t.value {
+			if err := checkItemLimits(t.value[i].Key, intBits, seen); err != nil {
+				return err
+			}
+			if err := checkItemLimits(t.value[i].Value, intBits, seen); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func checkVMLimits(v *VM) error {
+	if v.size > MaxStackSize {
+		return fmt.Errorf("stack is too big: %d", v.size)
+	}
+	if v.istack.Len() > MaxInvocationStackSize {
+		return fmt.Errorf("invocation stack is too big: %d", v.istack.Len())
+	}
+	var err error
+	seen := make(map[StackItem]bool)
+	check := func(e *Element) {
+		if err == nil {
+			err = checkItemLimits(e.value, maxExecIntBits, seen)
+		}
+	}
+	v.estack.Iter(check)
+	v.astack.Iter(check)
+	return err
+}
+
+func FuzzExecute(f *testing.F) {
+	addScriptSeeds(f, "testdata")
+	f.Add([]byte{byte(opcode.PUSH1), byte(opcode.PUSH2), byte(opcode.ADD)})
+	f.Add([]byte{byte(opcode.PUSH3), byte(opcode.NEWARRAY), byte(opcode.DUP), byte(opcode.PUSH0),
+		byte(opcode.PUSH5), byte(opcode.SETITEM)})
+	f.Add([]byte{byte(opcode.NEWMAP), byte(opcode.DUP), byte(opcode.PUSH1), byte(opcode.DUP), byte(opcode.SETITEM)})
+	f.Add([]byte{byte(opcode.JMP), 0x00, 0x00})
+
+	f.Fuzz(func(t *testing.T, script []byte) {
+		v1, err1 := runFuzzScript(script)
+		if v1.HasHalted() {
+			require.NoError(t, checkVMLimits(v1))
+		}
+
+		// Execution must be deterministic.
+		v2, err2 := runFuzzScript(script)
+		require.Equal(t, v1.state, v2.state)
+		require.Equal(t, err1 == nil, err2 == nil)
+		require.Equal(t, v1.GasConsumed(), v2.GasConsumed())
+		if v1.HasHalted() {
+			require.Equal(t, describeStack(v1.estack), describeStack(v2.estack))
+		}
+	})
+}
+
+func FuzzDeserializeItem(f *testing.F) {
+	m := NewMapItem()
+	m.Add(NewByteArrayItem([]byte("key")), NewBigIntegerItem(-42))
+	for _, item := range []StackItem{
+		NewBigIntegerItem(0),
+		NewBigIntegerItem(1 << 40),
+		NewBoolItem(true),
+		NewByteArrayItem([]byte("serialized")),
+		NewArrayItem([]StackItem{NewBigIntegerItem(1), NewBoolItem(false)}),
+		NewStructItem([]StackItem{NewArrayItem([]StackItem{}), NewByteArrayItem(nil)}),
+		m,
+	} {
+		data, err := SerializeItem(item)
+		require.NoError(f, err)
+		f.Add(data)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		item, err := DeserializeItem(data)
+		if err != nil {
+			return
+		}
+		require.NoError(t, checkItemLimits(item, MaxBigIntegerSizeBits, make(map[StackItem]bool)))
+
+		// Deserialized item can always be serialized back and this
+		// encoding is stable.
+		data1, err := SerializeItem(item)
+		require.NoError(t, err)
+		item2, err := DeserializeItem(data1)
+		require.NoError(t, err)
+		data2, err := SerializeItem(item2)
+		require.NoError(t, err)
+		require.Equal(t, data1, data2)
+	})
+}
@@ -0,0 +1,254 @@
+package vm
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+)
+
+// MaxJSONDepth is the maximum nesting level of stack items that can be
+// converted to or from JSON.
+const MaxJSONDepth = 16
+
+// Stack item type names used in JSON representation.
+const (
+	jsonIntegerT   = "Integer"
+	jsonBooleanT   = "Boolean"
+	jsonByteArrayT = "ByteArray"
+	jsonArrayT     = "Array"
+	jsonStructT    = "Struct"
+	jsonMapT       = "Map"
+	jsonInteropT   = "InteropInterface"
+)
+
+var (
+	// ErrRecursive is returned when trying to convert recursive
+	// structure to JSON.
+	ErrRecursive = errors.New("recursive structures can't be converted to JSON")
+	// ErrTooDeep is returned when stack item nesting level exceeds
+	// MaxJSONDepth.
+	ErrTooDeep = errors.New("too deep structure")
+	// ErrTooBig is returned when JSON representation of stack item is
+	// bigger than MaxItemSize.
+	ErrTooBig = errors.New("too big item")
+)
+
+// jsonItem is an intermediate representation of the JSON-encoded stack item.
+type jsonItem struct {
+	Type  string          `json:"type"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// jsonMapElement is an intermediate representation of the JSON-encoded
+// map element.
+type jsonMapElement struct {
+	Key   json.RawMessage `json:"key"`
+	Value json.RawMessage `json:"value"`
+}
+
+// StackItemToJSON converts given StackItem to its JSON representation. Every
+// item is encoded as an object with "type" and "value" fields where integers
+// are represented by decimal strings, byte arrays by hex strings, booleans
+// by JSON booleans, arrays and structs by lists of items and maps by lists of
+// objects with "key" and "value" fields. Interop items can't be represented
+// in JSON, so only their type is encoded. The same item can be referenced
+// several times, but recursive structures are not allowed. Aliasing is not
+// preserved, every reference is encoded as a separate copy of the item.
+// Resulting JSON is limited to MaxItemSize bytes and MaxJSONDepth nesting
+// levels.
+func StackItemToJSON(item StackItem) ([]byte, error) {
+	w := &bytes.Buffer{}
+	if err := toJSON(w, item, make(map[StackItem]bool), 0); err != nil {
+		return nil, err
+	}
+	return w.Bytes(), nil
+}
+
+// toJSON writes JSON representation of the item into the buffer. seen
+// contains all compound items on the path from the root to the item.
+func toJSON(w *bytes.Buffer, item StackItem, seen map[StackItem]bool, depth int) error {
+	if depth > MaxJSONDepth {
+		return ErrTooDeep
+	}
+	if w.Len() > MaxItemSize {
+		return ErrTooBig
+	}
+	switch t := item.(type) {
+	case *BigIntegerItem:
+		w.WriteString(`{"type":"` + jsonIntegerT + `","value":"`)
+		w.WriteString(t.value.String())
+		w.WriteString(`"}`)
+	case *BoolItem:
+		w.WriteString(`{"type":"` + jsonBooleanT + `","value":`)
+		w.WriteString(strconv.FormatBool(t.value))
+		w.WriteByte('}')
+	case *ByteArrayItem:
+		if w.Len()+hex.EncodedLen(len(t.value)) > MaxItemSize {
+			return ErrTooBig
+		}
+		w.WriteString(`{"type":"` + jsonByteArrayT + `","value":"`)
+		w.WriteString(hex.EncodeToString(t.value))
+		w.WriteString(`"}`)
+	case *ArrayItem, *StructItem:
+		if seen[item] {
+			return ErrRecursive
+		}
+		seen[item] = true
+		defer delete(seen, item)
+
+		typ := jsonArrayT
+		if _, ok := t.(*StructItem); ok {
+			typ = jsonStructT
+		}
+		w.WriteString(`{"type":"` + typ + `","value":[`)
+		arr := t.Value().([]StackItem)
+		for i := range arr {
+			if i != 0 {
+				w.WriteByte(',')
+			}
+			if err := toJSON(w, arr[i], seen, depth+1); err != nil {
+				return err
+			}
+		}
+		w.WriteString(`]}`)
+	case *MapItem:
+		if seen[item] {
+			return ErrRecursive
+		}
+		seen[item] = true
+		defer delete(seen, item)
+
+		w.WriteString(`{"type":"` + jsonMapT + `","value":[`)
+		for i := range t.value {
+			if i != 0 {
+				w.WriteByte(',')
+			}
+			w.WriteString(`{"key":`)
+			if err := toJSON(w, t.value[i].Key, seen, depth+1); err != nil {
+				return err
+			}
+			w.WriteString(`,"value":`)
+			if err := toJSON(w, t.value[i].Value, seen, depth+1); err != nil {
+				return err
+			}
+			w.WriteByte('}')
+		}
+		w.WriteString(`]}`)
+	case *InteropItem:
+		w.WriteString(`{"type":"` + jsonInteropT + `"}`)
+	default:
+		return fmt.Errorf("unknown stack item type %T", item)
+	}
+	if w.Len() > MaxItemSize {
+		return ErrTooBig
+	}
+	return nil
+}
+
+// StackItemFromJSON decodes StackItem from its JSON representation produced
+// by StackItemToJSON. Interop items are decoded with nil value. Items
+// shared in the original structure are decoded as distinct copies. Input is
+// limited to MaxItemSize bytes, MaxJSONDepth nesting levels and
+// MaxArraySize elements for every array, struct or map.
+func StackItemFromJSON(data []byte) (StackItem, error) {
+	if len(data) > MaxItemSize {
+		return nil, ErrTooBig
+	}
+	return fromJSON(data, 0)
+}
+
+func fromJSON(data []byte, depth int) (StackItem, error) {
+	if depth > MaxJSONDepth {
+		return nil, ErrTooDeep
+	}
+	var ji jsonItem
+	if err := json.Unmarshal(data, &ji); err != nil {
+		return nil, err
+	}
+	if ji.Type != jsonInteropT && len(ji.Value) == 0 {
+		return nil, fmt.Errorf("missing value for %s item", ji.Type)
+	}
+	switch ji.Type {
+	case jsonIntegerT:
+		var s string
+		if err := json.Unmarshal(ji.Value, &s); err != nil {
+			return nil, err
+		}
+		n, ok := new(big.Int).SetString(s, 10)
+		if !ok {
+			return nil, fmt.Errorf("invalid integer: %q", s)
+		}
+		if n.BitLen() > MaxBigIntegerSizeBits {
+			return nil, errors.New("integer is too big")
+		}
+		return &BigIntegerItem{value: n}, nil
+	case jsonBooleanT:
+		var b bool
+		if err := json.Unmarshal(ji.Value, &b); err != nil {
+			return nil, err
+		}
+		return NewBoolItem(b), nil
+	case jsonByteArrayT:
+		var s string
+		if err := json.Unmarshal(ji.Value, &s); err != nil {
+			return nil, err
+		}
+		b, err := hex.DecodeString(s)
+		if err != nil {
+			return nil, err
+		}
+		return NewByteArrayItem(b), nil
+	case jsonArrayT, jsonStructT:
+		var raw []json.RawMessage
+		if err := json.Unmarshal(ji.Value, &raw); err != nil {
+			return nil, err
+		}
+		if len(raw) > MaxArraySize {
+			return nil, errors.New("array is too big")
+		}
+		arr := make([]StackItem, len(raw))
+		for i := range raw {
+			item, err := fromJSON(raw[i], depth+1)
+			if err != nil {
+				return nil, err
+			}
+			arr[i] = item
+		}
+		if ji.Type == jsonArrayT {
+			return NewArrayItem(arr), nil
+		}
+		return NewStructItem(arr), nil
+	case jsonMapT:
+		var raw []jsonMapElement
+		if err := json.Unmarshal(ji.Value, &raw); err != nil {
+			return nil, err
+		}
+		if len(raw) > MaxArraySize {
+			return nil, errors.New("map is too big")
+		}
+		m := NewMapItem()
+		for i := range raw {
+			key, err := fromJSON(raw[i].Key, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			if !isValidMapKey(key) {
+				return nil, errors.New("invalid map key")
+			}
+			value, err := fromJSON(raw[i].Value, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			m.Add(key, value)
+		}
+		return m, nil
+	case jsonInteropT:
+		return NewInteropItem(nil), nil
+	default:
+		return nil, fmt.Errorf("unknown stack item type %q", ji.Type)
+	}
+}
@@ -102,21 +102,25 @@ func DecodeBinaryStackItem(r *io.BinReader) StackItem {
 
 	switch stackItemType(t) {
 	case byteArrayT:
-		data := r.ReadVarBytes()
+		data := r.ReadVarBytes(MaxItemSize)
 		return NewByteArrayItem(data)
 	case booleanT:
 		var b = r.ReadBool()
 		return NewBoolItem(b)
 	case integerT:
-		data := r.ReadVarBytes()
+		data := r.ReadVarBytes(MaxBigIntegerSizeBits / 8)
 		num := emit.BytesToInt(data)
 		return &BigIntegerItem{
 			value: num,
 		}
 	case arrayT, structT:
-		size := int(r.ReadVarUint())
+		size := r.ReadVarUint()
+		if size > MaxArraySize {
+			r.Err = errors.New("array is too big")
+			return nil
+		}
 		arr := make([]StackItem, size)
-		for i := 0; i < size; i++ {
+		for i := range arr {
 			arr[i] = DecodeBinaryStackItem(r)
 		}
 
@@ -125,14 +129,22 @@ func DecodeBinaryStackItem(r *io.BinReader) StackItem {
 		}
 		return &StructItem{value: arr}
 	case mapT:
-		size := int(r.ReadVarUint())
+		size := r.ReadVarUint()
+		if size > MaxArraySize {
+			r.Err = errors.New("map is too big")
+			return nil
+		}
 		m := NewMapItem()
-		for i := 0; i < size; i++ {
+		for i := uint64(0); i < size; i++ {
 			key := DecodeBinaryStackItem(r)
 			value := DecodeBinaryStackItem(r)
 			if r.Err != nil {
 				break
 			}
+			if !isValidMapKey(key) {
+				r.Err = errors.New("invalid map key")
+				break
+			}
 			m.Add(key, value)
 		}
 		return m
@@ -475,6 +475,25 @@ func (s *Stack) ToContractParameters() []smartcontract.Parameter {
 	return items
 }
 
+// ToJSON converts Stack to slice of JSON-encoded items (see StackItemToJSON)
+// in the same order as ToContractParameters does.
+func (s *Stack) ToJSON() ([]json.RawMessage, error) {
+	var err error
+	items := make([]json.RawMessage, 0, s.Len())
+	s.IterBack(func(e *Element) {
+		if err != nil {
+			return
+		}
+		var data []byte
+		data, err = StackItemToJSON(e.value)
+		items = append(items, data)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 // MarshalJSON implements JSON marshalling interface.
 func (s *Stack) MarshalJSON() ([]byte, error) {
 	return json.Marshal(s.ToContractParameters())
@@ -0,0 +1,141 @@
+package vm
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStackItemJSON(t *testing.T) {
+	shared := NewByteArrayItem([]byte{1, 2, 3})
+	m := NewMapItem()
+	m.Add(NewBigIntegerItem(1), NewBoolItem(true))
+	m.Add(NewByteArrayItem([]byte("key")), NewArrayItem([]StackItem{shared, shared}))
+	bigInt, _ := new(big.Int).SetString("-123456789012345678901234567890", 10)
+
+	items := []StackItem{
+		NewBigIntegerItem(0),
+		&BigIntegerItem{value: bigInt},
+		NewBoolItem(false),
+		NewByteArrayItem([]byte{}),
+		shared,
+		NewArrayItem([]StackItem{}),
+		NewStructItem([]StackItem{NewBigIntegerItem(42), NewArrayItem([]StackItem{shared})}),
+		m,
+	}
+	for _, item := range items {
+		data, err := StackItemToJSON(item)
+		require.NoError(t, err)
+		actual, err := StackItemFromJSON(data)
+		require.NoError(t, err)
+		require.Equal(t, item, actual, string(data))
+	}
+
+	t.Run("format", func(t *testing.T) {
+		data, err := StackItemToJSON(NewStructItem([]StackItem{NewBigIntegerItem(-1), m}))
+		require.NoError(t, err)
+		require.JSONEq(t, `{"type":"Struct","value":[
+			{"type":"Integer","value":"-1"},
+			{"type":"Map","value":[
+				{"key":{"type":"Integer","value":"1"},"value":{"type":"Boolean","value":true}},
+				{"key":{"type":"ByteArray","value":"6b6579"},"value":{"type":"Array","value":[
+					{"type":"ByteArray","value":"010203"},
+					{"type":"ByteArray","value":"010203"}]}}]}]}`, string(data))
+	})
+
+	t.Run("interop", func(t *testing.T) {
+		data, err := StackItemToJSON(NewInteropItem(42))
+		require.NoError(t, err)
+		require.JSONEq(t, `{"type":"InteropInterface"}`, string(data))
+		actual, err := StackItemFromJSON(data)
+		require.NoError(t, err)
+		require.Equal(t, NewInteropItem(nil), actual)
+	})
+}
+
+func TestStackItemToJSONErrors(t *testing.T) {
+	t.Run("recursive array", func(t *testing.T) {
+		arr := NewArrayItem([]StackItem{NewBigIntegerItem(1)})
+		arr.value = append(arr.value, NewStructItem([]StackItem{arr}))
+		_, err := StackItemToJSON(arr)
+		require.Equal(t, ErrRecursive, err)
+	})
+	t.Run("recursive map", func(t *testing.T) {
+		m := NewMapItem()
+		m.Add(NewBigIntegerItem(1), m)
+		_, err := StackItemToJSON(m)
+		require.Equal(t, ErrRecursive, err)
+	})
+	t.Run("too deep", func(t *testing.T) {
+		var item StackItem = NewBigIntegerItem(1)
+		for i := 0; i <= MaxJSONDepth; i++ {
+			item = NewArrayItem([]StackItem{item})
+		}
+		_, err := StackItemToJSON(item)
+		require.Equal(t, ErrTooDeep, err)
+	})
+	t.Run("too big", func(t *testing.T) {
+		b := NewByteArrayItem(make([]byte, MaxItemSize/2))
+		_, err := StackItemToJSON(b)
+		require.Equal(t, ErrTooBig, err)
+
+		// Shared references can make JSON grow exponentially.
+		var item StackItem = NewByteArrayItem(make([]byte, 1024))
+		for i := 0; i < 4; i++ {
+			arr := make([]StackItem, 64)
+			for j := range arr {
+				arr[j] = item
+			}
+			item = NewArrayItem(arr)
+		}
+		_, err = StackItemToJSON(item)
+		require.Equal(t, ErrTooBig, err)
+	})
+}
+
+func TestStackItemFromJSONErrors(t *testing.T) {
+	testCases := map[string]string{
+		"invalid JSON":     `{"type":`,
+		"unknown type":     `{"type":"Pointer","value":"1"}`,
+		"missing value":    `{"type":"Integer"}`,
+		"bad integer":      `{"type":"Integer","value":"0x10"}`,
+		"integer as num":   `{"type":"Integer","value":1}`,
+		"bad boolean":      `{"type":"Boolean","value":"true"}`,
+		"bad hex":          `{"type":"ByteArray","value":"xyz"}`,
+		"bad array":        `{"type":"Array","value":{}}`,
+		"bad array item":   `{"type":"Array","value":[{"type":"Integer","value":"z"}]}`,
+		"bad map":          `{"type":"Map","value":[1]}`,
+		"invalid map key":  `{"type":"Map","value":[{"key":{"type":"Array","value":[]},"value":{"type":"Boolean","value":true}}]}`,
+		"bad map value":    `{"type":"Map","value":[{"key":{"type":"Boolean","value":true},"value":{}}]}`,
+		"too big integer":  `{"type":"Integer","value":"1` + strings.Repeat("0", 100) + `"}`,
+		"too big array":    `{"type":"Array","value":[` + strings.Repeat(`{"type":"Boolean","value":true},`, MaxArraySize) + `{"type":"Boolean","value":true}]}`,
+		"too deep":         strings.Repeat(`{"type":"Array","value":[`, MaxJSONDepth+2) + strings.Repeat(`]}`, MaxJSONDepth+2),
+		"too big input":    `{"type":"ByteArray","value":"` + strings.Repeat("00", MaxItemSize/2+1) + `"}`,
+		"interop with map": `{"type":"Map","value":[{"key":{"type":"InteropInterface"},"value":{"type":"Boolean","value":true}}]}`,
+	}
+	for name, data := range testCases {
+		t.Run(name, func(t *testing.T) {
+			_, err := StackItemFromJSON([]byte(data))
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestStackToJSON(t *testing.T) {
+	s := NewStack("test")
+	s.PushVal(1)
+	s.PushVal([]byte{0xab})
+	items, err := s.ToJSON()
+	require.NoError(t, err)
+	require.Equal(t, 2, len(items))
+	require.JSONEq(t, `{"type":"Integer","value":"1"}`, string(items[0]))
+	require.JSONEq(t, `{"type":"ByteArray","value":"ab"}`, string(items[1]))
+
+	m := NewMapItem()
+	m.Add(NewBoolItem(true), m)
+	s.PushVal(m)
+	_, err = s.ToJSON()
+	require.Equal(t, ErrRecursive, err)
+}
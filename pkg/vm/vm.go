@@ -120,6 +120,19 @@ func (v *VM) RegisterInteropGetter(f InteropGetterFunc) {
 	v.getInterop = append(v.getInterop, f)
 }
 
+// RegisterInterop registers the given interop function with the given name
+// and price in v. It's a shortcut for RegisterInteropGetter for a single
+// function, so it takes precedence over all previously registered getters.
+func (v *VM) RegisterInterop(name string, f InteropFunc, price int) {
+	id := InteropNameToID([]byte(name))
+	v.RegisterInteropGetter(func(i uint32) *InteropFuncPrice {
+		if i == id {
+			return &InteropFuncPrice{Func: f, Price: price}
+		}
+		return nil
+	})
+}
+
 // SetPriceGetter registers the given PriceGetterFunc in v.
 // f accepts vm's Context, current instruction and instruction parameter.
 func (v *VM) SetPriceGetter(f func(*VM, opcode.Opcode, []byte) util.Fixed8) {
@@ -64,6 +64,27 @@ func TestRegisterInteropGetter(t *testing.T) {
 	assert.Equal(t, currRegistered+1, len(v.getInterop))
 }
 
+func TestRegisterInterop(t *testing.T) {
+	v := New()
+	v.RegisterInterop("bar", func(evm *VM) error {
+		evm.Estack().PushVal(42)
+		return nil
+	}, 10)
+
+	buf := io.NewBufBinWriter()
+	emit.Syscall(buf.BinWriter, "bar")
+	emit.Opcode(buf.BinWriter, opcode.RET)
+	v.Load(buf.Bytes())
+	runVM(t, v)
+	assert.Equal(t, 1, v.estack.Len())
+	assert.Equal(t, big.NewInt(42), v.estack.Pop().value.Value())
+
+	ifunc := v.GetInteropByID(InteropNameToID([]byte("bar")))
+	require.NotNil(t, ifunc)
+	assert.Equal(t, 10, ifunc.Price)
+	assert.Nil(t, v.GetInteropByID(InteropNameToID([]byte("baz"))))
+}
+
 func TestVM_SetPriceGetter(t *testing.T) {
 	v := New()
 	prog := []byte{
@@ -664,6 +685,36 @@ func TestDeserializeUnknown(t *testing.T) {
 	checkVMFailed(t, vm)
 }
 
+func TestDeserializeInvalid(t *testing.T) {
+	t.Run("BigArray", func(t *testing.T) {
+		_, err := DeserializeItem([]byte{byte(arrayT), 0xfe, 0xff, 0xff, 0xff, 0x7f})
+		require.Error(t, err)
+	})
+	t.Run("BigMap", func(t *testing.T) {
+		_, err := DeserializeItem([]byte{byte(mapT), 0xfd, 0x01, 0x04})
+		require.Error(t, err)
+	})
+	t.Run("ArrayMapKey", func(t *testing.T) {
+		_, err := DeserializeItem([]byte{byte(mapT), 0x01, byte(arrayT), 0x00, byte(booleanT), 0x01})
+		require.Error(t, err)
+	})
+	t.Run("BigByteArray", func(t *testing.T) {
+		_, err := DeserializeItem([]byte{byte(byteArrayT), 0xfe, 0x01, 0x00, 0x10, 0x00})
+		require.Error(t, err)
+	})
+	t.Run("BigInteger", func(t *testing.T) {
+		data := append([]byte{byte(integerT), 33}, make([]byte, 33)...)
+		_, err := DeserializeItem(data)
+		require.Error(t, err)
+
+		data = append([]byte{byte(integerT), 32}, make([]byte, 32)...)
+		data[len(data)-1] = 0x80
+		item, err := DeserializeItem(data)
+		require.NoError(t, err)
+		require.Equal(t, new(big.Int).Neg(new(big.Int).Lsh(big.NewInt(1), 255)), item.Value())
+	})
+}
+
 func TestSerializeMap(t *testing.T) {
 	vm := load(getSerializeProg())
 	item := NewMapItem()
@@ -25,6 +25,9 @@ type Account struct {
 	// NEO public key.
 	publicKey []byte
 
+	// Signer to use instead of the private key.
+	signer Signer
+
 	// Account import file.
 	wif string
 
@@ -47,6 +50,10 @@ type Account struct {
 
 	// Indicates whether the account is the default change account.
 	Default bool `json:"isDefault"`
+
+	// Extra contains additional account data, e.g. the derivation path
+	// for accounts generated from the mnemonic.
+	Extra *AccountExtra `json:"extra,omitempty"`
 }
 
 // Contract represents a subset of the smartcontract to embed in the
@@ -126,16 +133,61 @@ func NewAccount() (*Account, error) {
 	return newAccountFromPrivateKey(priv), nil
 }
 
+// NewWatchOnlyAccount creates an Account for the given script hash without
+// private key, it can only be used to track the balance of the address.
+func NewWatchOnlyAccount(h util.Uint160) *Account {
+	return &Account{
+		Address: address.Uint160ToString(h),
+	}
+}
+
+// IsWatchOnly returns true if the account has no private key.
+func (a *Account) IsWatchOnly() bool {
+	return a.EncryptedWIF == "" && a.privateKey == nil
+}
+
+// ScriptHash returns the script hash of the account. It's the hash of the
+// contract if it's present or the one decoded from the address otherwise.
+func (a *Account) ScriptHash() (util.Uint160, error) {
+	if a.Contract != nil {
+		return a.Contract.ScriptHash(), nil
+	}
+	return address.StringToUint160(a.Address)
+}
+
+// Signer returns the Signer for the account. It's the one set with SetSigner
+// or the one using the private key if the account is decrypted, nil is
+// returned otherwise.
+func (a *Account) Signer() Signer {
+	if a.signer != nil {
+		return a.signer
+	}
+	if a.privateKey != nil {
+		return NewLocalSigner(a.privateKey)
+	}
+	return nil
+}
+
+// SetSigner sets the Signer to be used by the account instead of its private
+// key, e.g. the one of external signer.
+func (a *Account) SetSigner(s Signer) {
+	a.signer = s
+}
+
 // SignTx signs transaction t and updates it's Witnesses.
 func (a *Account) SignTx(t *transaction.Transaction) error {
-	if a.privateKey == nil {
+	s := a.Signer()
+	if s == nil {
 		return errors.New("account is not unlocked")
 	}
 	data := t.GetSignedPart()
 	if data == nil {
 		return errors.New("failed to get transaction's signed part")
 	}
-	sign := a.privateKey.Sign(data)
+	sign, err := s.Sign(data)
+	if err != nil {
+		return err
+	}
 
 	t.Scripts = append(t.Scripts, transaction.Witness{
 		InvocationScript:   append([]byte{byte(opcode.PUSHBYTES64)}, sign...),
@@ -149,18 +201,24 @@ func (a *Account) getVerificationScript() []byte {
 	if a.Contract != nil {
 		return a.Contract.Script
 	}
-	return a.PrivateKey().PublicKey().GetVerificationScript()
+	return a.Signer().PublicKey().GetVerificationScript()
 }
 
 // Decrypt decrypts the EncryptedWIF with the given passphrase returning error
 // if anything goes wrong.
 func (a *Account) Decrypt(passphrase string) error {
+	return a.DecryptWithScrypt(passphrase, keys.NEP2ScryptParams())
+}
+
+// DecryptWithScrypt decrypts the EncryptedWIF with the given passphrase and
+// scrypt parameters returning error if anything goes wrong.
+func (a *Account) DecryptWithScrypt(passphrase string, scrypt keys.ScryptParams) error {
 	var err error
 
 	if a.EncryptedWIF == "" {
 		return errors.New("no encrypted wif in the account")
 	}
-	a.privateKey, err = keys.NEP2Decrypt(a.EncryptedWIF, passphrase)
+	a.privateKey, err = keys.NEP2DecryptWithParams(a.EncryptedWIF, passphrase, scrypt)
 	if err != nil {
 		return err
 	}
@@ -174,7 +232,13 @@ func (a *Account) Decrypt(passphrase string) error {
 // Encrypt encrypts the wallet's PrivateKey with the given passphrase
 // under the NEP-2 standard.
 func (a *Account) Encrypt(passphrase string) error {
-	wif, err := keys.NEP2Encrypt(a.privateKey, passphrase)
+	return a.EncryptWithScrypt(passphrase, keys.NEP2ScryptParams())
+}
+
+// EncryptWithScrypt encrypts the wallet's PrivateKey with the given passphrase
+// under the NEP-2 standard using the given scrypt parameters.
+func (a *Account) EncryptWithScrypt(passphrase string, scrypt keys.ScryptParams) error {
+	wif, err := keys.NEP2EncryptWithParams(a.privateKey, passphrase, scrypt)
 	if err != nil {
 		return err
 	}
@@ -198,7 +262,13 @@ func NewAccountFromWIF(wif string) (*Account, error) {
 
 // NewAccountFromEncryptedWIF creates a new Account from the given encrypted WIF.
 func NewAccountFromEncryptedWIF(wif string, pass string) (*Account, error) {
-	priv, err := keys.NEP2Decrypt(wif, pass)
+	return NewAccountFromEncryptedWIFWithScrypt(wif, pass, keys.NEP2ScryptParams())
+}
+
+// NewAccountFromEncryptedWIFWithScrypt creates a new Account from the given
+// encrypted WIF using the given scrypt parameters.
+func NewAccountFromEncryptedWIFWithScrypt(wif string, pass string, scrypt keys.ScryptParams) (*Account, error) {
+	priv, err := keys.NEP2DecryptWithParams(wif, pass, scrypt)
 	if err != nil {
 		return nil, err
 	}
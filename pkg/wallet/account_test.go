@@ -5,9 +5,11 @@ import (
 	"encoding/json"
 	"testing"
 
+	"github.com/ixje/neo-go-legacy/pkg/core/transaction"
 	"github.com/ixje/neo-go-legacy/pkg/crypto/hash"
 	"github.com/ixje/neo-go-legacy/pkg/crypto/keys"
 	"github.com/ixje/neo-go-legacy/pkg/internal/keytestcases"
+	"github.com/ixje/neo-go-legacy/pkg/util"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -18,6 +20,33 @@ func TestNewAccount(t *testing.T) {
 	require.NotNil(t, acc)
 }
 
+func TestNewWatchOnlyAccount(t *testing.T) {
+	h := util.Uint160{1, 2, 3}
+	acc := NewWatchOnlyAccount(h)
+	require.True(t, acc.IsWatchOnly())
+	require.Nil(t, acc.Contract)
+	require.Error(t, acc.Decrypt("qwerty"))
+	require.Error(t, acc.SignTx(&transaction.Transaction{}))
+
+	actual, err := acc.ScriptHash()
+	require.NoError(t, err)
+	require.Equal(t, h, actual)
+
+	data, err := json.Marshal(acc)
+	require.NoError(t, err)
+	var decoded Account
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	require.True(t, decoded.IsWatchOnly())
+	require.Equal(t, acc.Address, decoded.Address)
+
+	full, err := NewAccount()
+	require.NoError(t, err)
+	require.False(t, full.IsWatchOnly())
+	actual, err = full.ScriptHash()
+	require.NoError(t, err)
+	require.Equal(t, full.Contract.ScriptHash(), actual)
+}
+
 func TestDecryptAccount(t *testing.T) {
 	for _, testCase := range keytestcases.Arr {
 		acc := &Account{EncryptedWIF: testCase.EncryptedWif}
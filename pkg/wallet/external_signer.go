@@ -0,0 +1,394 @@
+package wallet
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ixje/neo-go-legacy/pkg/crypto/keys"
+	"github.com/ixje/neo-go-legacy/pkg/vm"
+)
+
+// DefaultSignerTimeout is the default time the external signer has to
+// answer a request.
+const DefaultSignerTimeout = time.Minute
+
+var errSignerTimeout = errors.New("signer timeout")
+
+// External signer protocol methods.
+const (
+	signerGetPublicKeys = "getpublickeys"
+	signerSign          = "sign"
+)
+
+// signerRequest is a request of the external signer protocol. Requests and
+// responses are JSON objects separated by newlines.
+type signerRequest struct {
+	ID     uint64        `json:"id"`
+	Method string        `json:"method"`
+	Params *signerParams `json:"params,omitempty"`
+}
+
+// signerParams are the parameters of sign request, both are hex-encoded.
+type signerParams struct {
+	Key  string `json:"key"`
+	Data string `json:"data"`
+}
+
+// signerResponse is a response of the external signer protocol. Result is
+// a list of hex-encoded compressed public keys for getpublickeys request
+// and hex-encoded signature for sign request.
+type signerResponse struct {
+	ID     uint64          `json:"id"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  string          `json:"error,omitempty"`
+}
+
+// ExternalSigner is a client for the external signer which keeps keys
+// outside of the process. It talks to the signer over a stream (socket or
+// pipes of the child process) with newline-separated JSON requests:
+//
+//	{"id":1,"method":"getpublickeys"}
+//	{"id":1,"result":["02b3622bf4017bdfe317c58aed5f4c753f206b7db896046fa7d774bbc4bf7f8dc2"]}
+//	{"id":2,"method":"sign","params":{"key":"02b3...8dc2","data":"8000..."}}
+//	{"id":2,"result":"<hex-encoded 64-byte signature>"}
+//
+// Failed requests get a response with "error" field set. Signatures are
+// made for the SHA-256 hash of data, the same way keys.PrivateKey does it.
+// After a timeout or a protocol error the connection can't be used anymore
+// and all subsequent requests fail.
+type ExternalSigner struct {
+	// Timeout is the time the signer has to answer a request,
+	// DefaultSignerTimeout is used by NewExternalSigner.
+	Timeout time.Duration
+
+	lock   sync.Mutex
+	conn   io.ReadWriteCloser
+	enc    *json.Encoder
+	dec    *json.Decoder
+	id     uint64
+	broken error
+}
+
+// deadlineSetter is implemented by connections supporting I/O deadlines
+// like net.Conn.
+type deadlineSetter interface {
+	SetDeadline(t time.Time) error
+}
+
+// NewExternalSigner returns a client for the external signer working via
+// the given connection.
+func NewExternalSigner(conn io.ReadWriteCloser) *ExternalSigner {
+	return &ExternalSigner{
+		Timeout: DefaultSignerTimeout,
+		conn:    conn,
+		enc:     json.NewEncoder(conn),
+		dec:     json.NewDecoder(bufio.NewReader(conn)),
+	}
+}
+
+// DialExternalSigner connects to the external signer at the given address
+// which is either "unix:<socket path>", "tcp:<host:port>" or
+// "exec:<command with arguments>" to start the signer as a child process
+// talking via its stdin and stdout.
+func DialExternalSigner(addr string) (*ExternalSigner, error) {
+	i := strings.IndexByte(addr, ':')
+	if i < 0 {
+		return nil, fmt.Errorf("invalid signer address %q", addr)
+	}
+	switch network, target := addr[:i], addr[i+1:]; network {
+	case "unix", "tcp":
+		conn, err := net.Dial(network, target)
+		if err != nil {
+			return nil, err
+		}
+		return NewExternalSigner(conn), nil
+	case "exec":
+		args := strings.Fields(target)
+		if len(args) == 0 {
+			return nil, errors.New("no signer command")
+		}
+		conn, err := startSignerProcess(exec.Command(args[0], args[1:]...))
+		if err != nil {
+			return nil, err
+		}
+		return NewExternalSigner(conn), nil
+	default:
+		return nil, fmt.Errorf("unsupported signer network %q", network)
+	}
+}
+
+// PublicKeys returns all keys available in the signer.
+func (e *ExternalSigner) PublicKeys() (keys.PublicKeys, error) {
+	var strs []string
+	if err := e.call(signerGetPublicKeys, nil, &strs); err != nil {
+		return nil, err
+	}
+	pubs := make(keys.PublicKeys, len(strs))
+	for i := range strs {
+		var err error
+		if pubs[i], err = keys.NewPublicKeyFromString(strs[i]); err != nil {
+			return nil, fmt.Errorf("bad public key from signer: %v", err)
+		}
+	}
+	return pubs, nil
+}
+
+// Signer returns the Signer using the given key of the external signer.
+func (e *ExternalSigner) Signer(pub *keys.PublicKey) Signer {
+	return &externalKey{signer: e, pub: pub}
+}
+
+// SignerFor returns the Signer for the account, it uses the first key from
+// the account contract that is available in the external signer.
+func (e *ExternalSigner) SignerFor(acc *Account) (Signer, error) {
+	if acc.Contract == nil {
+		return nil, fmt.Errorf("account %s has no contract", acc.Address)
+	}
+	ctrKeys, err := contractKeys(acc.Contract.Script)
+	if err != nil {
+		return nil, err
+	}
+	pubs, err := e.PublicKeys()
+	if err != nil {
+		return nil, err
+	}
+	for _, k := range ctrKeys {
+		if pubs.Contains(k) {
+			return e.Signer(k), nil
+		}
+	}
+	return nil, fmt.Errorf("signer has no key for account %s", acc.Address)
+}
+
+// Close closes connection to the signer.
+func (e *ExternalSigner) Close() error {
+	return e.conn.Close()
+}
+
+func (e *ExternalSigner) call(method string, params *signerParams, result interface{}) error {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if e.broken != nil {
+		return fmt.Errorf("signer connection is broken: %v", e.broken)
+	}
+	e.id++
+	req := signerRequest{ID: e.id, Method: method, Params: params}
+	resp, err := e.roundTrip(&req)
+	if err == nil && resp.ID != req.ID {
+		err = fmt.Errorf("unexpected response id %d from signer (expected %d)", resp.ID, req.ID)
+	}
+	if err != nil {
+		// Responses can't be matched with requests anymore.
+		e.broken = err
+		return err
+	}
+	if resp.Error != "" {
+		return fmt.Errorf("signer error: %s", resp.Error)
+	}
+	if err := json.Unmarshal(resp.Result, result); err != nil {
+		e.broken = fmt.Errorf("bad result from signer: %v", err)
+		return e.broken
+	}
+	return nil
+}
+
+// roundTrip sends the request and reads the response within the timeout.
+// Connections without deadlines support are closed (and signer processes
+// are killed) if the timeout expires.
+func (e *ExternalSigner) roundTrip(req *signerRequest) (*signerResponse, error) {
+	var expired <-chan time.Time
+	if d, ok := e.conn.(deadlineSetter); ok {
+		if err := d.SetDeadline(time.Now().Add(e.Timeout)); err != nil {
+			return nil, err
+		}
+	} else {
+		ch := make(chan time.Time, 1)
+		t := time.AfterFunc(e.Timeout, func() {
+			ch <- time.Now()
+			if p, ok := e.conn.(*signerProcess); ok {
+				_ = p.cmd.Process.Kill()
+			} else {
+				_ = e.conn.Close()
+			}
+		})
+		defer t.Stop()
+		expired = ch
+	}
+
+	resp := new(signerResponse)
+	if err := e.enc.Encode(req); err != nil {
+		return nil, signerIOError("can't send request to signer", err, expired)
+	}
+	if err := e.dec.Decode(resp); err != nil {
+		return nil, signerIOError("can't read response from signer", err, expired)
+	}
+	select {
+	case <-expired:
+		// The response came in time, but the connection is closed already.
+		return nil, errSignerTimeout
+	default:
+	}
+	return resp, nil
+}
+
+// signerIOError returns errSignerTimeout if the I/O error is caused by the
+// timeout and the error with the message given otherwise.
+func signerIOError(msg string, err error, expired <-chan time.Time) error {
+	if ne, ok := err.(net.Error); ok && ne.Timeout() {
+		return errSignerTimeout
+	}
+	select {
+	case <-expired:
+		return errSignerTimeout
+	default:
+		return fmt.Errorf("%s: %v", msg, err)
+	}
+}
+
+// externalKey is a Signer using the key of the external signer.
+type externalKey struct {
+	signer *ExternalSigner
+	pub    *keys.PublicKey
+}
+
+// PublicKey implements Signer interface.
+func (k *externalKey) PublicKey() *keys.PublicKey {
+	return k.pub
+}
+
+// Sign implements Signer interface. The signature returned by the external
+// signer is verified, so that invalid one can't get into the transaction.
+func (k *externalKey) Sign(data []byte) ([]byte, error) {
+	params := &signerParams{
+		Key:  hex.EncodeToString(k.pub.Bytes()),
+		Data: hex.EncodeToString(data),
+	}
+	var s string
+	if err := k.signer.call(signerSign, params, &s); err != nil {
+		return nil, err
+	}
+	sig, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("bad signature from signer: %v", err)
+	}
+	h := sha256.Sum256(data)
+	if !k.pub.Verify(sig, h[:]) {
+		return nil, errors.New("invalid signature from signer")
+	}
+	return sig, nil
+}
+
+// ServeExternalSigner serves external signer protocol requests from rw
+// using the given keys until rw is closed. It's a reference implementation
+// of the protocol that can be used as a local stub signer.
+func ServeExternalSigner(rw io.ReadWriter, privs []*keys.PrivateKey) error {
+	dec := json.NewDecoder(bufio.NewReader(rw))
+	enc := json.NewEncoder(rw)
+	for {
+		var req signerRequest
+		if err := dec.Decode(&req); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		result, err := serveSignerRequest(&req, privs)
+		resp := signerResponse{ID: req.ID}
+		if err != nil {
+			resp.Error = err.Error()
+		} else if resp.Result, err = json.Marshal(result); err != nil {
+			return err
+		}
+		if err := enc.Encode(resp); err != nil {
+			return err
+		}
+	}
+}
+
+func serveSignerRequest(req *signerRequest, privs []*keys.PrivateKey) (interface{}, error) {
+	switch req.Method {
+	case signerGetPublicKeys:
+		pubs := make([]string, len(privs))
+		for i := range privs {
+			pubs[i] = hex.EncodeToString(privs[i].PublicKey().Bytes())
+		}
+		return pubs, nil
+	case signerSign:
+		if req.Params == nil {
+			return nil, errors.New("no parameters")
+		}
+		data, err := hex.DecodeString(req.Params.Data)
+		if err != nil {
+			return nil, fmt.Errorf("bad data: %v", err)
+		}
+		for i := range privs {
+			if hex.EncodeToString(privs[i].PublicKey().Bytes()) == req.Params.Key {
+				return hex.EncodeToString(privs[i].Sign(data)), nil
+			}
+		}
+		return nil, errors.New("unknown key")
+	default:
+		return nil, fmt.Errorf("unknown method %q", req.Method)
+	}
+}
+
+// contractKeys returns public keys used by the standard contract.
+func contractKeys(script []byte) (keys.PublicKeys, error) {
+	var bs [][]byte
+	if vm.IsSignatureContract(script) {
+		bs = [][]byte{script[1:34]}
+	} else if multi, ok := vm.ParseMultiSigContract(script); ok {
+		bs = multi
+	} else {
+		return nil, errors.New("not a standard contract")
+	}
+	pubs := make(keys.PublicKeys, len(bs))
+	for i := range bs {
+		pubs[i] = new(keys.PublicKey)
+		if err := pubs[i].DecodeBytes(bs[i]); err != nil {
+			return nil, err
+		}
+	}
+	return pubs, nil
+}
+
+// signerProcess is a connection to the signer running as a child process.
+type signerProcess struct {
+	io.WriteCloser
+	io.Reader
+	cmd *exec.Cmd
+}
+
+func startSignerProcess(cmd *exec.Cmd) (*signerProcess, error) {
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("can't start signer: %v", err)
+	}
+	return &signerProcess{WriteCloser: stdin, Reader: stdout, cmd: cmd}, nil
+}
+
+// Close closes stdin of the signer process and waits for it to exit.
+func (p *signerProcess) Close() error {
+	if err := p.WriteCloser.Close(); err != nil {
+		return err
+	}
+	return p.cmd.Wait()
+}
@@ -0,0 +1,131 @@
+package wallet
+
+import (
+	"crypto/sha256"
+	"io"
+	"io/ioutil"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/ixje/neo-go-legacy/pkg/core/transaction"
+	"github.com/ixje/neo-go-legacy/pkg/crypto/keys"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestExternalSigner(privs ...*keys.PrivateKey) *ExternalSigner {
+	client, server := net.Pipe()
+	go func() { _ = ServeExternalSigner(server, privs) }()
+	return NewExternalSigner(client)
+}
+
+func TestExternalSigner(t *testing.T) {
+	priv, err := keys.NewPrivateKey()
+	require.NoError(t, err)
+	other, err := keys.NewPrivateKey()
+	require.NoError(t, err)
+
+	s := newTestExternalSigner(priv)
+	defer s.Close()
+
+	pubs, err := s.PublicKeys()
+	require.NoError(t, err)
+	require.Equal(t, keys.PublicKeys{priv.PublicKey()}, pubs)
+
+	data := []byte{1, 2, 3}
+	sig, err := s.Signer(priv.PublicKey()).Sign(data)
+	require.NoError(t, err)
+	h := sha256.Sum256(data)
+	require.True(t, priv.PublicKey().Verify(sig, h[:]))
+
+	_, err = s.Signer(other.PublicKey()).Sign(data)
+	require.Error(t, err)
+
+	t.Run("SignerFor", func(t *testing.T) {
+		acc := newAccountFromPrivateKey(priv)
+		signer, err := s.SignerFor(acc)
+		require.NoError(t, err)
+		require.Equal(t, priv.PublicKey(), signer.PublicKey())
+
+		_, err = s.SignerFor(newAccountFromPrivateKey(other))
+		require.Error(t, err)
+
+		multi := newAccountFromPrivateKey(other)
+		require.NoError(t, multi.ConvertMultisig(1, keys.PublicKeys{priv.PublicKey(), other.PublicKey()}))
+		signer, err = s.SignerFor(multi)
+		require.NoError(t, err)
+		require.Equal(t, priv.PublicKey(), signer.PublicKey())
+
+		_, err = s.SignerFor(NewWatchOnlyAccount(acc.Contract.ScriptHash()))
+		require.Error(t, err)
+	})
+
+	t.Run("SignTx", func(t *testing.T) {
+		acc := NewWatchOnlyAccount(priv.GetScriptHash())
+		acc.Contract = &Contract{Script: priv.PublicKey().GetVerificationScript()}
+		tx := transaction.NewContractTX()
+		require.Error(t, acc.SignTx(tx))
+
+		signer, err := s.SignerFor(acc)
+		require.NoError(t, err)
+		acc.SetSigner(signer)
+		require.NoError(t, acc.SignTx(tx))
+		require.Equal(t, 1, len(tx.Scripts))
+		h := sha256.Sum256(tx.GetSignedPart())
+		require.True(t, priv.PublicKey().Verify(tx.Scripts[0].InvocationScript[1:], h[:]))
+	})
+}
+
+func TestExternalSignerBroken(t *testing.T) {
+	// noDeadline hides SetDeadline of the connection.
+	type noDeadline struct{ io.ReadWriteCloser }
+
+	t.Run("Timeout", func(t *testing.T) {
+		client, server := net.Pipe()
+		go func() { _, _ = io.Copy(ioutil.Discard, server) }()
+		s := NewExternalSigner(client)
+		s.Timeout = 10 * time.Millisecond
+		defer s.Close()
+
+		_, err := s.PublicKeys()
+		require.Equal(t, errSignerTimeout, err)
+		_, err = s.PublicKeys()
+		require.EqualError(t, err, "signer connection is broken: "+errSignerTimeout.Error())
+	})
+	t.Run("TimeoutNoDeadline", func(t *testing.T) {
+		client, server := net.Pipe()
+		go func() { _, _ = io.Copy(ioutil.Discard, server) }()
+		s := NewExternalSigner(noDeadline{client})
+		s.Timeout = 10 * time.Millisecond
+
+		_, err := s.PublicKeys()
+		require.Equal(t, errSignerTimeout, err)
+		_, err = s.PublicKeys()
+		require.EqualError(t, err, "signer connection is broken: "+errSignerTimeout.Error())
+	})
+	t.Run("BadResponse", func(t *testing.T) {
+		priv, err := keys.NewPrivateKey()
+		require.NoError(t, err)
+		client, server := net.Pipe()
+		go func() {
+			buf := make([]byte, 1024)
+			_, _ = server.Read(buf)
+			_, _ = server.Write([]byte("{\"id\":1,\"result\":[\"\n"))
+			_ = ServeExternalSigner(server, []*keys.PrivateKey{priv})
+		}()
+		s := NewExternalSigner(client)
+		defer s.Close()
+
+		_, err = s.PublicKeys()
+		require.Error(t, err)
+		_, err = s.PublicKeys()
+		require.Contains(t, err.Error(), "signer connection is broken")
+	})
+}
+
+func TestDialExternalSigner(t *testing.T) {
+	for _, addr := range []string{"", "localhost", "udp:localhost:1", "exec:", "exec:/nonexistent-signer"} {
+		_, err := DialExternalSigner(addr)
+		require.Error(t, err, addr)
+	}
+}
@@ -0,0 +1,142 @@
+package wallet
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ixje/neo-go-legacy/pkg/crypto/keys"
+	"github.com/ixje/neo-go-legacy/pkg/util"
+	"github.com/tyler-smith/go-bip39"
+)
+
+const (
+	// CoinType is NEO coin type as registered in SLIP-0044.
+	CoinType = 888
+
+	// DefaultGapLimit is the number of consecutive unused addresses after
+	// which account discovery stops, as recommended by BIP-0044.
+	DefaultGapLimit = 20
+
+	// mnemonicEntropySize is the entropy size in bits for new mnemonics,
+	// it corresponds to 12 words.
+	mnemonicEntropySize = 128
+)
+
+// AccountExtra is the NEP-6 extra data of the account.
+type AccountExtra struct {
+	// DerivationPath is BIP-0032 path of the account key derived from
+	// the mnemonic.
+	DerivationPath string `json:"derivationPath,omitempty"`
+}
+
+// NewMnemonic generates a new random BIP-0039 mnemonic.
+func NewMnemonic() (string, error) {
+	entropy, err := bip39.NewEntropy(mnemonicEntropySize)
+	if err != nil {
+		return "", err
+	}
+	return bip39.NewMnemonic(entropy)
+}
+
+// DerivationPath returns BIP-0044 path of the external chain account with
+// the given index, that is m/44'/888'/0'/0/index.
+func DerivationPath(index uint32) string {
+	return keys.FormatDerivationPath([]uint32{
+		keys.HardenedKeyStart + 44,
+		keys.HardenedKeyStart + CoinType,
+		keys.HardenedKeyStart,
+		0,
+		index,
+	})
+}
+
+// NewAccountFromMnemonic creates an Account with the key derived from the
+// mnemonic and optional passphrase using DerivationPath with the given index.
+func NewAccountFromMnemonic(mnemonic, passphrase string, index uint32) (*Account, error) {
+	root, err := newMasterKey(mnemonic, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return deriveAccount(root, index)
+}
+
+// DerivationIndex returns the index of the account derived using
+// DerivationPath. False is returned if the account wasn't derived from
+// the mnemonic or has some other path.
+func (a *Account) DerivationIndex() (uint32, bool) {
+	if a.Extra == nil || a.Extra.DerivationPath == "" {
+		return 0, false
+	}
+	indices, err := keys.ParseDerivationPath(a.Extra.DerivationPath)
+	if err != nil || len(indices) != 5 {
+		return 0, false
+	}
+	index := indices[4]
+	if DerivationPath(index) != a.Extra.DerivationPath {
+		return 0, false
+	}
+	return index, true
+}
+
+// NextDerivationIndex returns the index of the account to derive next,
+// it follows the highest index among the wallet accounts.
+func (w *Wallet) NextDerivationIndex() uint32 {
+	var next uint32
+	for _, acc := range w.Accounts {
+		if i, ok := acc.DerivationIndex(); ok && i >= next {
+			next = i + 1
+		}
+	}
+	return next
+}
+
+// FindUsedAccounts derives accounts from the mnemonic one by one and returns
+// the ones used according to the provided function. The search stops after
+// gap consecutive unused accounts, DefaultGapLimit is used if gap is not
+// positive. All returned accounts are derived with DerivationPath.
+func FindUsedAccounts(mnemonic, passphrase string, gap int, used func(util.Uint160) (bool, error)) ([]*Account, error) {
+	if gap <= 0 {
+		gap = DefaultGapLimit
+	}
+	root, err := newMasterKey(mnemonic, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	var accs []*Account
+	for i, unused := uint32(0), 0; unused < gap; i++ {
+		acc, err := deriveAccount(root, i)
+		if err != nil {
+			return nil, err
+		}
+		ok, err := used(acc.Contract.ScriptHash())
+		if err != nil {
+			return nil, fmt.Errorf("can't check account %s: %v", acc.Address, err)
+		}
+		if !ok {
+			unused++
+			continue
+		}
+		unused = 0
+		accs = append(accs, acc)
+	}
+	return accs, nil
+}
+
+func newMasterKey(mnemonic, passphrase string) (*keys.ExtendedKey, error) {
+	seed, err := bip39.NewSeedWithErrorChecking(mnemonic, passphrase)
+	if err != nil {
+		return nil, errors.New("invalid mnemonic")
+	}
+	return keys.NewMasterKey(seed), nil
+}
+
+func deriveAccount(root *keys.ExtendedKey, index uint32) (*Account, error) {
+	path := DerivationPath(index)
+	k, err := root.Derive(path)
+	if err != nil {
+		return nil, err
+	}
+	acc := newAccountFromPrivateKey(k.PrivateKey)
+	acc.Extra = &AccountExtra{DerivationPath: path}
+	return acc, nil
+}
@@ -0,0 +1,111 @@
+package wallet
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ixje/neo-go-legacy/pkg/util"
+	"github.com/stretchr/testify/require"
+	"github.com/tyler-smith/go-bip39"
+)
+
+const testMnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+
+func TestNewMnemonic(t *testing.T) {
+	m, err := NewMnemonic()
+	require.NoError(t, err)
+	require.True(t, bip39.IsMnemonicValid(m))
+
+	m2, err := NewMnemonic()
+	require.NoError(t, err)
+	require.NotEqual(t, m, m2)
+}
+
+func TestNewAccountFromMnemonic(t *testing.T) {
+	acc, err := NewAccountFromMnemonic(testMnemonic, "", 3)
+	require.NoError(t, err)
+	require.Equal(t, "m/44'/888'/0'/0/3", acc.Extra.DerivationPath)
+	i, ok := acc.DerivationIndex()
+	require.True(t, ok)
+	require.EqualValues(t, 3, i)
+
+	same, err := NewAccountFromMnemonic(testMnemonic, "", 3)
+	require.NoError(t, err)
+	require.Equal(t, acc.Address, same.Address)
+
+	other, err := NewAccountFromMnemonic(testMnemonic, "", 4)
+	require.NoError(t, err)
+	require.NotEqual(t, acc.Address, other.Address)
+
+	withPass, err := NewAccountFromMnemonic(testMnemonic, "pass", 3)
+	require.NoError(t, err)
+	require.NotEqual(t, acc.Address, withPass.Address)
+
+	_, err = NewAccountFromMnemonic("abandon abandon abandon", "", 0)
+	require.Error(t, err)
+
+	t.Run("JSON", func(t *testing.T) {
+		data, err := json.Marshal(acc)
+		require.NoError(t, err)
+		require.Contains(t, string(data), `"extra":{"derivationPath":"m/44'/888'/0'/0/3"}`)
+
+		var actual Account
+		require.NoError(t, json.Unmarshal(data, &actual))
+		require.Equal(t, acc.Extra, actual.Extra)
+
+		plain, err := NewAccount()
+		require.NoError(t, err)
+		data, err = json.Marshal(plain)
+		require.NoError(t, err)
+		require.NotContains(t, string(data), `"extra"`)
+	})
+}
+
+func TestWallet_NextDerivationIndex(t *testing.T) {
+	w := &Wallet{}
+	require.EqualValues(t, 0, w.NextDerivationIndex())
+
+	plain, err := NewAccount()
+	require.NoError(t, err)
+	w.AddAccount(plain)
+	require.EqualValues(t, 0, w.NextDerivationIndex())
+
+	for _, i := range []uint32{0, 5, 2} {
+		acc, err := NewAccountFromMnemonic(testMnemonic, "", i)
+		require.NoError(t, err)
+		w.AddAccount(acc)
+	}
+	require.EqualValues(t, 6, w.NextDerivationIndex())
+
+	plain.Extra = &AccountExtra{DerivationPath: "m/44'/888'/1'/0/10"}
+	require.EqualValues(t, 6, w.NextDerivationIndex())
+}
+
+func TestFindUsedAccounts(t *testing.T) {
+	used := make(map[util.Uint160]bool)
+	for _, i := range []uint32{0, 1, 4} {
+		acc, err := NewAccountFromMnemonic(testMnemonic, "", i)
+		require.NoError(t, err)
+		used[acc.Contract.ScriptHash()] = true
+	}
+	var checked int
+	isUsed := func(h util.Uint160) (bool, error) {
+		checked++
+		return used[h], nil
+	}
+
+	accs, err := FindUsedAccounts(testMnemonic, "", 3, isUsed)
+	require.NoError(t, err)
+	require.Equal(t, 3, len(accs))
+	require.Equal(t, 8, checked)
+	for i, expected := range []uint32{0, 1, 4} {
+		index, ok := accs[i].DerivationIndex()
+		require.True(t, ok)
+		require.Equal(t, expected, index)
+	}
+
+	// Index 4 is beyond the gap.
+	accs, err = FindUsedAccounts(testMnemonic, "", 2, isUsed)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(accs))
+}
@@ -0,0 +1,35 @@
+package wallet
+
+import (
+	"github.com/ixje/neo-go-legacy/pkg/crypto/keys"
+)
+
+// Signer signs data with a single key. The key itself may be kept outside of
+// the process (in HSM, hardware wallet or some remote service), so signing
+// can fail.
+type Signer interface {
+	// PublicKey returns the public key corresponding to the signing key.
+	PublicKey() *keys.PublicKey
+	// Sign returns the signature of SHA-256 hash of data.
+	Sign(data []byte) ([]byte, error)
+}
+
+// localSigner is a Signer using the private key stored in memory.
+type localSigner struct {
+	priv *keys.PrivateKey
+}
+
+// NewLocalSigner returns a Signer using the given private key.
+func NewLocalSigner(priv *keys.PrivateKey) Signer {
+	return localSigner{priv: priv}
+}
+
+// PublicKey implements Signer interface.
+func (s localSigner) PublicKey() *keys.PublicKey {
+	return s.priv.PublicKey()
+}
+
+// Sign implements Signer interface.
+func (s localSigner) Sign(data []byte) ([]byte, error) {
+	return s.priv.Sign(data), nil
+}
@@ -3,7 +3,9 @@ package wallet
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 
 	"github.com/ixje/neo-go-legacy/pkg/crypto/keys"
@@ -44,13 +46,22 @@ type Extra struct {
 	Tokens []*Token
 }
 
-// NewWallet creates a new NEO wallet at the given location.
+// NewWallet creates a new NEO wallet at the given location with the default
+// NEP-2 scrypt parameters.
 func NewWallet(location string) (*Wallet, error) {
+	return NewWalletWithScrypt(location, keys.NEP2ScryptParams())
+}
+
+// NewWalletWithScrypt creates a new NEO wallet at the given location, keys
+// of its accounts are encrypted with the given scrypt parameters.
+func NewWalletWithScrypt(location string, scrypt keys.ScryptParams) (*Wallet, error) {
 	file, err := os.Create(location)
 	if err != nil {
 		return nil, err
 	}
-	return newWallet(file), nil
+	w := newWallet(file)
+	w.Scrypt = scrypt
+	return w, nil
 }
 
 // NewWalletFromFile creates a Wallet from the given wallet file path
@@ -91,7 +102,7 @@ func (w *Wallet) CreateAccount(name, passphrase string) error {
 		return err
 	}
 	acc.Label = name
-	if err := acc.Encrypt(passphrase); err != nil {
+	if err := acc.EncryptWithScrypt(passphrase, w.Scrypt); err != nil {
 		return err
 	}
 	w.AddAccount(acc)
@@ -150,6 +161,103 @@ func (w *Wallet) Save() error {
 	return json.NewEncoder(w.rw).Encode(w)
 }
 
+// Rewrite atomically replaces the wallet file with the current wallet data,
+// so that it's either completely updated or not changed at all. The previous
+// version of the file is kept at backup path if it's not empty.
+func (w *Wallet) Rewrite(backup string) error {
+	if w.path == "" {
+		return errors.New("wallet has no file")
+	}
+	data, err := json.Marshal(w)
+	if err != nil {
+		return err
+	}
+	fi, err := os.Stat(w.path)
+	if err != nil {
+		return err
+	}
+	if backup != "" {
+		old, err := ioutil.ReadFile(w.path)
+		if err != nil {
+			return err
+		}
+		if err := writeFileSync(backup, old, fi.Mode().Perm()); err != nil {
+			return fmt.Errorf("can't create backup: %v", err)
+		}
+	}
+	tmp := w.path + ".tmp"
+	if err := writeFileSync(tmp, data, fi.Mode().Perm()); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, w.path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	// The old file is replaced, so it's reopened to continue working with it.
+	file, err := os.OpenFile(w.path, os.O_RDWR, os.ModeAppend)
+	if err != nil {
+		return err
+	}
+	w.Close()
+	w.rw = file
+	return nil
+}
+
+// writeFileSync writes data to the file and flushes it to the disk.
+func writeFileSync(path string, data []byte, perm os.FileMode) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// ChangePassword re-encrypts keys of the given accounts with the new
+// password. None of the accounts is changed if any of them can't be
+// decrypted with the old password. Watch-only accounts are skipped.
+func (w *Wallet) ChangePassword(accs []*Account, oldPass, newPass string) error {
+	return w.reEncrypt(accs, oldPass, newPass, w.Scrypt)
+}
+
+// ChangeScrypt re-encrypts keys of all wallet accounts with the new scrypt
+// parameters keeping the password, parameters are only changed if all
+// accounts can be decrypted with it.
+func (w *Wallet) ChangeScrypt(pass string, scrypt keys.ScryptParams) error {
+	return w.reEncrypt(w.Accounts, pass, pass, scrypt)
+}
+
+func (w *Wallet) reEncrypt(accs []*Account, oldPass, newPass string, scrypt keys.ScryptParams) error {
+	wifs := make([]string, len(accs))
+	for i, acc := range accs {
+		if acc.IsWatchOnly() {
+			continue
+		}
+		priv, err := keys.NEP2DecryptWithParams(acc.EncryptedWIF, oldPass, w.Scrypt)
+		if err != nil {
+			return fmt.Errorf("account %s: %v", acc.Address, err)
+		}
+		if wifs[i], err = keys.NEP2EncryptWithParams(priv, newPass, scrypt); err != nil {
+			return fmt.Errorf("account %s: %v", acc.Address, err)
+		}
+	}
+	for i := range accs {
+		if wifs[i] != "" {
+			accs[i].EncryptedWIF = wifs[i]
+		}
+	}
+	w.Scrypt = scrypt
+	return nil
+}
+
 // JSON outputs a pretty JSON representation of the wallet.
 func (w *Wallet) JSON() ([]byte, error) {
 	return json.MarshalIndent(w, " ", "	")
@@ -165,7 +273,7 @@ func (w *Wallet) Close() {
 // GetAccount returns account corresponding to the provided scripthash.
 func (w *Wallet) GetAccount(h util.Uint160) *Account {
 	for _, acc := range w.Accounts {
-		if c := acc.Contract; c != nil && h.Equals(c.ScriptHash()) {
+		if ah, err := acc.ScriptHash(); err == nil && h.Equals(ah) {
 			return acc
 		}
 	}
@@ -4,4 +4,7 @@ package wallet
 type Config struct {
 	Path     string `yaml:"Path"`
 	Password string `yaml:"Password"`
+	// Signer is an address of the external signer (see DialExternalSigner)
+	// holding account keys, Password is not used if it's set.
+	Signer string `yaml:"Signer"`
 }
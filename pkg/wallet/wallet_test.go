@@ -4,8 +4,10 @@ import (
 	"encoding/json"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/ixje/neo-go-legacy/pkg/crypto/keys"
 	"github.com/ixje/neo-go-legacy/pkg/encoding/address"
 	"github.com/ixje/neo-go-legacy/pkg/util"
 	"github.com/stretchr/testify/assert"
@@ -104,11 +106,76 @@ func TestSave(t *testing.T) {
 		w2, err := NewWalletFromFile(openedWallet.path)
 		require.NoError(t, err)
 		require.Equal(t, 2, len(w2.Accounts))
-		require.NoError(t, w2.Accounts[1].Decrypt("pass"))
+		require.NoError(t, w2.Accounts[1].DecryptWithScrypt("pass", w2.Scrypt))
 		require.Equal(t, openedWallet.Accounts, w2.Accounts)
 	})
 }
 
+func TestWallet_ReEncrypt(t *testing.T) {
+	dir, err := ioutil.TempDir("", walletTemplate)
+	require.NoError(t, err)
+	defer removeWallet(t, dir)
+
+	path := filepath.Join(dir, "wallet.json")
+	scrypt := keys.ScryptParams{N: 2, R: 1, P: 1}
+	w, err := NewWalletWithScrypt(path, scrypt)
+	require.NoError(t, err)
+	defer w.Close()
+	require.NoError(t, w.CreateAccount("first", "one"))
+	require.NoError(t, w.CreateAccount("second", "one"))
+	w.AddAccount(NewWatchOnlyAccount(util.Uint160{1, 2, 3}))
+	require.NoError(t, w.Save())
+	require.NoError(t, w.Accounts[0].DecryptWithScrypt("one", scrypt))
+	// Default parameters are not used.
+	require.Error(t, w.Accounts[0].Decrypt("one"))
+
+	t.Run("bad password", func(t *testing.T) {
+		wifs := []string{w.Accounts[0].EncryptedWIF, w.Accounts[1].EncryptedWIF}
+		require.Error(t, w.ChangePassword(w.Accounts, "two", "three"))
+		require.Error(t, w.ChangeScrypt("two", keys.ScryptParams{N: 4, R: 1, P: 1}))
+		require.Equal(t, wifs, []string{w.Accounts[0].EncryptedWIF, w.Accounts[1].EncryptedWIF})
+		require.Equal(t, scrypt, w.Scrypt)
+	})
+	t.Run("change password", func(t *testing.T) {
+		require.NoError(t, w.ChangePassword(w.Accounts[1:], "one", "two"))
+		require.NoError(t, w.Accounts[0].DecryptWithScrypt("one", scrypt))
+		require.NoError(t, w.Accounts[1].DecryptWithScrypt("two", scrypt))
+	})
+	t.Run("change scrypt", func(t *testing.T) {
+		newScrypt := keys.ScryptParams{N: 4, R: 1, P: 1}
+		// Accounts have different passwords.
+		require.Error(t, w.ChangeScrypt("one", newScrypt))
+		require.NoError(t, w.ChangePassword(w.Accounts[1:], "two", "one"))
+		require.NoError(t, w.ChangeScrypt("one", newScrypt))
+		require.Equal(t, newScrypt, w.Scrypt)
+		require.NoError(t, w.Accounts[0].DecryptWithScrypt("one", newScrypt))
+		require.NoError(t, w.Accounts[1].DecryptWithScrypt("one", newScrypt))
+	})
+	t.Run("rewrite", func(t *testing.T) {
+		backup := path + ".bak"
+		require.NoError(t, w.Rewrite(backup))
+
+		old, err := NewWalletFromFile(backup)
+		require.NoError(t, err)
+		defer old.Close()
+		require.Equal(t, scrypt, old.Scrypt)
+
+		actual, err := NewWalletFromFile(path)
+		require.NoError(t, err)
+		defer actual.Close()
+		require.Equal(t, w.Scrypt, actual.Scrypt)
+		require.NoError(t, actual.Accounts[1].DecryptWithScrypt("one", actual.Scrypt))
+
+		// The wallet is still usable after rewrite.
+		w.Accounts[0].Label = "renamed"
+		require.NoError(t, w.Save())
+		actual, err = NewWalletFromFile(path)
+		require.NoError(t, err)
+		defer actual.Close()
+		require.Equal(t, "renamed", actual.Accounts[0].Label)
+	})
+}
+
 func TestJSONMarshallUnmarshal(t *testing.T) {
 	wallet := checkWalletConstructor(t)
 
@@ -174,6 +241,10 @@ func TestWallet_GetAccount(t *testing.T) {
 		h := acc.Contract.ScriptHash()
 		assert.Equal(t, acc, wallet.GetAccount(h), "can't get %d account", i)
 	}
+
+	watchOnly := NewWatchOnlyAccount(util.Uint160{1, 2, 3})
+	wallet.AddAccount(watchOnly)
+	assert.Equal(t, watchOnly, wallet.GetAccount(util.Uint160{1, 2, 3}))
 }
 
 func TestWalletGetChangeAddress(t *testing.T) {